@@ -5,10 +5,21 @@ import (
 	"log"
 
 	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
+	"github.com/phamdinhha/event-booking-service/internal/notification"
+	"github.com/phamdinhha/event-booking-service/internal/outbox"
+	"github.com/phamdinhha/event-booking-service/internal/payment"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
 	"github.com/phamdinhha/event-booking-service/internal/server"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/internal/worker"
 	"github.com/phamdinhha/event-booking-service/pkg/db/postgres"
 	"github.com/phamdinhha/event-booking-service/pkg/db/redis_client"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 )
 
 func main() {
@@ -35,6 +46,11 @@ func main() {
 		cfg.Redis.Host,
 	)
 
+	tracerProvider, err := tracing.NewTracerProvider(cfg)
+	if err != nil {
+		appLogger.Fatalf("Error creating tracer provider: %v", err)
+	}
+
 	db, err := postgres.NewPostgresDB(cfg)
 	if err != nil {
 		appLogger.Fatalf("Error connecting to db: %v", err)
@@ -49,13 +65,91 @@ func main() {
 	}
 
 	redisClient := redis_client.NewRedisClient(cfg)
+	// Commands are traced without their arguments, idempotency records hold
+	// response bodies.
+	if err := redisotel.InstrumentTracing(redisClient, redisotel.WithDBStatement(false)); err != nil {
+		appLogger.Fatalf("Error tracing redis: %v", err)
+	}
 	if err := redisClient.Ping(context.TODO()).Err(); err != nil {
 		appLogger.Fatalf("Error connecting to redis: %v", err)
 	}
-	// Apply migrations
-	//
-	server := server.NewServer(appLogger, cfg, redisClient, db)
+
+	if err := metrics.RegisterPools(db, redisClient); err != nil {
+		appLogger.Fatalf("Error registering pool metrics: %v", err)
+	}
+
+	gateway, err := payment.NewGateway(cfg)
+	if err != nil {
+		appLogger.Fatalf("Error creating payment gateway: %v", err)
+	}
+
+	publisher, err := outbox.NewPublisher(cfg, redisClient)
+	if err != nil {
+		appLogger.Fatalf("Error creating outbox publisher: %v", err)
+	}
+
+	sender, err := notification.NewSender(cfg, appLogger)
+	if err != nil {
+		appLogger.Fatalf("Error creating notification sender: %v", err)
+	}
+	templates, err := notification.NewTemplates()
+	if err != nil {
+		appLogger.Fatalf("Error loading notification templates: %v", err)
+	}
+
+	verifier, err := auth.NewVerifier(cfg)
+	if err != nil {
+		appLogger.Fatalf("Error creating token verifier: %v", err)
+	}
+
+	ctx := context.Background()
+
+	bookingRepo := repository.NewBookingRepository(db, appLogger)
+	eventRepo := repository.NewEventRepository(db, appLogger)
+	promoRepo := repository.NewPromoCodeRepository(db, appLogger)
+	ticketSrv := service.NewTicketService(cfg, bookingRepo, eventRepo, promoRepo, redisClient)
+	inventorySrv := service.NewInventoryService(eventRepo, appLogger, redisClient)
+	eventSrv := service.NewEventService(eventRepo, appLogger, redisClient)
+	paymentRepo := repository.NewPaymentRepository(db, appLogger)
+	webhookRepo := repository.NewWebhookRepository(db, appLogger)
+	paymentSrv := service.NewPaymentService(cfg, gateway, paymentRepo, bookingRepo, webhookRepo, appLogger, redisClient)
+	outboxRepo := repository.NewOutboxRepository(db, appLogger)
+	outboxSrv := service.NewOutboxService(cfg, outboxRepo, publisher, appLogger)
+	notificationRepo := repository.NewNotificationRepository(db, appLogger)
+	notificationSrv := service.NewNotificationService(cfg, notificationRepo, sender, templates, appLogger)
+	waitingRoomSrv := service.NewWaitingRoomService(cfg, eventRepo, appLogger, redisClient)
+
+	// Background workers
+	deamons := []utils.DeamonGenerator{
+		worker.NewHoldReaper(appLogger, ticketSrv).Deamon(cfg.Workers.HoldReaperInterval),
+		worker.NewInventoryReconciler(appLogger, inventorySrv).Deamon(cfg.Workers.InventoryReconcilerInterval),
+		worker.NewEventCompleter(appLogger, eventSrv).Deamon(cfg.Workers.EventCompleterInterval),
+		worker.NewUnpaidBookingExpirer(appLogger, paymentSrv).Deamon(cfg.Workers.UnpaidBookingExpirerInterval),
+		worker.NewOutboxRelay(appLogger, outboxSrv).Deamon(cfg.Workers.OutboxRelayInterval),
+		worker.NewNotificationScheduler(appLogger, notificationSrv).Deamon(cfg.Workers.NotificationInterval),
+		worker.NewWaitingRoomAdmitter(appLogger, waitingRoomSrv).Deamon(cfg.Workers.WaitingRoomAdmitterInterval),
+	}
+	stops := make([]utils.Deamon, 0, len(deamons))
+	for _, deamon := range deamons {
+		stop, err := deamon(ctx)
+		if err != nil {
+			appLogger.Fatalf("Error starting background worker: %v", err)
+		}
+		stops = append(stops, stop)
+	}
+
+	server := server.NewServer(appLogger, cfg, redisClient, db, gateway, verifier)
 	appLogger.Info("Starting server...")
-	appLogger.Fatal(server.Run(context.Background()))
-	appLogger.Info("Server started")
+	shutdown, err := server.Run(ctx)
+	if err != nil {
+		appLogger.Fatalf("Error running server: %v", err)
+	}
+	shutdown()
+	for _, stop := range stops {
+		stop()
+	}
+	if err := tracerProvider.Shutdown(context.Background()); err != nil {
+		appLogger.Errorf("Error flushing traces: %v", err)
+	}
+	appLogger.Info("Server stopped")
 }
@@ -2,7 +2,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -10,11 +13,22 @@ import (
 
 // App config struct
 type Config struct {
-	Server     ServerConfig     `mapstructure:"server"`
-	Logger     Logger           `mapstructure:"logger"`
-	Postgres   PostgresConfig   `mapstructure:"postgres"`
-	Redis      RedisConfig      `mapstructure:"redis"`
-	Migrations MigrationsConfig `mapstructure:"migrations"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Logger       Logger             `mapstructure:"logger"`
+	Postgres     PostgresConfig     `mapstructure:"postgres"`
+	Redis        RedisConfig        `mapstructure:"redis"`
+	Migrations   MigrationsConfig   `mapstructure:"migrations"`
+	Workers      WorkersConfig      `mapstructure:"workers"`
+	Ticket       TicketConfig       `mapstructure:"ticket"`
+	Booking      BookingConfig      `mapstructure:"booking"`
+	Idempotency  IdempotencyConfig  `mapstructure:"idempotency"`
+	Payment      PaymentConfig      `mapstructure:"payment"`
+	Outbox       OutboxConfig       `mapstructure:"outbox"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	WaitingRoom  WaitingRoomConfig  `mapstructure:"waiting_room"`
+	Auth         AuthConfig         `mapstructure:"auth"`
+	RateLimit    RateLimitConfig    `mapstructure:"rate_limit"`
+	Tracing      TracingConfig      `mapstructure:"tracing"`
 }
 
 type PostgresConfig struct {
@@ -37,6 +51,140 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// Ticket hold config
+type TicketConfig struct {
+	// HoldStrategy is either "lua" (atomic script) or "redsync" (lock + pipeline)
+	HoldStrategy string        `mapstructure:"hold_strategy"`
+	HoldTTL      time.Duration `mapstructure:"hold_ttl"`
+}
+
+// Booking config
+type BookingConfig struct {
+	// CancellationCutoff is how long before the event starts cancellation closes
+	CancellationCutoff time.Duration `mapstructure:"cancellation_cutoff"`
+	// FeeRate is the booking fee in basis points of the subtotal, 250 is 2.5%
+	FeeRate int64 `mapstructure:"fee_rate"`
+}
+
+// Payment config
+type PaymentConfig struct {
+	// Provider selects the payment.Gateway, only "fake" is built in
+	Provider string `mapstructure:"provider"`
+	// Timeout is how long a booking may stay awaiting_payment before it expires
+	Timeout time.Duration `mapstructure:"timeout"`
+	// WebhookSecret signs the webhooks of the provider
+	WebhookSecret string `mapstructure:"webhook_secret"`
+	// WebhookTolerance is the maximum age of an accepted webhook
+	WebhookTolerance time.Duration `mapstructure:"webhook_tolerance"`
+}
+
+// Outbox relay config
+type OutboxConfig struct {
+	// Publisher selects the outbox.Publisher, only "redis" (streams) is built in
+	Publisher    string `mapstructure:"publisher"`
+	StreamPrefix string `mapstructure:"stream_prefix"`
+	// StreamMaxLen approximately caps each stream, 0 keeps every entry
+	StreamMaxLen int64 `mapstructure:"stream_max_len"`
+	BatchSize    int   `mapstructure:"batch_size"`
+	// MaxBackoff caps the exponential delay between retries of a message
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+	// Retention is how long published messages are kept
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// Notification config
+type NotificationConfig struct {
+	// Sender selects the notification.Sender, "smtp" or "log"
+	Sender string `mapstructure:"sender"`
+	From   string `mapstructure:"from"`
+	// LogPath is the file the "log" sender appends to, empty logs the messages
+	LogPath string `mapstructure:"log_path"`
+	// ReminderOffsets are how long before the start of an event reminders go out
+	ReminderOffsets []time.Duration `mapstructure:"reminder_offsets"`
+	// MaxAttempts is how often a failed notification is tried
+	MaxAttempts int        `mapstructure:"max_attempts"`
+	SMTP        SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// Waiting room config. Every admitter run admits AdmitBatchSize users per
+// event, so the admission rate is AdmitBatchSize per
+// Workers.WaitingRoomAdmitterInterval.
+type WaitingRoomConfig struct {
+	AdmitBatchSize int `mapstructure:"admit_batch_size"`
+	// AdmissionTTL is how long an admitted user may start holding tickets
+	AdmissionTTL time.Duration `mapstructure:"admission_ttl"`
+}
+
+// Idempotency-Key config
+type IdempotencyConfig struct {
+	// TTL is how long a stored response can be replayed
+	TTL time.Duration `mapstructure:"ttl"`
+	// WaitTimeout is how long a duplicate waits for the in-flight request
+	// before it is answered with 409
+	WaitTimeout time.Duration `mapstructure:"wait_timeout"`
+}
+
+// Background workers config
+type WorkersConfig struct {
+	HoldReaperInterval           time.Duration `mapstructure:"hold_reaper_interval"`
+	InventoryReconcilerInterval  time.Duration `mapstructure:"inventory_reconciler_interval"`
+	EventCompleterInterval       time.Duration `mapstructure:"event_completer_interval"`
+	UnpaidBookingExpirerInterval time.Duration `mapstructure:"unpaid_booking_expirer_interval"`
+	OutboxRelayInterval          time.Duration `mapstructure:"outbox_relay_interval"`
+	NotificationInterval         time.Duration `mapstructure:"notification_interval"`
+	WaitingRoomAdmitterInterval  time.Duration `mapstructure:"waiting_room_admitter_interval"`
+}
+
+// Access token config. Tokens are accepted when signed with HS256 by
+// HMACSecret or with RS256 by a key of JWKSFile, at least one must be set.
+type AuthConfig struct {
+	HMACSecret string `mapstructure:"hmac_secret"`
+	// JWKSFile is a JSON Web Key Set with the RSA keys of the token issuer
+	JWKSFile string `mapstructure:"jwks_file"`
+	// Issuer and Audience are checked when set
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+	// Leeway absorbs clock skew when checking the expiry of a token
+	Leeway time.Duration `mapstructure:"leeway"`
+}
+
+// Rate limit config. A rule allows Limit requests per Window to every user,
+// or client IP when unauthenticated, a Limit of 0 disables it.
+type RateLimitConfig struct {
+	// Bookings limits creating, cancelling and deleting bookings
+	Bookings RateLimitRule `mapstructure:"bookings"`
+	// Holds limits creating and releasing holds
+	Holds RateLimitRule `mapstructure:"holds"`
+	// Reads limits the GET requests of the authenticated api
+	Reads RateLimitRule `mapstructure:"reads"`
+}
+
+type RateLimitRule struct {
+	Limit  int           `mapstructure:"limit"`
+	Window time.Duration `mapstructure:"window"`
+}
+
+// Tracing config
+type TracingConfig struct {
+	// Exporter is "otlp" (OTLP over http), "stdout" or "none". With "none"
+	// spans are still created, so logs and errors carry trace ids.
+	Exporter    string `mapstructure:"exporter"`
+	ServiceName string `mapstructure:"service_name"`
+	// OTLPEndpoint is the host:port of the collector, e.g. localhost:4318
+	OTLPEndpoint string `mapstructure:"otlp_endpoint"`
+	OTLPInsecure bool   `mapstructure:"otlp_insecure"`
+	// SampleRatio is the share of new traces that is sampled, requests
+	// carrying a traceparent follow the decision of their parent
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
 // Server config struct
 type ServerConfig struct {
 	Development bool
@@ -44,6 +192,9 @@ type ServerConfig struct {
 	Host        string
 	Port        string
 	CorsOrigins []string
+	// TrustedProxies may set X-Forwarded-For, the client IP of everyone else
+	// is the remote address
+	TrustedProxies []string
 }
 
 // Logger config
@@ -101,6 +252,51 @@ func GetEnvConfig() (*Config, error) {
 
 	v := viper.New()
 	v.AutomaticEnv()
+	v.SetDefault("WORKERS_HOLD_REAPER_INTERVAL", 10*time.Second)
+	v.SetDefault("WORKERS_INVENTORY_RECONCILER_INTERVAL", time.Minute)
+	v.SetDefault("WORKERS_EVENT_COMPLETER_INTERVAL", time.Minute)
+	v.SetDefault("WORKERS_UNPAID_BOOKING_EXPIRER_INTERVAL", 30*time.Second)
+	v.SetDefault("WORKERS_OUTBOX_RELAY_INTERVAL", time.Second)
+	v.SetDefault("WORKERS_NOTIFICATION_INTERVAL", time.Minute)
+	v.SetDefault("WORKERS_WAITING_ROOM_ADMITTER_INTERVAL", 5*time.Second)
+	v.SetDefault("TICKET_HOLD_STRATEGY", "lua")
+	v.SetDefault("TICKET_HOLD_TTL", 5*time.Minute)
+	v.SetDefault("BOOKING_CANCELLATION_CUTOFF", 24*time.Hour)
+	v.SetDefault("BOOKING_FEE_RATE", 0)
+	v.SetDefault("IDEMPOTENCY_TTL", 24*time.Hour)
+	v.SetDefault("IDEMPOTENCY_WAIT_TIMEOUT", 5*time.Second)
+	v.SetDefault("PAYMENT_PROVIDER", "fake")
+	v.SetDefault("PAYMENT_TIMEOUT", 15*time.Minute)
+	v.SetDefault("PAYMENT_WEBHOOK_TOLERANCE", 5*time.Minute)
+	v.SetDefault("OUTBOX_PUBLISHER", "redis")
+	v.SetDefault("OUTBOX_STREAM_PREFIX", "outbox")
+	v.SetDefault("OUTBOX_STREAM_MAX_LEN", 100000)
+	v.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	v.SetDefault("OUTBOX_MAX_BACKOFF", 5*time.Minute)
+	v.SetDefault("OUTBOX_RETENTION", 7*24*time.Hour)
+	v.SetDefault("NOTIFICATION_SENDER", "log")
+	v.SetDefault("NOTIFICATION_FROM", "no-reply@event-booking.local")
+	v.SetDefault("NOTIFICATION_REMINDER_OFFSETS", "24h,1h")
+	v.SetDefault("NOTIFICATION_MAX_ATTEMPTS", 3)
+	v.SetDefault("SMTP_PORT", "587")
+	v.SetDefault("WAITING_ROOM_ADMIT_BATCH_SIZE", 100)
+	v.SetDefault("WAITING_ROOM_ADMISSION_TTL", 10*time.Minute)
+	v.SetDefault("AUTH_LEEWAY", 30*time.Second)
+	v.SetDefault("RATE_LIMIT_BOOKINGS_LIMIT", 10)
+	v.SetDefault("RATE_LIMIT_BOOKINGS_WINDOW", time.Minute)
+	v.SetDefault("RATE_LIMIT_HOLDS_LIMIT", 20)
+	v.SetDefault("RATE_LIMIT_HOLDS_WINDOW", time.Minute)
+	v.SetDefault("RATE_LIMIT_READS_LIMIT", 300)
+	v.SetDefault("RATE_LIMIT_READS_WINDOW", time.Minute)
+	v.SetDefault("TRACING_EXPORTER", "none")
+	v.SetDefault("TRACING_SERVICE_NAME", "event-booking-service")
+	v.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	v.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+
+	reminderOffsets, err := parseDurations(v.GetString("NOTIFICATION_REMINDER_OFFSETS"))
+	if err != nil {
+		return nil, fmt.Errorf("NOTIFICATION_REMINDER_OFFSETS: %w", err)
+	}
 	return &Config{
 		Server: ServerConfig{
 			AppVersion:  v.GetString("SERVER_APPVERSION"),
@@ -108,6 +304,8 @@ func GetEnvConfig() (*Config, error) {
 			Port:        v.GetString("SERVER_PORT"),
 			Development: v.GetBool("SERVER_DEVELOPMENT"),
 			CorsOrigins: v.GetStringSlice("SERVER_CORS_ORIGINS"),
+			// Space separated list of IPs or CIDRs
+			TrustedProxies: v.GetStringSlice("SERVER_TRUSTED_PROXIES"),
 		},
 		Logger: Logger{
 			Encoding: v.GetString("LOGGER_ENCODING"),
@@ -130,5 +328,105 @@ func GetEnvConfig() (*Config, error) {
 		Migrations: MigrationsConfig{
 			Path: v.GetString("MIGRATIONS_PATH"),
 		},
+		Ticket: TicketConfig{
+			HoldStrategy: v.GetString("TICKET_HOLD_STRATEGY"),
+			HoldTTL:      v.GetDuration("TICKET_HOLD_TTL"),
+		},
+		Booking: BookingConfig{
+			CancellationCutoff: v.GetDuration("BOOKING_CANCELLATION_CUTOFF"),
+			FeeRate:            v.GetInt64("BOOKING_FEE_RATE"),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         v.GetDuration("IDEMPOTENCY_TTL"),
+			WaitTimeout: v.GetDuration("IDEMPOTENCY_WAIT_TIMEOUT"),
+		},
+		Payment: PaymentConfig{
+			Provider:         v.GetString("PAYMENT_PROVIDER"),
+			Timeout:          v.GetDuration("PAYMENT_TIMEOUT"),
+			WebhookSecret:    v.GetString("PAYMENT_WEBHOOK_SECRET"),
+			WebhookTolerance: v.GetDuration("PAYMENT_WEBHOOK_TOLERANCE"),
+		},
+		Outbox: OutboxConfig{
+			Publisher:    v.GetString("OUTBOX_PUBLISHER"),
+			StreamPrefix: v.GetString("OUTBOX_STREAM_PREFIX"),
+			StreamMaxLen: v.GetInt64("OUTBOX_STREAM_MAX_LEN"),
+			BatchSize:    v.GetInt("OUTBOX_BATCH_SIZE"),
+			MaxBackoff:   v.GetDuration("OUTBOX_MAX_BACKOFF"),
+			Retention:    v.GetDuration("OUTBOX_RETENTION"),
+		},
+		Notification: NotificationConfig{
+			Sender:          v.GetString("NOTIFICATION_SENDER"),
+			From:            v.GetString("NOTIFICATION_FROM"),
+			LogPath:         v.GetString("NOTIFICATION_LOG_PATH"),
+			ReminderOffsets: reminderOffsets,
+			MaxAttempts:     v.GetInt("NOTIFICATION_MAX_ATTEMPTS"),
+			SMTP: SMTPConfig{
+				Host:     v.GetString("SMTP_HOST"),
+				Port:     v.GetString("SMTP_PORT"),
+				Username: v.GetString("SMTP_USERNAME"),
+				Password: v.GetString("SMTP_PASSWORD"),
+			},
+		},
+		WaitingRoom: WaitingRoomConfig{
+			AdmitBatchSize: v.GetInt("WAITING_ROOM_ADMIT_BATCH_SIZE"),
+			AdmissionTTL:   v.GetDuration("WAITING_ROOM_ADMISSION_TTL"),
+		},
+		Auth: AuthConfig{
+			HMACSecret: v.GetString("AUTH_HMAC_SECRET"),
+			JWKSFile:   v.GetString("AUTH_JWKS_FILE"),
+			Issuer:     v.GetString("AUTH_ISSUER"),
+			Audience:   v.GetString("AUTH_AUDIENCE"),
+			Leeway:     v.GetDuration("AUTH_LEEWAY"),
+		},
+		RateLimit: RateLimitConfig{
+			Bookings: RateLimitRule{
+				Limit:  v.GetInt("RATE_LIMIT_BOOKINGS_LIMIT"),
+				Window: v.GetDuration("RATE_LIMIT_BOOKINGS_WINDOW"),
+			},
+			Holds: RateLimitRule{
+				Limit:  v.GetInt("RATE_LIMIT_HOLDS_LIMIT"),
+				Window: v.GetDuration("RATE_LIMIT_HOLDS_WINDOW"),
+			},
+			Reads: RateLimitRule{
+				Limit:  v.GetInt("RATE_LIMIT_READS_LIMIT"),
+				Window: v.GetDuration("RATE_LIMIT_READS_WINDOW"),
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:     v.GetString("TRACING_EXPORTER"),
+			ServiceName:  v.GetString("TRACING_SERVICE_NAME"),
+			OTLPEndpoint: v.GetString("TRACING_OTLP_ENDPOINT"),
+			OTLPInsecure: v.GetBool("TRACING_OTLP_INSECURE"),
+			SampleRatio:  v.GetFloat64("TRACING_SAMPLE_RATIO"),
+		},
+		Workers: WorkersConfig{
+			HoldReaperInterval:           v.GetDuration("WORKERS_HOLD_REAPER_INTERVAL"),
+			InventoryReconcilerInterval:  v.GetDuration("WORKERS_INVENTORY_RECONCILER_INTERVAL"),
+			EventCompleterInterval:       v.GetDuration("WORKERS_EVENT_COMPLETER_INTERVAL"),
+			UnpaidBookingExpirerInterval: v.GetDuration("WORKERS_UNPAID_BOOKING_EXPIRER_INTERVAL"),
+			OutboxRelayInterval:          v.GetDuration("WORKERS_OUTBOX_RELAY_INTERVAL"),
+			NotificationInterval:         v.GetDuration("WORKERS_NOTIFICATION_INTERVAL"),
+			WaitingRoomAdmitterInterval:  v.GetDuration("WORKERS_WAITING_ROOM_ADMITTER_INTERVAL"),
+		},
 	}, nil
 }
+
+// parseDurations parses a comma separated list such as "24h,1h".
+func parseDurations(list string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		d, err := time.ParseDuration(item)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("duration must be positive, got %s", item)
+		}
+		durations = append(durations, d)
+	}
+	return durations, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Roles of the roles claim. Customers book tickets, organizers run their own
+// events and admins may do anything.
+const (
+	RoleCustomer  = "customer"
+	RoleOrganizer = "organizer"
+	RoleAdmin     = "admin"
+)
+
+var (
+	ErrUnauthenticated  = errors.New("authentication required")
+	ErrInvalidToken     = errors.New("invalid access token")
+	ErrForbidden        = errors.New("not allowed to access this resource")
+	ErrInsufficientRole = fmt.Errorf("%w: role not allowed", ErrForbidden)
+	ErrNotOwner         = fmt.Errorf("%w: resource belongs to another user", ErrForbidden)
+)
+
+// Identity is the authenticated caller of a request, taken from the subject
+// and the roles claim of its access token.
+type Identity struct {
+	UserID uuid.UUID
+	Roles  []string
+}
+
+func (i *Identity) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if slices.Contains(i.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller of the request, nil for requests
+// that were not authenticated.
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// CheckRole allows callers holding one of the roles.
+func CheckRole(ctx context.Context, roles ...string) error {
+	identity := IdentityFromContext(ctx)
+	if identity == nil {
+		return ErrUnauthenticated
+	}
+	if !identity.HasRole(roles...) {
+		return ErrInsufficientRole
+	}
+	return nil
+}
+
+// CheckOwner allows the caller to act on a resource of userID. Admins act on
+// any resource.
+func CheckOwner(ctx context.Context, userID uuid.UUID) error {
+	identity := IdentityFromContext(ctx)
+	if identity == nil {
+		return ErrUnauthenticated
+	}
+	if identity.UserID != userID && !identity.HasRole(RoleAdmin) {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// CheckOrganizer allows the caller to manage an event of organizerID, which
+// takes the organizer themselves or an admin.
+func CheckOrganizer(ctx context.Context, organizerID uuid.UUID) error {
+	if err := CheckRole(ctx, RoleOrganizer, RoleAdmin); err != nil {
+		return err
+	}
+	return CheckOwner(ctx, organizerID)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPolicies(t *testing.T) {
+	owner := uuid.New()
+	contexts := map[string]context.Context{
+		"anonymous":       context.Background(),
+		"owner customer":  WithIdentity(context.Background(), &Identity{UserID: owner, Roles: []string{RoleCustomer}}),
+		"owner organizer": WithIdentity(context.Background(), &Identity{UserID: owner, Roles: []string{RoleOrganizer}}),
+		"other organizer": WithIdentity(context.Background(), &Identity{UserID: uuid.New(), Roles: []string{RoleOrganizer}}),
+		"admin":           WithIdentity(context.Background(), &Identity{UserID: uuid.New(), Roles: []string{RoleAdmin}}),
+	}
+	tests := []struct {
+		caller                string
+		role, owns, organizes error
+	}{
+		{"anonymous", ErrUnauthenticated, ErrUnauthenticated, ErrUnauthenticated},
+		{"owner customer", ErrInsufficientRole, nil, ErrInsufficientRole},
+		{"owner organizer", nil, nil, nil},
+		{"other organizer", nil, ErrNotOwner, ErrNotOwner},
+		{"admin", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller, func(t *testing.T) {
+			ctx := contexts[tt.caller]
+			if err := CheckRole(ctx, RoleOrganizer, RoleAdmin); !errors.Is(err, tt.role) {
+				t.Errorf("CheckRole() = %v, want %v", err, tt.role)
+			}
+			if err := CheckOwner(ctx, owner); !errors.Is(err, tt.owns) {
+				t.Errorf("CheckOwner() = %v, want %v", err, tt.owns)
+			}
+			if err := CheckOrganizer(ctx, owner); !errors.Is(err, tt.organizes) {
+				t.Errorf("CheckOrganizer() = %v, want %v", err, tt.organizes)
+			}
+		})
+	}
+
+	// Both denials are forbidden, apart from the code that tells them apart.
+	if !errors.Is(ErrInsufficientRole, ErrForbidden) || !errors.Is(ErrNotOwner, ErrForbidden) {
+		t.Error("role and ownership denials do not wrap ErrForbidden")
+	}
+}
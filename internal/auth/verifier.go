@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+)
+
+var ErrNoKeys = errors.New("no token signing keys configured")
+
+// claims are the claims of an access token the service relies on. The
+// subject is the id of the user.
+type claims struct {
+	Roles []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// Verifier validates access tokens signed with HS256 by the shared secret or
+// with RS256 by a key of the JWKS file.
+type Verifier struct {
+	secret  []byte
+	keys    map[string]*rsa.PublicKey
+	methods []string
+	options []jwt.ParserOption
+}
+
+func NewVerifier(cfg *config.Config) (*Verifier, error) {
+	v := &Verifier{}
+	if cfg.Auth.HMACSecret != "" {
+		v.secret = []byte(cfg.Auth.HMACSecret)
+		v.methods = append(v.methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.Auth.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.Auth.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		v.methods = append(v.methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(v.methods) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	v.options = []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Auth.Leeway),
+	}
+	if cfg.Auth.Issuer != "" {
+		v.options = append(v.options, jwt.WithIssuer(cfg.Auth.Issuer))
+	}
+	if cfg.Auth.Audience != "" {
+		v.options = append(v.options, jwt.WithAudience(cfg.Auth.Audience))
+	}
+	return v, nil
+}
+
+// Verify checks the signature, expiry, issuer and audience of the token and
+// returns the identity it was issued to.
+func (v *Verifier) Verify(token string) (*Identity, error) {
+	var c claims
+	if _, err := jwt.ParseWithClaims(token, &c, v.key, v.options...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject is not a user id", ErrInvalidToken)
+	}
+	// Tokens without roles are issued to plain customers.
+	if len(c.Roles) == 0 {
+		c.Roles = []string{RoleCustomer}
+	}
+	return &Identity{UserID: userID, Roles: c.Roles}, nil
+}
+
+// key picks the verification key by the algorithm and key id of the token.
+// The parser already rejected algorithms that are not configured.
+func (v *Verifier) key(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return v.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	// Tokens of issuers with a single key may omit the key id.
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+)
+
+const testSecret = "test-secret"
+
+func signHS256(t *testing.T, secret string, c jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, c jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// writeJWKS writes the public halves of keys, keyed by key id, to a JWKS file.
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	t.Helper()
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func tokenClaims(subject string, expiresIn time.Duration, roles ...string) *claims {
+	return &claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    "https://auth.example.com",
+			Audience:  jwt.ClaimStrings{"event-booking"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.HMACSecret = testSecret
+	cfg.Auth.Issuer = "https://auth.example.com"
+	cfg.Auth.Audience = "event-booking"
+	cfg.Auth.Leeway = 30 * time.Second
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewVerifier() = %v", err)
+	}
+	userID := uuid.New()
+
+	identity, err := v.Verify(signHS256(t, testSecret, tokenClaims(userID.String(), time.Hour, RoleOrganizer)))
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if identity.UserID != userID || !slices.Equal(identity.Roles, []string{RoleOrganizer}) {
+		t.Fatalf("identity = %+v, want organizer %s", identity, userID)
+	}
+	identity, err = v.Verify(signHS256(t, testSecret, tokenClaims(userID.String(), time.Hour)))
+	if err != nil || !slices.Equal(identity.Roles, []string{RoleCustomer}) {
+		t.Fatalf("Verify() without roles = %+v, %v, want a customer", identity, err)
+	}
+	if _, err := v.Verify(signHS256(t, testSecret, tokenClaims(userID.String(), -10*time.Second))); err != nil {
+		t.Fatalf("Verify() within the leeway = %v", err)
+	}
+
+	wrongIssuer := tokenClaims(userID.String(), time.Hour)
+	wrongIssuer.Issuer = "https://evil.example.com"
+	wrongAudience := tokenClaims(userID.String(), time.Hour)
+	wrongAudience.Audience = jwt.ClaimStrings{"another-service"}
+	noExpiry := tokenClaims(userID.String(), time.Hour)
+	noExpiry.ExpiresAt = nil
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, tokenClaims(userID.String(), time.Hour)).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs384, err := jwt.NewWithClaims(jwt.SigningMethodHS384, tokenClaims(userID.String(), time.Hour)).
+		SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"expired":               signHS256(t, testSecret, tokenClaims(userID.String(), -time.Minute)),
+		"wrong secret":          signHS256(t, "other-secret", tokenClaims(userID.String(), time.Hour)),
+		"wrong issuer":          signHS256(t, testSecret, wrongIssuer),
+		"wrong audience":        signHS256(t, testSecret, wrongAudience),
+		"no expiry":             signHS256(t, testSecret, noExpiry),
+		"subject not a user id": signHS256(t, testSecret, tokenClaims("jane", time.Hour)),
+		"unsigned":              unsigned,
+		"other algorithm":       hs384,
+		"malformed":             "not.a.token",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := v.Verify(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestVerifyRS256(t *testing.T) {
+	current, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.Auth.JWKSFile = writeJWKS(t, map[string]*rsa.PrivateKey{"current": current, "previous": previous})
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewVerifier() = %v", err)
+	}
+	userID := uuid.New()
+
+	for _, kid := range []string{"current", "previous"} {
+		key := map[string]*rsa.PrivateKey{"current": current, "previous": previous}[kid]
+		identity, err := v.Verify(signRS256(t, key, kid, tokenClaims(userID.String(), time.Hour, RoleAdmin)))
+		if err != nil || identity.UserID != userID || !identity.HasRole(RoleAdmin) {
+			t.Fatalf("Verify() with key %s = %+v, %v, want admin %s", kid, identity, err, userID)
+		}
+	}
+
+	tests := map[string]string{
+		"unknown key id": signRS256(t, current, "retired", tokenClaims(userID.String(), time.Hour)),
+		"wrong key":      signRS256(t, previous, "current", tokenClaims(userID.String(), time.Hour)),
+		// The key id is required once the set has several keys.
+		"no key id": signRS256(t, current, "", tokenClaims(userID.String(), time.Hour)),
+		// Without a shared secret HS256 tokens are refused, whatever they are
+		// signed with.
+		"hs256": signHS256(t, "", tokenClaims(userID.String(), time.Hour)),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := v.Verify(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+
+	// An issuer with a single key may omit the key id.
+	cfg.Auth.JWKSFile = writeJWKS(t, map[string]*rsa.PrivateKey{"current": current})
+	if v, err = NewVerifier(cfg); err != nil {
+		t.Fatalf("NewVerifier() = %v", err)
+	}
+	if _, err := v.Verify(signRS256(t, current, "", tokenClaims(userID.String(), time.Hour))); err != nil {
+		t.Fatalf("Verify() without a key id = %v", err)
+	}
+}
+
+func TestNewVerifierRequiresKeys(t *testing.T) {
+	if _, err := NewVerifier(&config.Config{}); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("NewVerifier() without keys = %v, want %v", err, ErrNoKeys)
+	}
+
+	cfg := &config.Config{}
+	cfg.Auth.JWKSFile = filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(cfg.Auth.JWKSFile, []byte(`{"keys": [{"kty": "EC", "kid": "ec"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVerifier(cfg); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("NewVerifier() without RSA keys = %v, want %v", err, ErrNoKeys)
+	}
+}
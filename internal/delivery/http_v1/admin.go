@@ -0,0 +1,116 @@
+package http_v1
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+var webhookEventStatusChoices = []string{
+	string(model.WebhookEventStatusReceived),
+	string(model.WebhookEventStatusProcessed),
+	string(model.WebhookEventStatusIgnored),
+	string(model.WebhookEventStatusFailed),
+}
+
+type AdminController struct {
+	logger       logger.Logger
+	inventorySrv service.InventoryServiceInterface
+	paymentSrv   service.PaymentServiceInterface
+}
+
+func NewAdminController(
+	logger logger.Logger,
+	inventorySrv service.InventoryServiceInterface,
+	paymentSrv service.PaymentServiceInterface,
+) AdminControllerInterface {
+	return &AdminController{logger: logger, inventorySrv: inventorySrv, paymentSrv: paymentSrv}
+}
+
+func (a *AdminController) GetEventInventory(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		a.logger.WithContext(c.Request.Context()).Error("ADMIN_CONTROLLER.GET_EVENT_INVENTORY.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	inventory, err := a.inventorySrv.GetInventory(c.Request.Context(), eventID)
+	if err != nil {
+		a.logger.WithContext(c.Request.Context()).Error("ADMIN_CONTROLLER.GET_EVENT_INVENTORY.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, inventory))
+}
+
+func (a *AdminController) ListWebhookEvents(c *gin.Context) {
+	status, attrErr := utils.ParseChoiceQuery("status", c.Query("status"), webhookEventStatusChoices)
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	timestamps, attrErr := utils.ParseTimestampQuery(map[string]string{
+		"from": c.Query("from"),
+		"to":   c.Query("to"),
+	})
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	pagination, attrErr := parsePagination(c, nil)
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+
+	filter := &dto.WebhookEventFilterDTO{
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		EventType: strings.TrimSpace(c.Query("event_type")),
+		IntentID:  strings.TrimSpace(c.Query("intent_id")),
+		Status:    status,
+	}
+	if timestamps["from"] > 0 {
+		filter.From = time.Unix(timestamps["from"], 0)
+	}
+	if timestamps["to"] > 0 {
+		filter.To = time.Unix(timestamps["to"], 0)
+	}
+
+	events, err := a.paymentSrv.ListWebhookEvents(c.Request.Context(), filter, pagination)
+	if err != nil {
+		a.logger.WithContext(c.Request.Context()).Error("ADMIN_CONTROLLER.LIST_WEBHOOK_EVENTS.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, events))
+}
+
+func (a *AdminController) GetWebhookEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		a.logger.WithContext(c.Request.Context()).Error("ADMIN_CONTROLLER.GET_WEBHOOK_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	event, err := a.paymentSrv.GetWebhookEvent(c.Request.Context(), id)
+	if err != nil {
+		a.logger.WithContext(c.Request.Context()).Error("ADMIN_CONTROLLER.GET_WEBHOOK_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, event))
+}
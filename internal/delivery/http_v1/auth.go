@@ -0,0 +1,17 @@
+package http_v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+)
+
+// currentUserID returns the id of the authenticated caller. Requests acting
+// for a user take it from the access token, never from the request.
+func currentUserID(c *gin.Context) (uuid.UUID, error) {
+	identity := auth.IdentityFromContext(c.Request.Context())
+	if identity == nil {
+		return uuid.Nil, auth.ErrUnauthenticated
+	}
+	return identity.UserID, nil
+}
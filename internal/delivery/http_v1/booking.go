@@ -1,17 +1,31 @@
 package http_v1
 
 import (
-	"database/sql"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
 	"github.com/phamdinhha/event-booking-service/internal/service"
 	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 )
 
+var bookingStatusChoices = []string{
+	string(model.BookingStatusPending),
+	string(model.BookingStatusAwaitingPayment),
+	string(model.BookingStatusConfirmed),
+	string(model.BookingStatusCancelled),
+	string(model.BookingStatusRefundPending),
+	string(model.BookingStatusRefunded),
+	string(model.BookingStatusExpired),
+	string(model.BookingStatusCheckedIn),
+}
+
 type BookingController struct {
 	logger     logger.Logger
 	bookingSrv service.BookingServiceInterface
@@ -27,14 +41,22 @@ func NewBookingController(
 func (b *BookingController) CreateBooking(c *gin.Context) {
 	var req dto.CreateBookingDTO
 	if err := c.ShouldBindJSON(&req); err != nil {
-		b.logger.Error("BOOKING_CONTROLLER.CREATE_BOOKING.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(http_utils.INVALID_REQUEST, err.Error()))
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.CREATE_BOOKING.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
 		return
 	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	req.UserID = userID
 	created, err := b.bookingSrv.CreateBooking(c.Request.Context(), &req)
 	if err != nil {
-		b.logger.Error("BOOKING_CONTROLLER.CREATE_BOOKING.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(http_utils.INTERNAL_SERVER_ERROR, err.Error()))
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.CREATE_BOOKING.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
 		return
 	}
 	c.JSON(http.StatusCreated, http_utils.NewOKResponse(http_utils.CREATED, created))
@@ -44,8 +66,8 @@ func (b *BookingController) GetBooking(c *gin.Context) {
 	bookingID := c.Param("id")
 	id, err := uuid.Parse(bookingID)
 	if err != nil {
-		b.logger.Error("BOOKING_CONTROLLER.GET_BOOKING.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.GET_BOOKING.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(),
 			http_utils.INVALID_REQUEST,
 			err,
 		))
@@ -54,17 +76,9 @@ func (b *BookingController) GetBooking(c *gin.Context) {
 
 	booking, err := b.bookingSrv.GetBooking(c.Request.Context(), id)
 	if err != nil {
-		b.logger.Error("BOOKING_CONTROLLER.GET_BOOKING.Error", err)
-		var statusCode int
-		var message string
-		if err == sql.ErrNoRows {
-			statusCode = http.StatusNotFound
-			message = http_utils.NOT_FOUND
-		} else {
-			statusCode = http.StatusInternalServerError
-			message = http_utils.INTERNAL_SERVER_ERROR
-		}
-		c.JSON(statusCode, http_utils.NewErrorResponse(message, err.Error()))
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.GET_BOOKING.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
 		return
 	}
 	c.JSON(http.StatusOK, http_utils.NewOKResponse(
@@ -73,12 +87,114 @@ func (b *BookingController) GetBooking(c *gin.Context) {
 	))
 }
 
+func (b *BookingController) CancelBooking(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.CANCEL_BOOKING.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	// The body is optional, a cancellation does not need a reason.
+	var req dto.CancelBookingDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.CANCEL_BOOKING.Error", err)
+			c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+			return
+		}
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.CANCEL_BOOKING.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	cancelled, err := b.bookingSrv.CancelBooking(c.Request.Context(), id, &req)
+	if err != nil {
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.CANCEL_BOOKING.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, cancelled))
+}
+
+func (b *BookingController) GetBookingHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.GET_BOOKING_HISTORY.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	history, err := b.bookingSrv.GetBookingHistory(c.Request.Context(), id)
+	if err != nil {
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.GET_BOOKING_HISTORY.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, history))
+}
+
+func (b *BookingController) ListUserBookings(c *gin.Context) {
+	userID, attrErr := utils.ParseUuidQuery("user_id", c.Param("user_id"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	eventID, attrErr := utils.ParseUuidQuery("event_id", c.Query("event_id"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	status, attrErr := utils.ParseChoiceQuery("status", c.Query("status"), bookingStatusChoices)
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	timestamps, attrErr := utils.ParseTimestampQuery(map[string]string{
+		"from": c.Query("from"),
+		"to":   c.Query("to"),
+	})
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	pagination, attrErr := parsePagination(c, repository.BookingOrderChoices())
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+
+	filter := &dto.BookingFilterDTO{
+		UserID:  userID,
+		EventID: eventID,
+		Status:  status,
+	}
+	if timestamps["from"] > 0 {
+		filter.From = time.Unix(timestamps["from"], 0)
+	}
+	if timestamps["to"] > 0 {
+		filter.To = time.Unix(timestamps["to"], 0)
+	}
+
+	bookings, err := b.bookingSrv.ListUserBookings(c.Request.Context(), filter, pagination)
+	if err != nil {
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.LIST_USER_BOOKINGS.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, bookings))
+}
+
 func (b *BookingController) DeleteBooking(c *gin.Context) {
 	bookingID := c.Param("id")
 	id, err := uuid.Parse(bookingID)
 	if err != nil {
-		b.logger.Error("BOOKING_CONTROLLER.GET_BOOKING.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.GET_BOOKING.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(),
 			http_utils.INVALID_REQUEST,
 			err,
 		))
@@ -86,17 +202,9 @@ func (b *BookingController) DeleteBooking(c *gin.Context) {
 	}
 	err = b.bookingSrv.DeleteBooking(c.Request.Context(), id)
 	if err != nil {
-		b.logger.Error("BOOKING_CONTROLLER.DELETE_BOOKING.Error", err)
-		var statusCode int
-		var message string
-		if err == sql.ErrNoRows {
-			statusCode = http.StatusNotFound
-			message = http_utils.NOT_FOUND
-		} else {
-			statusCode = http.StatusInternalServerError
-			message = http_utils.INTERNAL_SERVER_ERROR
-		}
-		response := http_utils.NewErrorResponse(message, err.Error())
+		b.logger.WithContext(c.Request.Context()).Error("BOOKING_CONTROLLER.DELETE_BOOKING.Error", err)
+		statusCode, message := errorStatus(err)
+		response := http_utils.NewErrorResponse(c.Request.Context(), message, err.Error())
 		c.JSON(statusCode, response)
 		return
 	}
@@ -0,0 +1,164 @@
+package http_v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+func testLogger() logger.Logger {
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	appLogger := logger.NewApiLogger(cfg)
+	appLogger.InitLogger()
+	return appLogger
+}
+
+// fakeBookingService records the arguments of CreateBooking and
+// ListUserBookings.
+type fakeBookingService struct {
+	service.BookingServiceInterface
+	created    *dto.CreateBookingDTO
+	filter     *dto.BookingFilterDTO
+	pagination *utils.Pagination
+	err        error
+}
+
+func (f *fakeBookingService) CreateBooking(_ context.Context, booking *dto.CreateBookingDTO) (*dto.BookingDTO, error) {
+	f.created = booking
+	return &dto.BookingDTO{}, nil
+}
+
+func (f *fakeBookingService) ListUserBookings(
+	_ context.Context,
+	filter *dto.BookingFilterDTO,
+	pagination *utils.Pagination,
+) (*dto.BookingListDTO, error) {
+	f.filter, f.pagination = filter, pagination
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dto.BookingListDTO{}, nil
+}
+
+func listUserBookings(t *testing.T, srv *fakeBookingService, userID, query string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:user_id/bookings", NewBookingController(testLogger(), srv).ListUserBookings)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/"+userID+"/bookings?"+query, nil))
+	return recorder.Code
+}
+
+func TestListUserBookingsParsesQuery(t *testing.T) {
+	srv := &fakeBookingService{}
+	userID, eventID := uuid.New(), uuid.New()
+	from, to := time.Unix(1700000000, 0), time.Unix(1800000000, 0)
+	query := fmt.Sprintf("event_id=%s&status=confirmed&from=%d&to=%d&page=2&size=5&orderBy=-start_time",
+		eventID, from.Unix(), to.Unix())
+
+	if code := listUserBookings(t, srv, userID.String(), query); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	want := dto.BookingFilterDTO{UserID: userID, EventID: eventID, Status: "confirmed", From: from, To: to}
+	if *srv.filter != want {
+		t.Errorf("filter = %+v, want %+v", *srv.filter, want)
+	}
+	if srv.pagination.GetPage() != 2 || srv.pagination.GetSize() != 5 || srv.pagination.GetOrderBy() != "-start_time" {
+		t.Errorf("pagination = page %d, size %d, orderBy %q; want page 2, size 5, orderBy -start_time",
+			srv.pagination.GetPage(), srv.pagination.GetSize(), srv.pagination.GetOrderBy())
+	}
+}
+
+func TestListUserBookingsDefaults(t *testing.T) {
+	srv := &fakeBookingService{}
+	userID := uuid.New()
+
+	if code := listUserBookings(t, srv, userID.String(), ""); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	if want := (dto.BookingFilterDTO{UserID: userID}); *srv.filter != want {
+		t.Errorf("filter = %+v, want %+v", *srv.filter, want)
+	}
+	if srv.pagination.GetPage() != 1 || srv.pagination.GetSize() != 10 {
+		t.Errorf("pagination = page %d, size %d; want page 1, size 10",
+			srv.pagination.GetPage(), srv.pagination.GetSize())
+	}
+}
+
+func TestListUserBookingsRejectsInvalidQuery(t *testing.T) {
+	userID := uuid.New().String()
+	tests := []struct {
+		name   string
+		userID string
+		query  string
+	}{
+		{"user id", "not-a-uuid", ""},
+		{"event id", userID, "event_id=42"},
+		{"status", userID, "status=lost"},
+		{"timestamp", userID, "from=yesterday"},
+		{"page", userID, "page=0"},
+		{"size too small", userID, "size=0"},
+		{"size too large", userID, fmt.Sprintf("size=%d", maxPageSize+1)},
+		{"order", userID, "orderBy=price"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &fakeBookingService{}
+			if code := listUserBookings(t, srv, tt.userID, tt.query); code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", code, http.StatusBadRequest)
+			}
+			if srv.filter != nil {
+				t.Error("service called for an invalid query")
+			}
+		})
+	}
+}
+
+func TestListUserBookingsOfAnotherUser(t *testing.T) {
+	srv := &fakeBookingService{err: auth.ErrNotOwner}
+	if code := listUserBookings(t, srv, uuid.New().String(), ""); code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestCreateBookingTakesUserFromToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := &fakeBookingService{}
+	router := gin.New()
+	router.POST("/bookings", NewBookingController(testLogger(), srv).CreateBooking)
+	userID, eventID := uuid.New(), uuid.New()
+	body := fmt.Sprintf(`{"event_id": %q, "user_id": %q, "quantity": 2}`, eventID, uuid.New())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body)))
+	if recorder.Code != http.StatusUnauthorized || srv.created != nil {
+		t.Fatalf("status without identity = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body))
+	req = req.WithContext(auth.WithIdentity(req.Context(), &auth.Identity{UserID: userID, Roles: []string{auth.RoleCustomer}}))
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusCreated)
+	}
+	if srv.created.UserID != userID || srv.created.EventID != eventID || srv.created.Quantity != 2 {
+		t.Fatalf("booking = %+v, want 2 tickets of %s for %s", srv.created, eventID, userID)
+	}
+}
@@ -0,0 +1,81 @@
+package http_v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/payment"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+)
+
+// errorStatus maps service and repository errors to a status code and
+// response message. Anything unknown is an internal server error.
+func errorStatus(err error) (int, string) {
+	var invalidTransition *model.ErrInvalidTransition
+	var invalidEventTransition *model.ErrInvalidEventTransition
+	var invalidPaymentTransition *model.ErrInvalidPaymentTransition
+	switch {
+	case errors.Is(err, sql.ErrNoRows),
+		errors.Is(err, service.ErrHoldNotFound),
+		errors.Is(err, service.ErrQueueTokenNotFound):
+		return http.StatusNotFound, http_utils.NOT_FOUND
+	case errors.Is(err, model.ErrAvailableExceedsCapacity),
+		errors.Is(err, model.ErrInvalidEventTime),
+		errors.Is(err, model.ErrInvalidSaleWindow),
+		errors.Is(err, model.ErrUnknownTicketType),
+		errors.Is(err, model.ErrTicketTypeRequired),
+		errors.Is(err, model.ErrEmptyOrder),
+		errors.Is(err, model.ErrCurrencyMismatch),
+		errors.Is(err, model.ErrInvalidDiscount),
+		errors.Is(err, model.ErrInvalidPromoWindow),
+		errors.Is(err, payment.ErrInvalidWebhook):
+		return http.StatusBadRequest, http_utils.INVALID_REQUEST
+	case errors.Is(err, payment.ErrInvalidSignature),
+		errors.Is(err, payment.ErrStaleWebhook),
+		errors.Is(err, auth.ErrUnauthenticated),
+		errors.Is(err, auth.ErrInvalidToken):
+		return http.StatusUnauthorized, http_utils.UNAUTHORIZED
+	case errors.Is(err, auth.ErrInsufficientRole):
+		return http.StatusForbidden, http_utils.INSUFFICIENT_ROLE
+	case errors.Is(err, auth.ErrNotOwner):
+		return http.StatusForbidden, http_utils.NOT_RESOURCE_OWNER
+	case errors.Is(err, auth.ErrForbidden),
+		errors.Is(err, service.ErrAdmissionRequired),
+		errors.Is(err, service.ErrInvalidAdmission):
+		return http.StatusForbidden, http_utils.FORBIDDEN
+	case errors.Is(err, model.ErrNotEnoughTickets),
+		errors.Is(err, service.ErrAlreadyHolding),
+		errors.As(err, &invalidTransition),
+		errors.As(err, &invalidEventTransition),
+		errors.As(err, &invalidPaymentTransition),
+		errors.Is(err, payment.ErrInvalidIntent),
+		errors.Is(err, model.ErrEventNotOnSale),
+		errors.Is(err, model.ErrTicketTypeNotOnSale),
+		errors.Is(err, model.ErrCancellationWindowClosed),
+		errors.Is(err, model.ErrVersionConflict),
+		errors.Is(err, model.ErrCapacityBelowCommitted),
+		errors.Is(err, model.ErrPromoCodeExists),
+		errors.Is(err, model.ErrRedemptionsAboveCap),
+		errors.Is(err, model.ErrBookingNotPurgeable),
+		errors.Is(err, model.ErrEventNotDraft),
+		errors.Is(err, model.ErrEventNotEditable),
+		errors.Is(err, service.ErrWebhookInProgress),
+		errors.Is(err, service.ErrWaitingRoomDisabled):
+		return http.StatusConflict, http_utils.CONFLICT
+	case errors.Is(err, model.ErrOrderLimitExceeded),
+		errors.Is(err, model.ErrUserLimitExceeded):
+		return http.StatusUnprocessableEntity, http_utils.PURCHASE_LIMIT_EXCEEDED
+	case errors.Is(err, model.ErrInvalidPromoCode),
+		errors.Is(err, model.ErrPromoCodeNotApplicable),
+		errors.Is(err, model.ErrPromoCodeExhausted):
+		return http.StatusUnprocessableEntity, http_utils.INVALID_PROMO_CODE
+	case errors.Is(err, payment.ErrPaymentDeclined):
+		return http.StatusPaymentRequired, http_utils.PAYMENT_REQUIRED
+	default:
+		return http.StatusInternalServerError, http_utils.INTERNAL_SERVER_ERROR
+	}
+}
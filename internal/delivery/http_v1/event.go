@@ -1,17 +1,28 @@
 package http_v1
 
 import (
-	"database/sql"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
 	"github.com/phamdinhha/event-booking-service/internal/service"
 	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 )
 
+var eventStatusChoices = []string{
+	string(model.EventStatusDraft),
+	string(model.EventStatusPublished),
+	string(model.EventStatusCancelled),
+	string(model.EventStatusCompleted),
+}
+
 type EventController struct {
 	logger   logger.Logger
 	eventSrv service.EventServiceInterface
@@ -27,14 +38,15 @@ func NewEventController(
 func (e *EventController) CreateEvent(c *gin.Context) {
 	var req dto.CreateEventDTO
 	if err := c.ShouldBindJSON(&req); err != nil {
-		e.logger.Error("EVENT_CONTROLLER.CREATE_EVENT.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(http_utils.INVALID_REQUEST, err.Error()))
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CREATE_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
 		return
 	}
 	created, err := e.eventSrv.CreateEvent(c.Request.Context(), &req)
 	if err != nil {
-		e.logger.Error("EVENT_CONTROLLER.CREATE_EVENT.Error", err)
-		c.JSON(http.StatusInternalServerError, http_utils.NewErrorResponse(http_utils.INTERNAL_SERVER_ERROR, err.Error()))
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CREATE_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
 		return
 	}
 	c.JSON(http.StatusCreated, http_utils.NewOKResponse(http_utils.CREATED, created))
@@ -44,8 +56,8 @@ func (e *EventController) GetEvent(c *gin.Context) {
 	eventID := c.Param("id")
 	id, err := uuid.Parse(eventID)
 	if err != nil {
-		e.logger.Error("EVENT_CONTROLLER.GET_EVENT.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.GET_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(),
 			http_utils.INVALID_REQUEST,
 			err,
 		))
@@ -54,17 +66,9 @@ func (e *EventController) GetEvent(c *gin.Context) {
 
 	event, err := e.eventSrv.GetEventByID(c.Request.Context(), id)
 	if err != nil {
-		e.logger.Error("EVENT_CONTROLLER.GET_EVENT.Error", err)
-		var statusCode int
-		var message string
-		if err == sql.ErrNoRows {
-			statusCode = http.StatusNotFound
-			message = http_utils.NOT_FOUND
-		} else {
-			statusCode = http.StatusInternalServerError
-			message = http_utils.INTERNAL_SERVER_ERROR
-		}
-		c.JSON(statusCode, http_utils.NewErrorResponse(message, err.Error()))
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.GET_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
 		return
 	}
 	c.JSON(http.StatusOK, http_utils.NewOKResponse(
@@ -73,12 +77,175 @@ func (e *EventController) GetEvent(c *gin.Context) {
 	))
 }
 
+func (e *EventController) ListEvents(c *gin.Context) {
+	categoryID, attrErr := utils.ParseUuidQuery("category_id", c.Query("category_id"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	organizerID, attrErr := utils.ParseUuidQuery("organizer_id", c.Query("organizer_id"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	status, attrErr := utils.ParseChoiceQuery("status", c.Query("status"), eventStatusChoices)
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	timestamps, attrErr := utils.ParseTimestampQuery(map[string]string{
+		"start_from": c.Query("start_from"),
+		"start_to":   c.Query("start_to"),
+	})
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	// Prices are filtered in minor units, best combined with a currency.
+	priceMin, attrErr := utils.ParseInt64("price_min", c.Query("price_min"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	priceMax, attrErr := utils.ParseInt64("price_max", c.Query("price_max"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	pagination, attrErr := parsePagination(c, repository.EventOrderChoices())
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+
+	filter := &dto.EventFilterDTO{
+		CategoryID:  categoryID,
+		OrganizerID: organizerID,
+		Status:      status,
+		Currency:    strings.ToUpper(strings.TrimSpace(c.Query("currency"))),
+		PriceMin:    priceMin,
+		PriceMax:    priceMax,
+		Location:    strings.TrimSpace(c.Query("location")),
+		Query:       strings.TrimSpace(c.Query("q")),
+	}
+	if timestamps["start_from"] > 0 {
+		filter.StartFrom = time.Unix(timestamps["start_from"], 0)
+	}
+	if timestamps["start_to"] > 0 {
+		filter.StartTo = time.Unix(timestamps["start_to"], 0)
+	}
+
+	events, err := e.eventSrv.ListEvents(c.Request.Context(), filter, pagination)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.LIST_EVENTS.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, events))
+}
+
+func (e *EventController) UpdateEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	var req dto.UpdateEventDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	updated, err := e.eventSrv.UpdateEvent(c.Request.Context(), id, &req)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, updated))
+}
+
+func (e *EventController) PublishEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.PUBLISH_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	published, err := e.eventSrv.PublishEvent(c.Request.Context(), id)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.PUBLISH_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, published))
+}
+
+func (e *EventController) CancelEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CANCEL_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	// The body is optional, a cancellation does not need a reason.
+	var req dto.CancelEventDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CANCEL_EVENT.Error", err)
+			c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+			return
+		}
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CANCEL_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	cancelled, err := e.eventSrv.CancelEvent(c.Request.Context(), id, &req)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CANCEL_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, cancelled))
+}
+
+func (e *EventController) CompleteEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.COMPLETE_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	completed, err := e.eventSrv.CompleteEvent(c.Request.Context(), id)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.COMPLETE_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, completed))
+}
+
 func (e *EventController) DeleteEvent(c *gin.Context) {
 	eventID := c.Param("id")
 	id, err := uuid.Parse(eventID)
 	if err != nil {
-		e.logger.Error("EVENT_CONTROLLER.DELETE_EVENT.Error", err)
-		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.DELETE_EVENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(),
 			http_utils.INVALID_REQUEST,
 			err,
 		))
@@ -86,17 +253,9 @@ func (e *EventController) DeleteEvent(c *gin.Context) {
 	}
 	err = e.eventSrv.DeleteEvent(c.Request.Context(), id)
 	if err != nil {
-		e.logger.Error("EVENT_CONTROLLER.DELETE_EVENT.Error", err)
-		var statusCode int
-		var message string
-		if err == sql.ErrNoRows {
-			statusCode = http.StatusNotFound
-			message = http_utils.NOT_FOUND
-		} else {
-			statusCode = http.StatusInternalServerError
-			message = http_utils.INTERNAL_SERVER_ERROR
-		}
-		response := http_utils.NewErrorResponse(message, err.Error())
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.DELETE_EVENT.Error", err)
+		statusCode, message := errorStatus(err)
+		response := http_utils.NewErrorResponse(c.Request.Context(), message, err.Error())
 		c.JSON(statusCode, response)
 		return
 	}
@@ -106,3 +265,85 @@ func (e *EventController) DeleteEvent(c *gin.Context) {
 		gin.H{"message": "Event successfully deleted"},
 	))
 }
+
+func (e *EventController) CreateTicketType(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CREATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	var req dto.CreateTicketTypeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CREATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CREATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	ticketType, err := e.eventSrv.CreateTicketType(c.Request.Context(), eventID, &req)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.CREATE_TICKET_TYPE.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, http_utils.NewOKResponse(http_utils.CREATED, ticketType))
+}
+
+func (e *EventController) ListTicketTypes(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.LIST_TICKET_TYPES.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	ticketTypes, err := e.eventSrv.ListTicketTypes(c.Request.Context(), eventID)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.LIST_TICKET_TYPES.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, ticketTypes))
+}
+
+func (e *EventController) UpdateTicketType(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	id, err := uuid.Parse(c.Param("type_id"))
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	var req dto.UpdateTicketTypeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_TICKET_TYPE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	ticketType, err := e.eventSrv.UpdateTicketType(c.Request.Context(), eventID, id, &req)
+	if err != nil {
+		e.logger.WithContext(c.Request.Context()).Error("EVENT_CONTROLLER.UPDATE_TICKET_TYPE.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, ticketType))
+}
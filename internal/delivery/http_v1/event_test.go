@@ -0,0 +1,109 @@
+package http_v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// fakeEventService records the arguments of ListEvents.
+type fakeEventService struct {
+	service.EventServiceInterface
+	filter     *dto.EventFilterDTO
+	pagination *utils.Pagination
+}
+
+func (f *fakeEventService) ListEvents(
+	_ context.Context,
+	filter *dto.EventFilterDTO,
+	pagination *utils.Pagination,
+) (*dto.EventListDTO, error) {
+	f.filter, f.pagination = filter, pagination
+	return &dto.EventListDTO{}, nil
+}
+
+func listEvents(t *testing.T, srv *fakeEventService, query string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events", NewEventController(testLogger(), srv).ListEvents)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events?"+query, nil))
+	return recorder.Code
+}
+
+func TestListEventsParsesFilter(t *testing.T) {
+	srv := &fakeEventService{}
+	categoryID, organizerID := uuid.New(), uuid.New()
+	startFrom, startTo := time.Unix(1700000000, 0), time.Unix(1800000000, 0)
+	query := url.Values{
+		"category_id":  {categoryID.String()},
+		"organizer_id": {organizerID.String()},
+		"status":       {"published"},
+		"start_from":   {fmt.Sprint(startFrom.Unix())},
+		"start_to":     {fmt.Sprint(startTo.Unix())},
+		"currency":     {" usd "},
+		"price_min":    {"1000"},
+		"price_max":    {"5000"},
+		"location":     {" Hanoi "},
+		"q":            {" jazz night "},
+		"orderBy":      {"relevance"},
+	}
+
+	if code := listEvents(t, srv, query.Encode()); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+	priceMin, priceMax := int64(1000), int64(5000)
+	want := &dto.EventFilterDTO{
+		CategoryID:  categoryID,
+		OrganizerID: organizerID,
+		Status:      "published",
+		StartFrom:   startFrom,
+		StartTo:     startTo,
+		Currency:    "USD",
+		PriceMin:    &priceMin,
+		PriceMax:    &priceMax,
+		Location:    "Hanoi",
+		Query:       "jazz night",
+	}
+	if !reflect.DeepEqual(srv.filter, want) {
+		t.Errorf("filter = %+v, want %+v", srv.filter, want)
+	}
+	if got := srv.pagination.GetOrderBy(); got != "relevance" {
+		t.Errorf("orderBy = %q, want relevance", got)
+	}
+}
+
+func TestListEventsRejectsInvalidFilter(t *testing.T) {
+	tests := map[string]string{
+		"category id": "category_id=music",
+		"status":      "status=sold_out",
+		"start":       "start_from=tomorrow",
+		"price":       "price_min=-1",
+		"price range": "price_max=ten",
+		"order":       "orderBy=price",
+	}
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := &fakeEventService{}
+			if code := listEvents(t, srv, query); code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", code, http.StatusBadRequest)
+			}
+			if srv.filter != nil {
+				t.Error("service called for an invalid filter")
+			}
+		})
+	}
+}
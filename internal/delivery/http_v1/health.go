@@ -28,17 +28,17 @@ func (h *HealthCheckController) GetHealthCheck(c *gin.Context) {
 	statusCode := http.StatusOK
 
 	// Check Redis connection
-	_, err := h.redis.Ping(c).Result()
+	_, err := h.redis.Ping(c.Request.Context()).Result()
 	if err != nil {
-		h.logger.Error("Redis health check failed", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Redis health check failed", "error", err)
 		status = "unhealthy"
 		statusCode = http.StatusServiceUnavailable
 	}
 
 	// Check DB connection
-	err = h.db.Ping()
+	err = h.db.PingContext(c.Request.Context())
 	if err != nil {
-		h.logger.Error("Database health check failed", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Database health check failed", "error", err)
 		status = "unhealthy"
 		statusCode = http.StatusServiceUnavailable
 	}
@@ -0,0 +1,120 @@
+package http_v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+type HoldController struct {
+	logger    logger.Logger
+	ticketSrv service.TicketServiceInterface
+}
+
+func NewHoldController(
+	logger logger.Logger,
+	ticketSrv service.TicketServiceInterface,
+) HoldControllerInterface {
+	return &HoldController{logger: logger, ticketSrv: ticketSrv}
+}
+
+func (h *HoldController) CreateHold(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.CREATE_HOLD.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	var req dto.CreateHoldDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.CREATE_HOLD.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	if req.UserID, err = currentUserID(c); err != nil {
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.CREATE_HOLD.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	items := make([]model.LineItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, model.LineItem{TicketTypeID: item.TicketTypeID, Quantity: item.Quantity})
+	}
+	if len(items) == 0 && req.Quantity > 0 {
+		// Quantity without items holds the only ticket type of the event.
+		items = append(items, model.LineItem{Quantity: req.Quantity})
+	}
+
+	hold, err := h.ticketSrv.HoldTickets(c.Request.Context(), eventID, req.UserID, items, req.AdmissionToken, req.PromoCode)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.CREATE_HOLD.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, http_utils.NewOKResponse(http_utils.CREATED, hold))
+}
+
+func (h *HoldController) GetHold(c *gin.Context) {
+	eventID, userID, err := parseHoldParams(c)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.GET_HOLD.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	hold, err := h.ticketSrv.GetHold(c.Request.Context(), eventID, userID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.GET_HOLD.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, hold))
+}
+
+func (h *HoldController) ReleaseHold(c *gin.Context) {
+	eventID, userID, err := parseHoldParams(c)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.RELEASE_HOLD.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	err = h.ticketSrv.ReleaseHold(c.Request.Context(), eventID, userID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("HOLD_CONTROLLER.RELEASE_HOLD.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(
+		http_utils.SUCCESS,
+		gin.H{"message": "Hold successfully released"},
+	))
+}
+
+func parseHoldParams(c *gin.Context) (eventID, userID uuid.UUID, err error) {
+	eventID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	userID, err = uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return eventID, userID, nil
+}
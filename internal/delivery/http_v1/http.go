@@ -3,6 +3,8 @@ package http_v1
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/payment"
 	"github.com/phamdinhha/event-booking-service/internal/repository"
 	"github.com/phamdinhha/event-booking-service/internal/service"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
@@ -12,9 +14,43 @@ import (
 type BookingControllerInterface interface {
 	CreateBooking(c *gin.Context)
 	GetBooking(c *gin.Context)
+	CancelBooking(c *gin.Context)
+	GetBookingHistory(c *gin.Context)
+	ListUserBookings(c *gin.Context)
 	DeleteBooking(c *gin.Context)
 }
 
+type HoldControllerInterface interface {
+	CreateHold(c *gin.Context)
+	GetHold(c *gin.Context)
+	ReleaseHold(c *gin.Context)
+}
+
+type WaitingRoomControllerInterface interface {
+	JoinQueue(c *gin.Context)
+	GetQueueTicket(c *gin.Context)
+}
+
+type PaymentControllerInterface interface {
+	GetPayment(c *gin.Context)
+	CapturePayment(c *gin.Context)
+	RefundPayment(c *gin.Context)
+	HandleWebhook(c *gin.Context)
+}
+
+type AdminControllerInterface interface {
+	GetEventInventory(c *gin.Context)
+	ListWebhookEvents(c *gin.Context)
+	GetWebhookEvent(c *gin.Context)
+}
+
+type PromoCodeControllerInterface interface {
+	CreatePromoCode(c *gin.Context)
+	GetPromoCode(c *gin.Context)
+	ListPromoCodes(c *gin.Context)
+	UpdatePromoCode(c *gin.Context)
+}
+
 type HealthCheckInterface interface {
 	GetHealthCheck(c *gin.Context)
 }
@@ -22,34 +58,61 @@ type HealthCheckInterface interface {
 // Controller for testing data, should be in another service
 type EventControllerInterface interface {
 	CreateEvent(c *gin.Context)
+	ListEvents(c *gin.Context)
 	GetEvent(c *gin.Context)
+	UpdateEvent(c *gin.Context)
+	PublishEvent(c *gin.Context)
+	CancelEvent(c *gin.Context)
+	CompleteEvent(c *gin.Context)
 	DeleteEvent(c *gin.Context)
+	CreateTicketType(c *gin.Context)
+	ListTicketTypes(c *gin.Context)
+	UpdateTicketType(c *gin.Context)
 }
 
 type ControllerFactory struct {
-	db     *sqlx.DB
-	logger logger.Logger
-	redis  *redis.Client
+	cfg     *config.Config
+	db      *sqlx.DB
+	logger  logger.Logger
+	redis   *redis.Client
+	gateway payment.Gateway
 }
 
 func NewControllerFactory(
+	cfg *config.Config,
 	db *sqlx.DB,
 	logger logger.Logger,
 	redis *redis.Client,
+	gateway payment.Gateway,
 ) *ControllerFactory {
 	return &ControllerFactory{
-		db:     db,
-		logger: logger,
-		redis:  redis,
+		cfg:     cfg,
+		db:      db,
+		logger:  logger,
+		redis:   redis,
+		gateway: gateway,
 	}
 }
 
 func (f *ControllerFactory) NewBookingController() BookingControllerInterface {
 	bookingRepo := repository.NewBookingRepository(f.db, f.logger)
-	bookingSrv := service.NewBookingService(bookingRepo, f.logger, f.redis)
+	eventRepo := repository.NewEventRepository(f.db, f.logger)
+	promoRepo := repository.NewPromoCodeRepository(f.db, f.logger)
+	bookingSrv := service.NewBookingService(f.cfg, bookingRepo, eventRepo, promoRepo, f.newPaymentService(), f.logger, f.redis)
 	return NewBookingController(f.logger, bookingSrv)
 }
 
+func (f *ControllerFactory) NewPaymentController() PaymentControllerInterface {
+	return NewPaymentController(f.logger, f.newPaymentService())
+}
+
+func (f *ControllerFactory) newPaymentService() service.PaymentServiceInterface {
+	bookingRepo := repository.NewBookingRepository(f.db, f.logger)
+	paymentRepo := repository.NewPaymentRepository(f.db, f.logger)
+	webhookRepo := repository.NewWebhookRepository(f.db, f.logger)
+	return service.NewPaymentService(f.cfg, f.gateway, paymentRepo, bookingRepo, webhookRepo, f.logger, f.redis)
+}
+
 func (f *ControllerFactory) NewHealthCheckController() HealthCheckInterface {
 	return NewHealthCheckController(f.logger, f.redis, f.db)
 }
@@ -59,3 +122,30 @@ func (f *ControllerFactory) NewEventController() EventControllerInterface {
 	eventSrv := service.NewEventService(eventRepo, f.logger, f.redis)
 	return NewEventController(f.logger, eventSrv)
 }
+
+func (f *ControllerFactory) NewPromoCodeController() PromoCodeControllerInterface {
+	promoRepo := repository.NewPromoCodeRepository(f.db, f.logger)
+	eventRepo := repository.NewEventRepository(f.db, f.logger)
+	promoSrv := service.NewPromoCodeService(promoRepo, eventRepo)
+	return NewPromoCodeController(f.logger, promoSrv)
+}
+
+func (f *ControllerFactory) NewHoldController() HoldControllerInterface {
+	bookingRepo := repository.NewBookingRepository(f.db, f.logger)
+	eventRepo := repository.NewEventRepository(f.db, f.logger)
+	promoRepo := repository.NewPromoCodeRepository(f.db, f.logger)
+	ticketSrv := service.NewTicketService(f.cfg, bookingRepo, eventRepo, promoRepo, f.redis)
+	return NewHoldController(f.logger, ticketSrv)
+}
+
+func (f *ControllerFactory) NewWaitingRoomController() WaitingRoomControllerInterface {
+	eventRepo := repository.NewEventRepository(f.db, f.logger)
+	waitingRoomSrv := service.NewWaitingRoomService(f.cfg, eventRepo, f.logger, f.redis)
+	return NewWaitingRoomController(f.logger, waitingRoomSrv)
+}
+
+func (f *ControllerFactory) NewAdminController() AdminControllerInterface {
+	eventRepo := repository.NewEventRepository(f.db, f.logger)
+	inventorySrv := service.NewInventoryService(eventRepo, f.logger, f.redis)
+	return NewAdminController(f.logger, inventorySrv, f.newPaymentService())
+}
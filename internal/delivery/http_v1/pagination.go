@@ -0,0 +1,36 @@
+package http_v1
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+const maxPageSize = 100
+
+// parsePagination reads page, size and orderBy from the query string.
+func parsePagination(c *gin.Context, orderChoices []string) (*utils.Pagination, *http_utils.AttributeError) {
+	pagination := &utils.Pagination{}
+	if err := pagination.SetPage(c.Query("page")); err != nil || pagination.GetPage() < 1 {
+		return nil, &http_utils.AttributeError{
+			Attribute:  "page",
+			Cause:      fmt.Sprintf("invalid page: %s", c.Query("page")),
+			Constraint: "page must be a positive integer.",
+		}
+	}
+	if err := pagination.SetSize(c.Query("size")); err != nil || pagination.GetSize() < 1 || pagination.GetSize() > maxPageSize {
+		return nil, &http_utils.AttributeError{
+			Attribute:  "size",
+			Cause:      fmt.Sprintf("invalid size: %s", c.Query("size")),
+			Constraint: fmt.Sprintf("size must be an integer between 1 and %d.", maxPageSize),
+		}
+	}
+	orderBy, attrErr := utils.ParseChoiceQuery("orderBy", c.Query("orderBy"), orderChoices)
+	if attrErr != nil {
+		return nil, attrErr
+	}
+	pagination.SetOrderBy(orderBy)
+	return pagination, nil
+}
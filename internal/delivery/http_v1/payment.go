@@ -0,0 +1,101 @@
+package http_v1
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+// maxWebhookBodySize bounds the payload read from a payment provider
+const maxWebhookBodySize = 1 << 20
+
+type PaymentController struct {
+	logger     logger.Logger
+	paymentSrv service.PaymentServiceInterface
+}
+
+func NewPaymentController(
+	logger logger.Logger,
+	paymentSrv service.PaymentServiceInterface,
+) PaymentControllerInterface {
+	return &PaymentController{logger: logger, paymentSrv: paymentSrv}
+}
+
+func (p *PaymentController) GetPayment(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.GET_PAYMENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	payment, err := p.paymentSrv.GetPayment(c.Request.Context(), bookingID)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.GET_PAYMENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, payment))
+}
+
+func (p *PaymentController) CapturePayment(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.CAPTURE_PAYMENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	booking, err := p.paymentSrv.CapturePayment(c.Request.Context(), bookingID)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.CAPTURE_PAYMENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, booking))
+}
+
+func (p *PaymentController) RefundPayment(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.REFUND_PAYMENT.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	booking, err := p.paymentSrv.RefundPayment(c.Request.Context(), bookingID)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.REFUND_PAYMENT.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, booking))
+}
+
+// HandleWebhook receives the notifications of the payment provider. The raw
+// body is passed on untouched because the signature is computed over it.
+func (p *PaymentController) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.HANDLE_WEBHOOK.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	event, err := p.paymentSrv.HandleWebhook(c.Request.Context(), payload, c.Request.Header)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PAYMENT_CONTROLLER.HANDLE_WEBHOOK.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, event))
+}
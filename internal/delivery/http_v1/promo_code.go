@@ -0,0 +1,127 @@
+package http_v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+type PromoCodeController struct {
+	logger   logger.Logger
+	promoSrv service.PromoCodeServiceInterface
+}
+
+func NewPromoCodeController(
+	logger logger.Logger,
+	promoSrv service.PromoCodeServiceInterface,
+) PromoCodeControllerInterface {
+	return &PromoCodeController{logger: logger, promoSrv: promoSrv}
+}
+
+func (p *PromoCodeController) CreatePromoCode(c *gin.Context) {
+	var req dto.CreatePromoCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.CREATE_PROMO_CODE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.CREATE_PROMO_CODE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	promo, err := p.promoSrv.CreatePromoCode(c.Request.Context(), &req)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.CREATE_PROMO_CODE.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, http_utils.NewOKResponse(http_utils.CREATED, promo))
+}
+
+func (p *PromoCodeController) GetPromoCode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.GET_PROMO_CODE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	promo, err := p.promoSrv.GetPromoCode(c.Request.Context(), id)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.GET_PROMO_CODE.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, promo))
+}
+
+func (p *PromoCodeController) ListPromoCodes(c *gin.Context) {
+	eventID, attrErr := utils.ParseUuidQuery("event_id", c.Query("event_id"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	active, attrErr := utils.ParseBool("active", c.Query("active"))
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+	pagination, attrErr := parsePagination(c, nil)
+	if attrErr != nil {
+		c.JSON(http.StatusBadRequest, http_utils.NewAttributeErrorResponse(c.Request.Context(), *attrErr))
+		return
+	}
+
+	filter := &dto.PromoCodeFilterDTO{EventID: eventID}
+	if c.Query("active") != "" {
+		filter.Active = &active
+	}
+
+	promos, err := p.promoSrv.ListPromoCodes(c.Request.Context(), filter, pagination)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.LIST_PROMO_CODES.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, promos))
+}
+
+func (p *PromoCodeController) UpdatePromoCode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.UPDATE_PROMO_CODE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	var req dto.UpdatePromoCodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.UPDATE_PROMO_CODE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	if err := utils.ValidateStruct(c.Request.Context(), &req); err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.UPDATE_PROMO_CODE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	promo, err := p.promoSrv.UpdatePromoCode(c.Request.Context(), id, &req)
+	if err != nil {
+		p.logger.WithContext(c.Request.Context()).Error("PROMO_CODE_CONTROLLER.UPDATE_PROMO_CODE.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, promo))
+}
@@ -1,6 +1,10 @@
 package http_v1
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/middleware"
+)
 
 func MapHealthCheckRoutes(
 	router *gin.RouterGroup,
@@ -12,17 +16,97 @@ func MapHealthCheckRoutes(
 func MapBookingRoutes(
 	router *gin.RouterGroup,
 	controller BookingControllerInterface,
+	mw *middleware.MiddlewareManager,
 ) {
-	router.POST("/", controller.CreateBooking)
+	limit := mw.RateLimit(middleware.RateLimitBookings)
+	router.POST("/", limit, mw.Idempotency(), controller.CreateBooking)
 	router.GET("/:id", controller.GetBooking)
-	router.DELETE("/:id", controller.DeleteBooking)
+	router.PUT("/:id/cancel", limit, controller.CancelBooking)
+	router.GET("/:id/history", controller.GetBookingHistory)
+	router.DELETE("/:id", mw.RequireRole(auth.RoleAdmin), limit, controller.DeleteBooking)
 }
 
+func MapPaymentRoutes(
+	router *gin.RouterGroup,
+	controller PaymentControllerInterface,
+	mw *middleware.MiddlewareManager,
+) {
+	router.GET("/:id/payment", controller.GetPayment)
+	router.POST("/:id/payment/capture", mw.Idempotency(), controller.CapturePayment)
+	router.POST("/:id/payment/refund", mw.RequireRole(auth.RoleAdmin), mw.Idempotency(), controller.RefundPayment)
+}
+
+func MapWebhookRoutes(
+	router *gin.RouterGroup,
+	controller PaymentControllerInterface,
+) {
+	router.POST("/payments", controller.HandleWebhook)
+}
+
+func MapUserRoutes(
+	router *gin.RouterGroup,
+	bookingController BookingControllerInterface,
+) {
+	router.GET("/:user_id/bookings", bookingController.ListUserBookings)
+}
+
+// MapEventRoutes lets everyone read events, drafts are only shown to their
+// organizer and admins. Mutations take an organizer, who may only touch their
+// own events, or an admin. Only draft events can be
+// deleted, published ones are cancelled so their bookings and payments stay.
 func MapEventRoutes(
 	router *gin.RouterGroup,
 	controller EventControllerInterface,
+	mw *middleware.MiddlewareManager,
 ) {
-	router.POST("/", controller.CreateEvent)
+	organizer := mw.RequireRole(auth.RoleOrganizer, auth.RoleAdmin)
+	router.POST("/", organizer, controller.CreateEvent)
+	router.GET("/", controller.ListEvents)
 	router.GET("/:id", controller.GetEvent)
-	router.DELETE("/:id", controller.DeleteEvent)
+	router.PATCH("/:id", organizer, controller.UpdateEvent)
+	router.POST("/:id/publish", organizer, controller.PublishEvent)
+	router.POST("/:id/cancel", organizer, controller.CancelEvent)
+	router.POST("/:id/complete", organizer, controller.CompleteEvent)
+	router.DELETE("/:id", organizer, controller.DeleteEvent)
+	router.POST("/:id/ticket-types", organizer, controller.CreateTicketType)
+	router.GET("/:id/ticket-types", controller.ListTicketTypes)
+	router.PATCH("/:id/ticket-types/:type_id", organizer, controller.UpdateTicketType)
+}
+
+func MapHoldRoutes(
+	router *gin.RouterGroup,
+	controller HoldControllerInterface,
+	mw *middleware.MiddlewareManager,
+) {
+	limit := mw.RateLimit(middleware.RateLimitHolds)
+	router.POST("/:id/holds", limit, mw.Idempotency(), controller.CreateHold)
+	router.GET("/:id/holds/:user_id", controller.GetHold)
+	router.DELETE("/:id/holds/:user_id", limit, controller.ReleaseHold)
+}
+
+func MapWaitingRoomRoutes(
+	router *gin.RouterGroup,
+	controller WaitingRoomControllerInterface,
+) {
+	router.POST("/:id/queue", controller.JoinQueue)
+	router.GET("/:id/queue/:token", controller.GetQueueTicket)
+}
+
+func MapAdminRoutes(
+	router *gin.RouterGroup,
+	controller AdminControllerInterface,
+) {
+	router.GET("/events/:id/inventory", controller.GetEventInventory)
+	router.GET("/webhooks", controller.ListWebhookEvents)
+	router.GET("/webhooks/:id", controller.GetWebhookEvent)
+}
+
+func MapPromoCodeRoutes(
+	router *gin.RouterGroup,
+	controller PromoCodeControllerInterface,
+) {
+	router.POST("/", controller.CreatePromoCode)
+	router.GET("/", controller.ListPromoCodes)
+	router.GET("/:id", controller.GetPromoCode)
+	router.PATCH("/:id", controller.UpdatePromoCode)
 }
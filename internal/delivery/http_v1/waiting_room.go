@@ -0,0 +1,65 @@
+package http_v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+type WaitingRoomController struct {
+	logger         logger.Logger
+	waitingRoomSrv service.WaitingRoomServiceInterface
+}
+
+func NewWaitingRoomController(
+	logger logger.Logger,
+	waitingRoomSrv service.WaitingRoomServiceInterface,
+) WaitingRoomControllerInterface {
+	return &WaitingRoomController{logger: logger, waitingRoomSrv: waitingRoomSrv}
+}
+
+func (h *WaitingRoomController) JoinQueue(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("WAITING_ROOM_CONTROLLER.JOIN_QUEUE.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+
+	ticket, err := h.waitingRoomSrv.JoinQueue(c.Request.Context(), eventID, userID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("WAITING_ROOM_CONTROLLER.JOIN_QUEUE.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, http_utils.NewOKResponse(http_utils.CREATED, ticket))
+}
+
+func (h *WaitingRoomController) GetQueueTicket(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("WAITING_ROOM_CONTROLLER.GET_QUEUE_TICKET.Error", err)
+		c.JSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	ticket, err := h.waitingRoomSrv.GetQueueTicket(c.Request.Context(), eventID, c.Param("token"))
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("WAITING_ROOM_CONTROLLER.GET_QUEUE_TICKET.Error", err)
+		statusCode, message := errorStatus(err)
+		c.JSON(statusCode, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, http_utils.NewOKResponse(http_utils.SUCCESS, ticket))
+}
@@ -7,9 +7,14 @@ import (
 )
 
 type CreateBookingDTO struct {
-	EventID  uuid.UUID `json:"event_id" validate:"required"`
-	UserID   uuid.UUID `json:"user_id" validate:"required"`
+	EventID uuid.UUID `json:"event_id" validate:"required"`
+	// UserID is the authenticated caller, never taken from the body
+	UserID   uuid.UUID `json:"-" validate:"required"`
 	Quantity int       `json:"quantity" validate:"required"`
+	// Email receives the confirmation and the event reminders
+	Email string `json:"email" validate:"omitempty,email,max=255"`
+	// PromoCode overrides the promo code entered with the hold
+	PromoCode string `json:"promo_code" validate:"omitempty,max=64"`
 }
 
 type BookingDTO struct {
@@ -20,4 +25,49 @@ type BookingDTO struct {
 	Status    string    `json:"status" validate:"required"`
 	CreatedAt time.Time `json:"created_at" validate:"required"`
 	UpdatedAt time.Time `json:"updated_at" validate:"required"`
+
+	CancellationReason *string    `json:"cancellation_reason,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	ContactEmail       *string    `json:"contact_email,omitempty"`
+	PromoCodeID        *uuid.UUID `json:"promo_code_id,omitempty"`
+
+	Items    []*BookingItemDTO `json:"items,omitempty"`
+	Subtotal MoneyDTO          `json:"subtotal"`
+	Discount MoneyDTO          `json:"discount"`
+	Fees     MoneyDTO          `json:"fees"`
+	Total    MoneyDTO          `json:"total"`
+	Payment  *PaymentDTO       `json:"payment,omitempty"`
+}
+
+type BookingItemDTO struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id"`
+	Quantity     int       `json:"quantity"`
+	UnitPrice    MoneyDTO  `json:"unit_price"`
+}
+
+type CancelBookingDTO struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type UserBookingDTO struct {
+	BookingDTO
+	EventTitle     string    `json:"event_title"`
+	EventStartTime time.Time `json:"event_start_time"`
+}
+
+type BookingListDTO struct {
+	Bookings   []*UserBookingDTO `json:"bookings"`
+	TotalCount int               `json:"total_count"`
+	TotalPages int               `json:"total_pages"`
+	Page       int               `json:"page"`
+	Size       int               `json:"size"`
+	HasMore    bool              `json:"has_more"`
+}
+
+type BookingFilterDTO struct {
+	UserID  uuid.UUID
+	EventID uuid.UUID
+	Status  string
+	From    time.Time
+	To      time.Time
 }
@@ -6,18 +6,58 @@ import (
 	"github.com/google/uuid"
 )
 
+// CreateEventDTO creates the event with TicketTypes, or with a single General
+// ticket type of Capacity, AvailableTickets and Price when there are none. All
+// prices of an event share one currency.
 type CreateEventDTO struct {
 	Title            string    `json:"title" validate:"required"`
 	Description      string    `json:"description" validate:"required"`
 	StartTime        time.Time `json:"start_time" validate:"required"`
 	EndTime          time.Time `json:"end_time" validate:"required"`
 	Location         string    `json:"location" validate:"required"`
-	Capacity         int       `json:"capacity" validate:"required"`
-	Price            float64   `json:"price" validate:"required"`
+	Capacity         int       `json:"capacity" validate:"required_without=TicketTypes"`
+	Price            *MoneyDTO `json:"price" validate:"required_without=TicketTypes"`
 	OrganizerId      uuid.UUID `json:"organizer_id" validate:"required"`
 	CategoryId       uuid.UUID `json:"category_id" validate:"required"`
-	Status           string    `json:"status" validate:"required"`
-	AvailableTickets int       `json:"available_tickets" validate:"required"`
+	Status           string    `json:"status"`
+	AvailableTickets int       `json:"available_tickets" validate:"required_without=TicketTypes"`
+	WaitingRoom      bool      `json:"waiting_room"`
+
+	TicketTypes []CreateTicketTypeDTO `json:"ticket_types" validate:"omitempty,min=1,max=20,dive"`
+
+	// Purchase limits, zero or omitted means unlimited.
+	MaxTicketsPerUser  int `json:"max_tickets_per_user" validate:"gte=0"`
+	MaxTicketsPerOrder int `json:"max_tickets_per_order" validate:"gte=0"`
+}
+
+type CancelEventDTO struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// EventCancellationDTO is the cancelled event with the number of bookings the
+// cancellation moved to cancelled and to refund_pending.
+type EventCancellationDTO struct {
+	Event                 *EventDTO `json:"event"`
+	CancelledBookings     int       `json:"cancelled_bookings"`
+	RefundPendingBookings int       `json:"refund_pending_bookings"`
+}
+
+// UpdateEventDTO is a partial update, nil fields are left untouched. Version
+// must be the version the client read, the update fails if it changed since.
+// Capacity and price are changed through the ticket types.
+type UpdateEventDTO struct {
+	Version     int        `json:"version" validate:"required,gt=0"`
+	Title       *string    `json:"title" validate:"omitempty,min=1,max=255"`
+	Description *string    `json:"description"`
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	Location    *string    `json:"location" validate:"omitempty,min=1,max=255"`
+	CategoryId  *uuid.UUID `json:"category_id"`
+	WaitingRoom *bool      `json:"waiting_room"`
+
+	// Purchase limits, zero removes the limit.
+	MaxTicketsPerUser  *int `json:"max_tickets_per_user" validate:"omitempty,gte=0"`
+	MaxTicketsPerOrder *int `json:"max_tickets_per_order" validate:"omitempty,gte=0"`
 }
 
 type EventDTO struct {
@@ -28,11 +68,40 @@ type EventDTO struct {
 	EndTime          time.Time `json:"end_time"`
 	Location         string    `json:"location"`
 	Capacity         int       `json:"capacity"`
-	Price            float64   `json:"price"`
+	Price            MoneyDTO  `json:"price"`
 	OrganizerId      uuid.UUID `json:"organizer_id"`
 	CategoryId       uuid.UUID `json:"category_id"`
 	Status           string    `json:"status"`
 	AvailableTickets int       `json:"available_tickets"`
+	WaitingRoom      bool      `json:"waiting_room"`
+	Version          int       `json:"version"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+
+	MaxTicketsPerUser  int `json:"max_tickets_per_user"`
+	MaxTicketsPerOrder int `json:"max_tickets_per_order"`
+
+	TicketTypes []*TicketTypeDTO `json:"ticket_types,omitempty"`
+}
+
+type EventListDTO struct {
+	Events     []*EventDTO `json:"events"`
+	TotalCount int         `json:"total_count"`
+	TotalPages int         `json:"total_pages"`
+	Page       int         `json:"page"`
+	Size       int         `json:"size"`
+	HasMore    bool        `json:"has_more"`
+}
+
+type EventFilterDTO struct {
+	CategoryID  uuid.UUID
+	OrganizerID uuid.UUID
+	Status      string
+	StartFrom   time.Time
+	StartTo     time.Time
+	Currency    string
+	PriceMin    *int64
+	PriceMax    *int64
+	Location    string
+	Query       string
 }
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+func TestCreateEventDTOValidation(t *testing.T) {
+	const base = `"title": "Concert", "description": "Live", "location": "Hall",
+		"start_time": "2030-01-01T20:00:00Z", "end_time": "2030-01-01T23:00:00Z",
+		"organizer_id": "7d9f3c2e-1a4b-4c5d-8e6f-0a1b2c3d4e5f",
+		"category_id": "1b2c3d4e-5f60-4718-8293-a4b5c6d7e8f9"`
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{
+			name:  "single tier from price and capacity",
+			body:  `{` + base + `, "capacity": 100, "available_tickets": 100, "price": {"amount": 2500, "currency": "USD"}}`,
+			valid: true,
+		},
+		{
+			name: "ticket types without price",
+			body: `{` + base + `, "ticket_types": [
+				{"name": "VIP", "capacity": 10, "price": {"amount": 9000, "currency": "USD"}}
+			]}`,
+			valid: true,
+		},
+		{
+			name:  "no ticket types and no price",
+			body:  `{` + base + `, "capacity": 100, "available_tickets": 100}`,
+			valid: false,
+		},
+		{
+			name:  "empty ticket types and no price",
+			body:  `{` + base + `, "capacity": 100, "available_tickets": 100, "ticket_types": []}`,
+			valid: false,
+		},
+		{
+			name:  "empty ticket types with price",
+			body:  `{` + base + `, "capacity": 100, "available_tickets": 100, "price": {"amount": 2500, "currency": "USD"}, "ticket_types": []}`,
+			valid: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req CreateEventDTO
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			err := utils.ValidateStruct(context.Background(), &req)
+			if tt.valid && err != nil {
+				t.Fatalf("ValidateStruct() = %v, want nil", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("ValidateStruct() = nil, want a validation error")
+			}
+			// Whatever passes validation has the price of its fallback tier.
+			if err == nil && len(req.TicketTypes) == 0 && req.Price == nil {
+				t.Fatal("valid request without ticket types has no price")
+			}
+		})
+	}
+}
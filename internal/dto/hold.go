@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HoldItemDTO is a line item of a hold.
+type HoldItemDTO struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id" validate:"required"`
+	Quantity     int       `json:"quantity" validate:"required,gt=0"`
+}
+
+// CreateHoldDTO holds either Items or, for events with a single ticket type,
+// Quantity tickets of that ticket type.
+type CreateHoldDTO struct {
+	// UserID is the authenticated caller, never taken from the body
+	UserID         uuid.UUID     `json:"-" validate:"required"`
+	Quantity       int           `json:"quantity" validate:"gte=0"`
+	Items          []HoldItemDTO `json:"items" validate:"omitempty,max=20,dive"`
+	AdmissionToken string        `json:"admission_token"`
+	PromoCode      string        `json:"promo_code" validate:"omitempty,max=64"`
+}
+
+type HoldDTO struct {
+	EventID   uuid.UUID     `json:"event_id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Quantity  int           `json:"quantity"`
+	Items     []HoldItemDTO `json:"items"`
+	TTL       int64         `json:"ttl_seconds"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	PromoCode string        `json:"promo_code,omitempty"`
+	// Discount estimates the discount of the promo code at the current prices
+	Discount *MoneyDTO `json:"discount,omitempty"`
+}
+
+// QueueTicketDTO is a place in the waiting room of an event. A waiting token
+// has a position and an estimated wait, an admitted token can be used as
+// admission_token when holding tickets until AdmissionExpiresAt.
+type QueueTicketDTO struct {
+	EventID              uuid.UUID  `json:"event_id"`
+	Token                string     `json:"token"`
+	Status               string     `json:"status"`
+	Position             int64      `json:"position,omitempty"`
+	EstimatedWaitSeconds int64      `json:"estimated_wait_seconds,omitempty"`
+	AdmissionExpiresAt   *time.Time `json:"admission_expires_at,omitempty"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "github.com/google/uuid"
+
+// InventoryDTO compares the availability of an event in Redis with Postgres.
+// The event level fields sum up the ticket types, Drift is the total drift
+// over the ticket types whose counters are seeded.
+type InventoryDTO struct {
+	EventID           uuid.UUID                 `json:"event_id"`
+	Capacity          int                       `json:"capacity"`
+	DBAvailable       int                       `json:"db_available"`
+	ActiveHolds       int                       `json:"active_holds"`
+	HeldTickets       int                       `json:"held_tickets"`
+	ExpectedAvailable int                       `json:"expected_available"`
+	Seeded            bool                      `json:"seeded"`
+	RedisAvailable    *int                      `json:"redis_available"`
+	Drift             int                       `json:"drift"`
+	TicketTypes       []*TicketTypeInventoryDTO `json:"ticket_types"`
+}
+
+type TicketTypeInventoryDTO struct {
+	TicketTypeID      uuid.UUID `json:"ticket_type_id"`
+	Name              string    `json:"name"`
+	Capacity          int       `json:"capacity"`
+	DBAvailable       int       `json:"db_available"`
+	HeldTickets       int       `json:"held_tickets"`
+	ExpectedAvailable int       `json:"expected_available"`
+	Seeded            bool      `json:"seeded"`
+	RedisAvailable    *int      `json:"redis_available"`
+	Drift             int       `json:"drift"`
+}
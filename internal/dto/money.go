@@ -0,0 +1,8 @@
+package dto
+
+// MoneyDTO is an amount in the minor units of an ISO 4217 currency, e.g.
+// {"amount": 1250, "currency": "USD"} is $12.50.
+type MoneyDTO struct {
+	Amount   int64  `json:"amount" validate:"gte=0"`
+	Currency string `json:"currency" validate:"required,iso4217"`
+}
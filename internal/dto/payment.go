@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentDTO struct {
+	ID        uuid.UUID `json:"id"`
+	BookingID uuid.UUID `json:"booking_id"`
+	Provider  string    `json:"provider"`
+	IntentID  string    `json:"intent_id"`
+	Amount    MoneyDTO  `json:"amount"`
+	Status    string    `json:"status"`
+	// ClientSecret is only returned when the payment is created
+	ClientSecret string    `json:"client_secret,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type WebhookEventFilterDTO struct {
+	Provider  string
+	EventType string
+	IntentID  string
+	Status    string
+	From      time.Time
+	To        time.Time
+}
+
+type WebhookEventDTO struct {
+	ID          uuid.UUID       `json:"id"`
+	Provider    string          `json:"provider"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	IntentID    string          `json:"intent_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Error       *string         `json:"error,omitempty"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
+
+type WebhookEventListDTO struct {
+	Events     []*WebhookEventDTO `json:"events"`
+	TotalCount int                `json:"total_count"`
+	TotalPages int                `json:"total_pages"`
+	Page       int                `json:"page"`
+	Size       int                `json:"size"`
+	HasMore    bool               `json:"has_more"`
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreatePromoCodeDTO creates a percentage code with PercentOff or a fixed code
+// with AmountOff. Zero caps do not restrict the code, open validity bounds do
+// not restrict the window and a nil EventID makes the code global.
+type CreatePromoCodeDTO struct {
+	Code         string     `json:"code" validate:"required,min=3,max=64,alphanumunicode"`
+	EventID      *uuid.UUID `json:"event_id"`
+	DiscountType string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	// PercentOff is in basis points, 1000 is 10%
+	PercentOff            int64      `json:"percent_off" validate:"gte=0,lte=10000"`
+	AmountOff             *MoneyDTO  `json:"amount_off" validate:"required_if=DiscountType fixed"`
+	MaxRedemptions        int        `json:"max_redemptions" validate:"gte=0"`
+	MaxRedemptionsPerUser int        `json:"max_redemptions_per_user" validate:"gte=0"`
+	MinQuantity           int        `json:"min_quantity" validate:"gte=0"`
+	ValidFrom             *time.Time `json:"valid_from"`
+	ValidUntil            *time.Time `json:"valid_until"`
+	Active                *bool      `json:"active"`
+}
+
+// UpdatePromoCodeDTO is a partial update, nil fields are left untouched. The
+// discount of a code cannot change once it may have been redeemed.
+type UpdatePromoCodeDTO struct {
+	MaxRedemptions        *int       `json:"max_redemptions" validate:"omitempty,gte=0"`
+	MaxRedemptionsPerUser *int       `json:"max_redemptions_per_user" validate:"omitempty,gte=0"`
+	MinQuantity           *int       `json:"min_quantity" validate:"omitempty,gte=0"`
+	ValidFrom             *time.Time `json:"valid_from"`
+	ValidUntil            *time.Time `json:"valid_until"`
+	Active                *bool      `json:"active"`
+}
+
+type PromoCodeDTO struct {
+	ID                    uuid.UUID  `json:"id"`
+	Code                  string     `json:"code"`
+	EventID               *uuid.UUID `json:"event_id,omitempty"`
+	DiscountType          string     `json:"discount_type"`
+	PercentOff            int64      `json:"percent_off,omitempty"`
+	AmountOff             *MoneyDTO  `json:"amount_off,omitempty"`
+	MaxRedemptions        int        `json:"max_redemptions"`
+	MaxRedemptionsPerUser int        `json:"max_redemptions_per_user"`
+	RedemptionCount       int        `json:"redemption_count"`
+	MinQuantity           int        `json:"min_quantity"`
+	ValidFrom             *time.Time `json:"valid_from,omitempty"`
+	ValidUntil            *time.Time `json:"valid_until,omitempty"`
+	Active                bool       `json:"active"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+type PromoCodeFilterDTO struct {
+	EventID uuid.UUID
+	Active  *bool
+}
+
+type PromoCodeListDTO struct {
+	PromoCodes []*PromoCodeDTO `json:"promo_codes"`
+	TotalCount int             `json:"total_count"`
+	TotalPages int             `json:"total_pages"`
+	Page       int             `json:"page"`
+	Size       int             `json:"size"`
+	HasMore    bool            `json:"has_more"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateTicketTypeDTO adds a tier to an event. AvailableTickets defaults to
+// Capacity, open sale bounds do not restrict the sale window.
+type CreateTicketTypeDTO struct {
+	Name              string     `json:"name" validate:"required,max=100"`
+	Price             MoneyDTO   `json:"price"`
+	Capacity          int        `json:"capacity" validate:"required,gt=0"`
+	AvailableTickets  *int       `json:"available_tickets" validate:"omitempty,gte=0"`
+	SaleStartsAt      *time.Time `json:"sale_starts_at"`
+	SaleEndsAt        *time.Time `json:"sale_ends_at"`
+	MaxTicketsPerUser int        `json:"max_tickets_per_user" validate:"gte=0"`
+}
+
+// UpdateTicketTypeDTO is a partial update, nil fields are left untouched. A
+// capacity change moves the available tickets by the same delta.
+type UpdateTicketTypeDTO struct {
+	Name              *string    `json:"name" validate:"omitempty,min=1,max=100"`
+	Price             *MoneyDTO  `json:"price"`
+	Capacity          *int       `json:"capacity" validate:"omitempty,gte=0"`
+	SaleStartsAt      *time.Time `json:"sale_starts_at"`
+	SaleEndsAt        *time.Time `json:"sale_ends_at"`
+	MaxTicketsPerUser *int       `json:"max_tickets_per_user" validate:"omitempty,gte=0"`
+}
+
+// TicketTypeDTO is a tier of an event. AvailableTickets is the live
+// availability, i.e. what can still be held right now.
+type TicketTypeDTO struct {
+	ID                uuid.UUID  `json:"id"`
+	EventID           uuid.UUID  `json:"event_id"`
+	Name              string     `json:"name"`
+	Price             MoneyDTO   `json:"price"`
+	Capacity          int        `json:"capacity"`
+	AvailableTickets  int        `json:"available_tickets"`
+	SaleStartsAt      *time.Time `json:"sale_starts_at,omitempty"`
+	SaleEndsAt        *time.Time `json:"sale_ends_at,omitempty"`
+	MaxTicketsPerUser int        `json:"max_tickets_per_user"`
+	OnSale            bool       `json:"on_sale"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
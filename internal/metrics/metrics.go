@@ -0,0 +1,104 @@
+// Package metrics holds the Prometheus metrics of the service. They are
+// registered with the default registry and served by promhttp on /metrics.
+//
+// The names below are part of the dashboards and alerts built on them, do not
+// rename a metric or change its labels, add a new one instead.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "event_booking"
+
+// Labels of HoldLockFailures.
+const (
+	LockPurchase = "purchase"
+	LockEvent    = "event"
+)
+
+var (
+	// HTTPRequests is event_booking_http_requests_total{method, route, status},
+	// the handled requests by route template, e.g. /bookings/:id. Requests
+	// matching no route have the route "unmatched".
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Handled HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration is
+	// event_booking_http_request_duration_seconds{method, route, status}.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	// HoldsCreated is event_booking_holds_created_total.
+	HoldsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "holds_created_total",
+		Help:      "Ticket holds created.",
+	})
+
+	// HoldsExpired is event_booking_holds_expired_total, the holds whose
+	// tickets were returned by the hold reaper.
+	HoldsExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "holds_expired_total",
+		Help:      "Ticket holds that expired before being booked.",
+	})
+
+	// HoldsReleased is event_booking_holds_released_total, the holds released
+	// by their user.
+	HoldsReleased = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "holds_released_total",
+		Help:      "Ticket holds released by their user.",
+	})
+
+	// BookingsConfirmed is event_booking_bookings_confirmed_total.
+	BookingsConfirmed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_confirmed_total",
+		Help:      "Bookings confirmed by a successful payment.",
+	})
+
+	// BookingsCancelled is event_booking_bookings_cancelled_total, cancelled
+	// by the user, because the payment failed or because the event was
+	// cancelled. Paid bookings count when they move to refund_pending.
+	BookingsCancelled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_cancelled_total",
+		Help:      "Bookings cancelled.",
+	})
+
+	// SoldOutRejections is event_booking_sold_out_rejections_total, the holds
+	// and bookings rejected because not enough tickets were left.
+	SoldOutRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sold_out_rejections_total",
+		Help:      "Holds and bookings rejected for lack of tickets.",
+	})
+
+	// HoldLockFailures is event_booking_hold_lock_failures_total{lock}, the
+	// holds that failed to acquire the purchase lock of the user or, with the
+	// redsync hold strategy, the lock of the event.
+	HoldLockFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "hold_lock_failures_total",
+		Help:      "Holds that failed to acquire a distributed lock, by lock.",
+	}, []string{"lock"})
+)
+
+func init() {
+	// Export the lock series before the first failure so rates start at 0.
+	for _, lock := range []string{LockPurchase, LockEvent} {
+		HoldLockFailures.WithLabelValues(lock)
+	}
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterPools exposes the connection pool stats of Postgres and Redis.
+//
+// Postgres is reported by the standard go_sql_* metrics with db_name
+// "booking", e.g. go_sql_open_connections and go_sql_wait_count_total.
+// Redis is reported as event_booking_redis_pool_*, see redisPoolCollector.
+func RegisterPools(db *sqlx.DB, client *redis.Client) error {
+	if err := prometheus.Register(collectors.NewDBStatsCollector(db.DB, "booking")); err != nil {
+		return err
+	}
+	return prometheus.Register(&redisPoolCollector{client: client})
+}
+
+var (
+	redisPoolHits = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "hits_total"),
+		"Times a free connection was found in the pool.", nil, nil)
+	redisPoolMisses = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "misses_total"),
+		"Times no free connection was found in the pool.", nil, nil)
+	redisPoolTimeouts = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "timeouts_total"),
+		"Times waiting for a connection timed out.", nil, nil)
+	redisPoolTotalConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "total_connections"),
+		"Connections in the pool.", nil, nil)
+	redisPoolIdleConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "idle_connections"),
+		"Idle connections in the pool.", nil, nil)
+	redisPoolStaleConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "redis_pool", "stale_connections_total"),
+		"Stale connections removed from the pool.", nil, nil)
+)
+
+// redisPoolCollector reads the pool stats of the client on every scrape.
+type redisPoolCollector struct {
+	client *redis.Client
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisPoolHits
+	ch <- redisPoolMisses
+	ch <- redisPoolTimeouts
+	ch <- redisPoolTotalConns
+	ch <- redisPoolIdleConns
+	ch <- redisPoolStaleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisPoolHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisPoolMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisPoolTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisPoolTotalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisPoolIdleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisPoolStaleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
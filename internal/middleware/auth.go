@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+)
+
+// Authenticate requires an "Authorization: Bearer <token>" header with a valid
+// access token and puts the identity of the caller into the request context,
+// see auth.IdentityFromContext.
+func (m *MiddlewareManager) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, http_utils.NewErrorResponse(c.Request.Context(),
+				http_utils.UNAUTHORIZED, auth.ErrUnauthenticated.Error(),
+			))
+			return
+		}
+
+		identity, err := m.verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.AUTHENTICATE.Error", err)
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, http_utils.NewErrorResponse(c.Request.Context(),
+				http_utils.UNAUTHORIZED, auth.ErrInvalidToken.Error(),
+			))
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+		c.Next()
+	}
+}
+
+// RequireRole lets through callers holding one of the roles. It runs after
+// Authenticate.
+func (m *MiddlewareManager) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := auth.CheckRole(c.Request.Context(), roles...); err != nil {
+			status, message := http.StatusForbidden, http_utils.INSUFFICIENT_ROLE
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				status, message = http.StatusUnauthorized, http_utils.UNAUTHORIZED
+			}
+			c.AbortWithStatusJSON(status, http_utils.NewErrorResponse(c.Request.Context(), message, err.Error()))
+			return
+		}
+		c.Next()
+	}
+}
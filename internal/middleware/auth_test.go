@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+)
+
+const testSecret = "test-secret"
+
+func newAuthManager(t *testing.T) *MiddlewareManager {
+	t.Helper()
+	m, _ := newTestManager(t)
+	m.cfg.Auth.HMACSecret = testSecret
+	verifier, err := auth.NewVerifier(m.cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.verifier = verifier
+	return m
+}
+
+func accessToken(t *testing.T, userID uuid.UUID, roles ...string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   userID.String(),
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// serve sends a GET with the Authorization header to router.
+func serve(router *gin.Engine, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAuthenticate(t *testing.T) {
+	m := newAuthManager(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var identity *auth.Identity
+	router.GET("/me", m.Authenticate(), func(c *gin.Context) {
+		identity = auth.IdentityFromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	userID := uuid.New()
+
+	rec := serve(router, "/me", "bearer "+accessToken(t, userID, auth.RoleOrganizer))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if identity == nil || identity.UserID != userID || !identity.HasRole(auth.RoleOrganizer) {
+		t.Fatalf("identity = %+v, want organizer %s", identity, userID)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		challenge     string
+	}{
+		{"no header", "", "Bearer"},
+		{"basic auth", "Basic dXNlcjpwYXNz", "Bearer"},
+		{"no token", "Bearer ", "Bearer"},
+		{"invalid token", "Bearer not.a.token", `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity = nil
+			rec := serve(router, "/me", tt.authorization)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.challenge)
+			}
+			if identity != nil {
+				t.Error("handler ran without a valid token")
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	m := newAuthManager(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/events/new", m.Authenticate(), m.RequireRole(auth.RoleOrganizer, auth.RoleAdmin), ok)
+	router.GET("/unauthenticated", m.RequireRole(auth.RoleAdmin), ok)
+
+	tests := []struct {
+		name    string
+		path    string
+		roles   []string
+		status  int
+		message string
+	}{
+		{"organizer", "/events/new", []string{auth.RoleOrganizer}, http.StatusNoContent, ""},
+		{"admin", "/events/new", []string{auth.RoleCustomer, auth.RoleAdmin}, http.StatusNoContent, ""},
+		{"customer", "/events/new", []string{auth.RoleCustomer}, http.StatusForbidden, http_utils.INSUFFICIENT_ROLE},
+		{"no identity", "/unauthenticated", nil, http.StatusUnauthorized, http_utils.UNAUTHORIZED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, tt.path, "Bearer "+accessToken(t, uuid.New(), tt.roles...))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.message == "" {
+				return
+			}
+			var response http_utils.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Message != tt.message {
+				t.Errorf("message = %q, want %q", response.Message, tt.message)
+			}
+		})
+	}
+}
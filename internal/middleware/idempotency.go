@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+	idempotencyStateInFlight  = "in_flight"
+	idempotencyStateCompleted = "completed"
+	// idempotencyLockTTL bounds how long a crashed request can block its key
+	idempotencyLockTTL      = time.Minute
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// acquireIdempotencyScript claims a key for the request carrying the token.
+//
+// KEYS[1] idempotency record
+// ARGV[1] token, ARGV[2] fingerprint, ARGV[3] lock ttl (ms)
+//
+// Returns 1 when the key was claimed and 0 when a record already exists.
+var acquireIdempotencyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', 'in_flight', 'token', ARGV[1], 'fingerprint', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// completeIdempotencyScript stores the response of the request holding the
+// key. A request whose lock expired in the meantime stores nothing.
+//
+// KEYS[1] idempotency record
+// ARGV[1] token, ARGV[2] status, ARGV[3] content type, ARGV[4] body, ARGV[5] ttl (ms)
+var completeIdempotencyScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', 'completed', 'status', ARGV[2], 'content_type', ARGV[3], 'body', ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// abandonIdempotencyScript frees a key whose request failed, so the client
+// can retry it.
+//
+// KEYS[1] idempotency record
+// ARGV[1] token
+var abandonIdempotencyScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// responseRecorder keeps a copy of the response body written by the handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency honours the Idempotency-Key header. The first response of a key
+// is stored per user together with a fingerprint of the request, replays get
+// the stored response verbatim and a different request under the same key
+// gets 422. A duplicate arriving while the first request is still running
+// waits for it and gets 409 once IdempotencyConfig.WaitTimeout has passed.
+// Server errors are not stored, the key can be retried.
+func (m *MiddlewareManager) Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(),
+				http_utils.INVALID_REQUEST,
+				fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, idempotencyKeyMaxLength),
+			))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.IDEMPOTENCY.Error", err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, http_utils.NewErrorResponse(c.Request.Context(), http_utils.INVALID_REQUEST, err.Error()))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		recordKey := idempotencyRecordKey(c, key)
+		fingerprint := idempotencyFingerprint(c, body)
+		token := uuid.NewString()
+		deadline := time.Now().Add(m.cfg.Idempotency.WaitTimeout)
+		for {
+			acquired, err := acquireIdempotencyScript.Run(c.Request.Context(), m.redis, []string{recordKey},
+				token,
+				fingerprint,
+				idempotencyLockTTL.Milliseconds(),
+			).Bool()
+			if err != nil {
+				m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.IDEMPOTENCY.Error", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, http_utils.NewErrorResponse(c.Request.Context(),
+					http_utils.INTERNAL_SERVER_ERROR, err.Error(),
+				))
+				return
+			}
+			if acquired {
+				m.executeIdempotent(c, recordKey, token)
+				return
+			}
+
+			record, err := m.redis.HGetAll(c.Request.Context(), recordKey).Result()
+			if err != nil {
+				m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.IDEMPOTENCY.Error", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, http_utils.NewErrorResponse(c.Request.Context(),
+					http_utils.INTERNAL_SERVER_ERROR, err.Error(),
+				))
+				return
+			}
+			// The record expired or was abandoned in between, try to claim it again.
+			if len(record) == 0 {
+				continue
+			}
+			if record["fingerprint"] != fingerprint {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, http_utils.NewErrorResponse(c.Request.Context(),
+					http_utils.UNPROCESSABLE_ENTITY,
+					fmt.Sprintf("%s was already used for a different request", IdempotencyKeyHeader),
+				))
+				return
+			}
+			if record["state"] == idempotencyStateCompleted {
+				replayIdempotent(c, record)
+				return
+			}
+			if time.Now().After(deadline) {
+				c.AbortWithStatusJSON(http.StatusConflict, http_utils.NewErrorResponse(c.Request.Context(),
+					http_utils.CONFLICT,
+					fmt.Sprintf("a request with this %s is still in progress", IdempotencyKeyHeader),
+				))
+				return
+			}
+			select {
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+	}
+}
+
+// executeIdempotent runs the handler and stores its response under the key.
+func (m *MiddlewareManager) executeIdempotent(c *gin.Context, recordKey, token string) {
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	stored := false
+	defer func() {
+		if stored {
+			return
+		}
+		// Server error or panic, free the key so the request can be retried.
+		err := abandonIdempotencyScript.Run(c.Request.Context(), m.redis, []string{recordKey}, token).Err()
+		if err != nil {
+			m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.IDEMPOTENCY.Error", err)
+		}
+	}()
+
+	c.Next()
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		return
+	}
+	err := completeIdempotencyScript.Run(c.Request.Context(), m.redis, []string{recordKey},
+		token,
+		status,
+		recorder.Header().Get("Content-Type"),
+		recorder.body.String(),
+		m.cfg.Idempotency.TTL.Milliseconds(),
+	).Err()
+	if err != nil {
+		m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.IDEMPOTENCY.Error", err)
+		return
+	}
+	stored = true
+}
+
+func replayIdempotent(c *gin.Context, record map[string]string) {
+	status, err := strconv.Atoi(record["status"])
+	if err != nil {
+		status = http.StatusOK
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(status, record["content_type"], []byte(record["body"]))
+	c.Abort()
+}
+
+// idempotencyRecordKey scopes the key to the resource and the authenticated
+// user sending it.
+func idempotencyRecordKey(c *gin.Context, key string) string {
+	var userID string
+	if identity := auth.IdentityFromContext(c.Request.Context()); identity != nil {
+		userID = identity.UserID.String()
+	}
+	return fmt.Sprintf("idempotency:%s:%s:user:%s:%s", c.Request.Method, c.Request.URL.Path, userID, key)
+}
+
+// idempotencyFingerprint identifies the request a key was first used for.
+func idempotencyFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestManager(t *testing.T) (*MiddlewareManager, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Idempotency.TTL = time.Hour
+	cfg.Idempotency.WaitTimeout = 100 * time.Millisecond
+	appLogger := logger.NewApiLogger(cfg)
+	appLogger.InitLogger()
+	return NewMiddlewareManager(cfg, appLogger, client, nil), server
+}
+
+// idempotentRouter serves POST /bookings behind the Idempotency middleware
+// with handler and authenticates the caller from the X-User header.
+func idempotentRouter(m *MiddlewareManager, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID, err := uuid.Parse(c.GetHeader("X-User")); err == nil {
+			ctx := auth.WithIdentity(c.Request.Context(), &auth.Identity{UserID: userID})
+			c.Request = c.Request.WithContext(ctx)
+		}
+	})
+	router.POST("/bookings", m.Idempotency(), handler)
+	return router
+}
+
+func postBooking(router *gin.Engine, key, userID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	req.Header.Set("X-User", userID)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// countingHandler answers 201 with a body naming the call.
+func countingHandler(calls *atomic.Int32) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n := calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	}
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	m, _ := newTestManager(t)
+	var calls atomic.Int32
+	router := idempotentRouter(m, countingHandler(&calls))
+	userID := uuid.NewString()
+
+	first := postBooking(router, "key-1", userID, `{"quantity":2}`)
+	second := postBooking(router, "key-1", userID, `{"quantity":2}`)
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler called %d times, want 1", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("%s = %q then %q, want only the replay marked", IdempotentReplayedHeader,
+			first.Header().Get(IdempotentReplayedHeader), second.Header().Get(IdempotentReplayedHeader))
+	}
+	if got := second.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+		t.Fatalf("replay Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	m, _ := newTestManager(t)
+	var calls atomic.Int32
+	router := idempotentRouter(m, countingHandler(&calls))
+	userID := uuid.NewString()
+
+	if rec := postBooking(router, "", userID, `{}`); rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	postBooking(router, "", userID, `{}`)
+	if calls.Load() != 2 {
+		t.Fatalf("handler called %d times without a key, want 2", calls.Load())
+	}
+
+	// The same key sent by another user is another request.
+	postBooking(router, "key-1", userID, `{}`)
+	postBooking(router, "key-1", uuid.NewString(), `{}`)
+	if calls.Load() != 4 {
+		t.Fatalf("handler called %d times for two users, want 4", calls.Load())
+	}
+}
+
+func TestIdempotencyRejectsInvalidReuse(t *testing.T) {
+	m, _ := newTestManager(t)
+	var calls atomic.Int32
+	router := idempotentRouter(m, countingHandler(&calls))
+	userID := uuid.NewString()
+
+	postBooking(router, "key-1", userID, `{"quantity":2}`)
+	if rec := postBooking(router, "key-1", userID, `{"quantity":3}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("different body status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if rec := postBooking(router, strings.Repeat("k", idempotencyKeyMaxLength+1), userID, `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("long key status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler called %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	m, server := newTestManager(t)
+	var calls atomic.Int32
+	router := idempotentRouter(m, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+	userID := uuid.NewString()
+
+	if rec := postBooking(router, "key-1", userID, `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Fatalf("keys after a server error = %v, want none", keys)
+	}
+	if rec := postBooking(router, "key-1", userID, `{}`); rec.Code != http.StatusCreated {
+		t.Fatalf("retry status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler called %d times, want 2", calls.Load())
+	}
+}
+
+func TestIdempotencyWaitsForInFlightRequest(t *testing.T) {
+	m, _ := newTestManager(t)
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	router := idempotentRouter(m, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+	userID := uuid.NewString()
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postBooking(router, "key-1", userID, `{}`) }()
+	<-started
+
+	if rec := postBooking(router, "key-1", userID, `{}`); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	close(release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Fatalf("first status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec := postBooking(router, "key-1", userID, `{}`); rec.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatal("request after the first finished was not replayed")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler called %d times, want 1", calls.Load())
+	}
+}
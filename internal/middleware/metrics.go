@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
+)
+
+// Metrics counts every request and observes its latency by route template,
+// see metrics.HTTPRequests. Register it before the routes.
+func (m *MiddlewareManager) Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Unmatched paths are not used as labels, scanners would blow
+			// up the cardinality.
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).
+			Observe(time.Since(start).Seconds())
+	}
+}
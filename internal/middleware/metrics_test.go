@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// observations returns the number of latencies observed for the series.
+func observations(t *testing.T, method, route, status string) uint64 {
+	t.Helper()
+	observer := metrics.HTTPRequestDuration.WithLabelValues(method, route, status)
+	var metric dto.Metric
+	if err := observer.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics(t *testing.T) {
+	m, _ := newTestManager(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(m.Metrics())
+	router.GET("/bookings/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/bookings/:id", func(c *gin.Context) { c.Status(http.StatusConflict) })
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		status string
+	}{
+		{"route template", http.MethodGet, "/bookings/123", "/bookings/:id", "200"},
+		{"status", http.MethodPost, "/bookings/123", "/bookings/:id", "409"},
+		{"unmatched", http.MethodGet, "/wp-admin/123", "unmatched", "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.HTTPRequests.WithLabelValues(tt.method, tt.route, tt.status)
+			requests, observed := testutil.ToFloat64(counter), observations(t, tt.method, tt.route, tt.status)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if got := testutil.ToFloat64(counter) - requests; got != 1 {
+				t.Errorf("requests metric grew by %v, want 1", got)
+			}
+			if got := observations(t, tt.method, tt.route, tt.status) - observed; got != 1 {
+				t.Errorf("duration metric observed %d requests, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// MiddlewareManager builds the gin middlewares shared by the http handlers.
+type MiddlewareManager struct {
+	cfg      *config.Config
+	logger   logger.Logger
+	redis    *redis.Client
+	verifier *auth.Verifier
+}
+
+func NewMiddlewareManager(
+	cfg *config.Config,
+	logger logger.Logger,
+	redis *redis.Client,
+	verifier *auth.Verifier,
+) *MiddlewareManager {
+	return &MiddlewareManager{
+		cfg:      cfg,
+		logger:   logger,
+		redis:    redis,
+		verifier: verifier,
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/pkg/http_utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// Rate limit scopes, each has its own rule in config.RateLimitConfig and its
+// own budget per caller.
+const (
+	RateLimitBookings = "bookings"
+	RateLimitHolds    = "holds"
+	RateLimitReads    = "reads"
+)
+
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// rateLimitScript is a token bucket implemented as GCRA: the key stores the
+// theoretical arrival time of the next request, a burst of up to limit
+// requests is allowed and one token comes back every interval. The clock of
+// redis is used so every instance sees the same time.
+//
+// KEYS[1] bucket
+// ARGV[1] limit, ARGV[2] interval (ms)
+//
+// Returns {allowed, remaining, retry after (ms), reset (ms)}.
+var rateLimitScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local next_tat = tat + interval
+local allow_at = next_tat - limit * interval
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+redis.call('SET', KEYS[1], next_tat, 'PX', next_tat - now)
+return {1, math.floor((now - allow_at) / interval), 0, next_tat - now}
+`)
+
+// RateLimit allows every caller the requests of the rule of the scope and
+// answers the rest with 429. Callers are told their budget in the RateLimit-*
+// headers and when to retry in Retry-After. The reads scope only counts GET
+// and HEAD requests, so it can guard a whole group. When redis is unavailable
+// requests are let through rather than failing the api.
+func (m *MiddlewareManager) RateLimit(scope string) gin.HandlerFunc {
+	rule := m.rateLimitRule(scope)
+	interval := rule.Window.Milliseconds() / int64(max(rule.Limit, 1))
+	return func(c *gin.Context) {
+		if rule.Limit <= 0 || interval <= 0 {
+			c.Next()
+			return
+		}
+		if scope == RateLimitReads && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		result, err := rateLimitScript.Run(c.Request.Context(), m.redis, []string{rateLimitKey(c, scope)},
+			rule.Limit,
+			interval,
+		).Int64Slice()
+		if err != nil {
+			m.logger.WithContext(c.Request.Context()).Error("MIDDLEWARE.RATE_LIMIT.Error", err)
+			c.Next()
+			return
+		}
+		allowed, remaining := result[0] == 1, result[1]
+		retryAfter, reset := time.Duration(result[2])*time.Millisecond, time.Duration(result[3])*time.Millisecond
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(rule.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.FormatInt(remaining, 10))
+		c.Header(RateLimitResetHeader, strconv.FormatInt(ceilSeconds(reset), 10))
+		c.Header(RateLimitPolicyHeader, fmt.Sprintf("%d;w=%d", rule.Limit, ceilSeconds(rule.Window)))
+		if !allowed {
+			c.Header("Retry-After", strconv.FormatInt(max(ceilSeconds(retryAfter), 1), 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, http_utils.NewErrorResponse(c.Request.Context(),
+				http_utils.TOO_MANY_REQUESTS,
+				fmt.Sprintf("rate limit of %d requests per %s exceeded", rule.Limit, rule.Window),
+			))
+			return
+		}
+		c.Next()
+	}
+}
+
+func (m *MiddlewareManager) rateLimitRule(scope string) config.RateLimitRule {
+	switch scope {
+	case RateLimitBookings:
+		return m.cfg.RateLimit.Bookings
+	case RateLimitHolds:
+		return m.cfg.RateLimit.Holds
+	case RateLimitReads:
+		return m.cfg.RateLimit.Reads
+	}
+	panic(fmt.Sprintf("unknown rate limit scope %q", scope))
+}
+
+// rateLimitKey is the bucket of the authenticated user, or of the client IP
+// for anonymous requests.
+func rateLimitKey(c *gin.Context, scope string) string {
+	if identity := auth.IdentityFromContext(c.Request.Context()); identity != nil {
+		return fmt.Sprintf("ratelimit:%s:user:%s", scope, identity.UserID)
+	}
+	return fmt.Sprintf("ratelimit:%s:ip:%s", scope, c.ClientIP())
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+)
+
+// rateLimitRouter serves GET and POST /events behind the RateLimit of scope
+// and authenticates the caller from the X-User header.
+func rateLimitRouter(m *MiddlewareManager, scope string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID, err := uuid.Parse(c.GetHeader("X-User")); err == nil {
+			ctx := auth.WithIdentity(c.Request.Context(), &auth.Identity{UserID: userID})
+			c.Request = c.Request.WithContext(ctx)
+		}
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/events", m.RateLimit(scope), ok)
+	router.POST("/events", m.RateLimit(scope), ok)
+	return router
+}
+
+func request(router *gin.Engine, method, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/events", nil)
+	if userID != "" {
+		req.Header.Set("X-User", userID)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimit(t *testing.T) {
+	m, server := newTestManager(t)
+	m.cfg.RateLimit.Bookings = config.RateLimitRule{Limit: 3, Window: time.Minute}
+	router := rateLimitRouter(m, RateLimitBookings)
+	userID := uuid.NewString()
+	server.SetTime(time.Now())
+
+	for i, remaining := range []string{"2", "1", "0"} {
+		rec := request(router, http.MethodPost, userID)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("request %d status = %d, want %d", i+1, rec.Code, http.StatusNoContent)
+		}
+		if got := rec.Header().Get(RateLimitRemainingHeader); got != remaining {
+			t.Fatalf("request %d %s = %s, want %s", i+1, RateLimitRemainingHeader, got, remaining)
+		}
+	}
+	rec := request(router, http.MethodPost, userID)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status over the limit = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	headers := map[string]string{
+		"Retry-After":            "20",
+		RateLimitLimitHeader:     "3",
+		RateLimitRemainingHeader: "0",
+		RateLimitResetHeader:     "60",
+		RateLimitPolicyHeader:    "3;w=60",
+	}
+	for header, want := range headers {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Every caller has a budget of its own.
+	if rec := request(router, http.MethodPost, uuid.NewString()); rec.Code != http.StatusNoContent {
+		t.Fatalf("status of another user = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec := request(router, http.MethodPost, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("status of an anonymous caller = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	// One token comes back every interval.
+	server.SetTime(time.Now().Add(20 * time.Second))
+	if rec := request(router, http.MethodPost, userID); rec.Code != http.StatusNoContent {
+		t.Fatalf("status after an interval = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec := request(router, http.MethodPost, userID); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status after spending the token = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitReadsCountsReadsOnly(t *testing.T) {
+	m, _ := newTestManager(t)
+	m.cfg.RateLimit.Reads = config.RateLimitRule{Limit: 1, Window: time.Minute}
+	router := rateLimitRouter(m, RateLimitReads)
+	userID := uuid.NewString()
+
+	if rec := request(router, http.MethodGet, userID); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec := request(router, http.MethodGet, userID); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status of a second read = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec := request(router, http.MethodPost, userID); rec.Code != http.StatusNoContent || rec.Header().Get(RateLimitLimitHeader) != "" {
+		t.Fatalf("write status = %d, want %d without rate limit headers", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	m, server := newTestManager(t)
+	m.cfg.RateLimit.Holds = config.RateLimitRule{Limit: 1, Window: time.Minute}
+	router := rateLimitRouter(m, RateLimitHolds)
+	userID := uuid.NewString()
+	request(router, http.MethodPost, userID)
+
+	// Without a limit the scope is not limited.
+	unlimited := rateLimitRouter(m, RateLimitBookings)
+	for i := 0; i < 5; i++ {
+		if rec := request(unlimited, http.MethodPost, userID); rec.Code != http.StatusNoContent {
+			t.Fatalf("status without a limit = %d, want %d", rec.Code, http.StatusNoContent)
+		}
+	}
+
+	server.Close()
+	if rec := request(router, http.MethodPost, userID); rec.Code != http.StatusNoContent {
+		t.Fatalf("status without redis = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
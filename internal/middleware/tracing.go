@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader carries the trace id of a traced request in its response.
+const TraceIDHeader = "Trace-Id"
+
+// Tracing starts the server span of every request and puts it into the
+// request context, everything called with that context traces as its child.
+// A W3C traceparent header continues the trace of the caller. The metrics and
+// health check probes are not traced.
+func (m *MiddlewareManager) Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "/metrics" || strings.HasPrefix(route, "/health") {
+			c.Next()
+			return
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(TraceIDHeader, span.SpanContext().TraceID().String())
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// AccessLog logs every request like gin.Logger, followed by its trace id.
+// Register it after Tracing.
+func (m *MiddlewareManager) AccessLog() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | trace_id=%s\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency.Truncate(time.Microsecond),
+			param.ClientIP,
+			param.Method,
+			param.Path,
+			tracing.TraceID(param.Request.Context()),
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider recording every span and the W3C
+// propagator until the end of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+func tracingRouter(m *MiddlewareManager, handlerTraceID *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(m.Tracing())
+	router.GET("/bookings/:id", func(c *gin.Context) {
+		*handlerTraceID = tracing.TraceID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	router.POST("/bookings/:id", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	router.GET("/health/ready", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/metrics", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func attributeOf(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing(t *testing.T) {
+	m, _ := newTestManager(t)
+	var handlerTraceID string
+	router := tracingRouter(m, &handlerTraceID)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		span   string
+		route  string
+		status int64
+		code   codes.Code
+	}{
+		{"route template", http.MethodGet, "/bookings/123", "GET /bookings/:id", "/bookings/:id", 200, codes.Unset},
+		{"server error", http.MethodPost, "/bookings/123", "POST /bookings/:id", "/bookings/:id", 500, codes.Error},
+		{"unmatched", http.MethodGet, "/wp-admin", "GET unmatched", "unmatched", 404, codes.Unset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("ended %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.span || span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span = %q of kind %v, want %q of kind server", span.Name(), span.SpanKind(), tt.span)
+			}
+			if got := attributeOf(span, "http.route").AsString(); got != tt.route {
+				t.Errorf("http.route = %q, want %q", got, tt.route)
+			}
+			if got := attributeOf(span, "http.response.status_code").AsInt64(); got != tt.status {
+				t.Errorf("http.response.status_code = %d, want %d", got, tt.status)
+			}
+			if got := span.Status().Code; got != tt.code {
+				t.Errorf("span status = %v, want %v", got, tt.code)
+			}
+			if got, want := rec.Header().Get(TraceIDHeader), span.SpanContext().TraceID().String(); got != want {
+				t.Errorf("%s = %q, want %q", TraceIDHeader, got, want)
+			}
+		})
+	}
+	if handlerTraceID == "" {
+		t.Error("the handler context carries no span")
+	}
+}
+
+func TestTracingContinuesTrace(t *testing.T) {
+	m, _ := newTestManager(t)
+	recorder := recordSpans(t)
+	var handlerTraceID string
+	router := tracingRouter(m, &handlerTraceID)
+	traceID, parentID := "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+
+	req := httptest.NewRequest(http.MethodGet, "/bookings/123", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace id = %s, want %s", got, traceID)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != parentID {
+		t.Errorf("parent span id = %s, want %s", got, parentID)
+	}
+	if handlerTraceID != traceID {
+		t.Errorf("trace id in the handler = %q, want %s", handlerTraceID, traceID)
+	}
+}
+
+func TestTracingSkipsProbes(t *testing.T) {
+	m, _ := newTestManager(t)
+	recorder := recordSpans(t)
+	var handlerTraceID string
+	router := tracingRouter(m, &handlerTraceID)
+
+	for _, path := range []string{"/health/ready", "/metrics"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Header().Get(TraceIDHeader) != "" {
+			t.Errorf("%s answered with a %s", path, TraceIDHeader)
+		}
+	}
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Errorf("probes ended %d spans, want 0", len(spans))
+	}
+}
@@ -7,11 +7,215 @@ import (
 )
 
 type Booking struct {
-	ID        uuid.UUID `json:"id" validate:"required"`
-	EventID   uuid.UUID `json:"event_id" validate:"required"`
-	UserID    uuid.UUID `json:"user_id" validate:"required"`
-	Status    string    `json:"status" validate:"required"`
-	Quantity  int       `json:"quantity" validate:"required"`
-	CreatedAt time.Time `json:"created_at" validate:"required"`
-	UpdatedAt time.Time `json:"updated_at" validate:"required"`
+	ID        uuid.UUID     `json:"id" db:"id" validate:"required"`
+	EventID   uuid.UUID     `json:"event_id" db:"event_id" validate:"required"`
+	UserID    uuid.UUID     `json:"user_id" db:"user_id" validate:"required"`
+	Status    BookingStatus `json:"status" db:"status" validate:"required"`
+	Quantity  int           `json:"quantity" db:"quantity" validate:"required"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at" validate:"required"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at" validate:"required"`
+
+	CancellationReason *string    `json:"cancellation_reason,omitempty" db:"cancellation_reason"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	// ContactEmail receives the notifications of the booking
+	ContactEmail *string `json:"contact_email,omitempty" db:"contact_email"`
+	// Items are the ticket types and quantities Quantity is made of
+	Items []*BookingItem `json:"items,omitempty" db:"-"`
+
+	// Charged at the time of booking, Total is Subtotal minus Discount plus
+	// Fees.
+	Subtotal Money `json:"subtotal" db:"subtotal"`
+	Discount Money `json:"discount" db:"discount"`
+	Fees     Money `json:"fees" db:"fees"`
+	Total    Money `json:"total" db:"total"`
+	// PromoCodeID is the promo code the Discount came from
+	PromoCodeID *uuid.UUID `json:"promo_code_id,omitempty" db:"promo_code_id"`
+}
+
+// LineItems returns the ticket types and quantities of the booking.
+func (b *Booking) LineItems() []LineItem {
+	items := make([]LineItem, 0, len(b.Items))
+	for _, item := range b.Items {
+		items = append(items, item.LineItem)
+	}
+	return items
+}
+
+// CalculateTotals prices the booking from the unit prices of its items, the
+// promo code, if any, and the fee rate. Fees are charged on the discounted
+// subtotal.
+func (b *Booking) CalculateTotals(feeRate FeeRate, promo *PromoCode) error {
+	subtotal := Money{}
+	for _, item := range b.Items {
+		var err error
+		if subtotal, err = subtotal.Add(item.UnitPrice.Mul(item.Quantity)); err != nil {
+			return err
+		}
+	}
+	discount := Money{Currency: subtotal.Currency}
+	b.PromoCodeID = nil
+	if promo != nil {
+		var err error
+		if discount, err = promo.Discount(subtotal); err != nil {
+			return err
+		}
+		b.PromoCodeID = &promo.ID
+	}
+	net, err := subtotal.Sub(discount)
+	if err != nil {
+		return err
+	}
+	fees := feeRate.Of(net)
+	total, err := net.Add(fees)
+	if err != nil {
+		return err
+	}
+	b.Subtotal, b.Discount, b.Fees, b.Total = subtotal, discount, fees, total
+	return nil
+}
+
+// BookingWithEvent is a booking joined with the event fields clients need to
+// render it without fetching the event.
+type BookingWithEvent struct {
+	Booking
+	EventTitle     string    `json:"event_title" db:"event_title"`
+	EventStartTime time.Time `json:"event_start_time" db:"event_start_time"`
+}
+
+// BookingStatusChange is one entry of the status history of a booking.
+type BookingStatusChange struct {
+	ID         int64          `json:"id" db:"id"`
+	BookingID  uuid.UUID      `json:"booking_id" db:"booking_id"`
+	FromStatus *BookingStatus `json:"from_status" db:"from_status"`
+	ToStatus   BookingStatus  `json:"to_status" db:"to_status"`
+	Reason     *string        `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+}
+
+// EventCancellation is the outcome of cancelling an event together with the
+// status changes it cascaded to the bookings of the event.
+type EventCancellation struct {
+	Event    *Event
+	Bookings []*BookingTransition
+}
+
+// BookingTransition is the outcome of a status change applied to a booking.
+type BookingTransition struct {
+	Booking *Booking
+	From    BookingStatus
+	// ReleasedTickets is the number of tickets given back to the event
+	ReleasedTickets int
+}
+
+type BookingStatus string
+
+const (
+	BookingStatusPending         BookingStatus = "pending"
+	BookingStatusAwaitingPayment BookingStatus = "awaiting_payment"
+	BookingStatusConfirmed       BookingStatus = "confirmed"
+	BookingStatusCancelled       BookingStatus = "cancelled"
+	BookingStatusRefundPending   BookingStatus = "refund_pending"
+	BookingStatusRefunded        BookingStatus = "refunded"
+	BookingStatusExpired         BookingStatus = "expired"
+	BookingStatusCheckedIn       BookingStatus = "checked_in"
+)
+
+// bookingTransitions lists the statuses each status may move to. The empty
+// status is the origin of a new booking.
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	"": {
+		BookingStatusPending,
+		BookingStatusAwaitingPayment,
+		BookingStatusConfirmed,
+	},
+	BookingStatusPending: {
+		BookingStatusAwaitingPayment,
+		BookingStatusConfirmed,
+		BookingStatusCancelled,
+		BookingStatusExpired,
+	},
+	BookingStatusAwaitingPayment: {
+		BookingStatusConfirmed,
+		BookingStatusCancelled,
+		BookingStatusExpired,
+	},
+	BookingStatusConfirmed: {
+		BookingStatusCancelled,
+		BookingStatusRefundPending,
+		BookingStatusCheckedIn,
+	},
+	BookingStatusCancelled: {
+		BookingStatusRefunded,
+	},
+	BookingStatusRefundPending: {
+		BookingStatusRefunded,
+	},
+}
+
+func (s BookingStatus) Validate() bool {
+	switch s {
+	case BookingStatusPending, BookingStatusAwaitingPayment, BookingStatusConfirmed,
+		BookingStatusCancelled, BookingStatusRefundPending, BookingStatusRefunded, BookingStatusExpired,
+		BookingStatusCheckedIn:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the transition table allows moving to next
+func (s BookingStatus) CanTransitionTo(next BookingStatus) bool {
+	for _, allowed := range bookingTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo returns an *ErrInvalidTransition if moving to next is not allowed
+func (s BookingStatus) TransitionTo(next BookingStatus) error {
+	if !s.CanTransitionTo(next) {
+		return &ErrInvalidTransition{From: s, To: next}
+	}
+	return nil
+}
+
+// HoldsInventory reports whether a booking in this status occupies tickets of
+// its event. Leaving such a status gives the tickets back.
+func (s BookingStatus) HoldsInventory() bool {
+	switch s {
+	case BookingStatusPending, BookingStatusAwaitingPayment, BookingStatusConfirmed, BookingStatusCheckedIn:
+		return true
+	}
+	return false
+}
+
+// Purgeable reports whether a booking in this status may be deleted for good.
+// Only bookings that can no longer change and hold no tickets qualify, live
+// bookings are cancelled instead so their history and payments are kept.
+func (s BookingStatus) Purgeable() bool {
+	return !s.HoldsInventory() && len(bookingTransitions[s]) == 0
+}
+
+// OnCancelled returns the status a booking moves to when it is cancelled by
+// its customer. A paid booking waits for the refund of its payment instead
+// of keeping the money.
+func (s BookingStatus) OnCancelled(paid bool) BookingStatus {
+	if s == BookingStatusConfirmed && paid {
+		return BookingStatusRefundPending
+	}
+	return BookingStatusCancelled
+}
+
+// OnEventCancelled returns the status a booking moves to when its event is
+// cancelled. Paid bookings wait for a refund, unpaid ones are cancelled.
+// ok is false for bookings that are left as they are.
+func (s BookingStatus) OnEventCancelled() (next BookingStatus, ok bool) {
+	switch s {
+	case BookingStatusPending, BookingStatusAwaitingPayment:
+		return BookingStatusCancelled, true
+	case BookingStatusConfirmed:
+		return BookingStatusRefundPending, true
+	}
+	return "", false
 }
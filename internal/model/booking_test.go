@@ -0,0 +1,159 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBookingStatusTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    BookingStatus
+		to      BookingStatus
+		allowed bool
+	}{
+		{"", BookingStatusPending, true},
+		{"", BookingStatusAwaitingPayment, true},
+		{"", BookingStatusConfirmed, true},
+		{"", BookingStatusCancelled, false},
+		{BookingStatusPending, BookingStatusAwaitingPayment, true},
+		{BookingStatusPending, BookingStatusConfirmed, true},
+		{BookingStatusPending, BookingStatusCancelled, true},
+		{BookingStatusPending, BookingStatusExpired, true},
+		{BookingStatusPending, BookingStatusRefunded, false},
+		{BookingStatusAwaitingPayment, BookingStatusConfirmed, true},
+		{BookingStatusAwaitingPayment, BookingStatusCancelled, true},
+		{BookingStatusAwaitingPayment, BookingStatusExpired, true},
+		{BookingStatusAwaitingPayment, BookingStatusPending, false},
+		{BookingStatusAwaitingPayment, BookingStatusRefundPending, false},
+		{BookingStatusConfirmed, BookingStatusCancelled, true},
+		{BookingStatusConfirmed, BookingStatusRefundPending, true},
+		{BookingStatusConfirmed, BookingStatusCheckedIn, true},
+		{BookingStatusConfirmed, BookingStatusExpired, false},
+		{BookingStatusConfirmed, BookingStatusAwaitingPayment, false},
+		{BookingStatusCancelled, BookingStatusRefunded, true},
+		{BookingStatusCancelled, BookingStatusConfirmed, false},
+		{BookingStatusRefundPending, BookingStatusRefunded, true},
+		{BookingStatusRefundPending, BookingStatusConfirmed, false},
+		{BookingStatusRefunded, BookingStatusConfirmed, false},
+		{BookingStatusExpired, BookingStatusConfirmed, false},
+		{BookingStatusExpired, BookingStatusCancelled, false},
+		{BookingStatusCheckedIn, BookingStatusCancelled, false},
+		{BookingStatusConfirmed, BookingStatusConfirmed, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := tt.from.TransitionTo(tt.to)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("TransitionTo() = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *ErrInvalidTransition
+			if !errors.As(err, &invalid) {
+				t.Fatalf("TransitionTo() = %v, want *ErrInvalidTransition", err)
+			}
+			if invalid.From != tt.from || invalid.To != tt.to {
+				t.Fatalf("TransitionTo() = %+v, want from %q to %q", invalid, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestBookingStatusPurgeable(t *testing.T) {
+	tests := []struct {
+		status    BookingStatus
+		purgeable bool
+	}{
+		{BookingStatusPending, false},
+		{BookingStatusAwaitingPayment, false},
+		{BookingStatusConfirmed, false},
+		{BookingStatusCancelled, false},
+		{BookingStatusRefundPending, false},
+		{BookingStatusRefunded, true},
+		{BookingStatusExpired, true},
+		{BookingStatusCheckedIn, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.Purgeable(); got != tt.purgeable {
+				t.Fatalf("Purgeable() = %v, want %v", got, tt.purgeable)
+			}
+		})
+	}
+}
+
+func TestBookingStatusOnEventCancelled(t *testing.T) {
+	tests := []struct {
+		status BookingStatus
+		next   BookingStatus
+		ok     bool
+	}{
+		{BookingStatusPending, BookingStatusCancelled, true},
+		{BookingStatusAwaitingPayment, BookingStatusCancelled, true},
+		{BookingStatusConfirmed, BookingStatusRefundPending, true},
+		{BookingStatusCancelled, "", false},
+		{BookingStatusExpired, "", false},
+		{BookingStatusCheckedIn, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			next, ok := tt.status.OnEventCancelled()
+			if next != tt.next || ok != tt.ok {
+				t.Fatalf("OnEventCancelled() = (%q, %v), want (%q, %v)", next, ok, tt.next, tt.ok)
+			}
+			if ok {
+				if err := tt.status.TransitionTo(next); err != nil {
+					t.Fatalf("OnEventCancelled() = %q is not a valid transition: %v", next, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBookingStatusOnCancelled(t *testing.T) {
+	tests := []struct {
+		status BookingStatus
+		paid   bool
+		next   BookingStatus
+	}{
+		{BookingStatusPending, false, BookingStatusCancelled},
+		{BookingStatusAwaitingPayment, false, BookingStatusCancelled},
+		{BookingStatusConfirmed, false, BookingStatusCancelled},
+		{BookingStatusConfirmed, true, BookingStatusRefundPending},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s paid=%v", tt.status, tt.paid), func(t *testing.T) {
+			next := tt.status.OnCancelled(tt.paid)
+			if next != tt.next {
+				t.Fatalf("OnCancelled(%v) = %q, want %q", tt.paid, next, tt.next)
+			}
+			if err := tt.status.TransitionTo(next); err != nil {
+				t.Fatalf("OnCancelled(%v) = %q is not a valid transition: %v", tt.paid, next, err)
+			}
+		})
+	}
+}
+
+func TestBookingCalculateTotals(t *testing.T) {
+	booking := &Booking{Items: []*BookingItem{
+		{LineItem: LineItem{Quantity: 2}, UnitPrice: NewMoney(2500, "USD")},
+		{LineItem: LineItem{Quantity: 1}, UnitPrice: NewMoney(9000, "USD")},
+	}}
+	if err := booking.CalculateTotals(250, nil); err != nil {
+		t.Fatalf("CalculateTotals() = %v", err)
+	}
+	want := []Money{NewMoney(14000, "USD"), NewMoney(0, "USD"), NewMoney(350, "USD"), NewMoney(14350, "USD")}
+	got := []Money{booking.Subtotal, booking.Discount, booking.Fees, booking.Total}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("subtotal, discount, fees, total = %v, want %v", got, want)
+		}
+	}
+
+	booking.Items = append(booking.Items, &BookingItem{LineItem: LineItem{Quantity: 1}, UnitPrice: NewMoney(100, "EUR")})
+	if err := booking.CalculateTotals(250, nil); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("CalculateTotals() of mixed currencies = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotEnoughTickets         = errors.New("not enough tickets available")
+	ErrAvailableExceedsCapacity = errors.New("available tickets cannot exceed the event capacity")
+	ErrCancellationWindowClosed = errors.New("booking can no longer be cancelled")
+	ErrVersionConflict          = errors.New("the resource was modified concurrently, reload and retry")
+	ErrCapacityBelowCommitted   = errors.New("capacity cannot be lower than the tickets already sold or held")
+	ErrInvalidEventTime         = errors.New("event end time must be after its start time")
+	ErrEventNotOnSale           = errors.New("event is not open for sales")
+	ErrOrderLimitExceeded       = errors.New("quantity exceeds the per-order ticket limit of the event")
+	ErrUserLimitExceeded        = errors.New("user would exceed the per-user ticket limit of the event")
+	ErrInvalidSaleWindow        = errors.New("ticket type sale must end after it starts")
+	ErrTicketTypeNotOnSale      = errors.New("ticket type is not on sale")
+	ErrUnknownTicketType        = errors.New("ticket type does not belong to the event")
+	ErrTicketTypeRequired       = errors.New("event has several ticket types, pick one per line item")
+	ErrEmptyOrder               = errors.New("order has no tickets")
+	ErrCurrencyMismatch         = errors.New("amounts of different currencies cannot be combined")
+	ErrInvalidDiscount          = errors.New("discount must be a percentage of up to 100 or a positive amount with a currency")
+	ErrInvalidPromoWindow       = errors.New("promo code must expire after it becomes valid")
+	ErrInvalidPromoCode         = errors.New("promo code does not exist or is not active")
+	ErrPromoCodeNotApplicable   = errors.New("promo code does not apply to this order")
+	ErrPromoCodeExhausted       = errors.New("promo code has reached its redemption limit")
+	ErrPromoCodeExists          = errors.New("promo code already exists")
+	ErrRedemptionsAboveCap      = errors.New("max redemptions cannot be lower than the redemptions already made")
+	ErrEventNotEditable         = errors.New("only draft or published events can be changed")
+	ErrEventNotDraft            = errors.New("only draft events can be deleted, cancel the event with POST /events/:id/cancel instead")
+	ErrBookingNotPurgeable      = errors.New("only expired or refunded bookings can be deleted, cancel the booking instead")
+)
+
+// ErrInvalidTransition is returned when a booking status change is not
+// allowed by the transition table.
+type ErrInvalidTransition struct {
+	From BookingStatus
+	To   BookingStatus
+}
+
+func (e *ErrInvalidTransition) Error() string {
+	from := e.From
+	if from == "" {
+		from = "new"
+	}
+	return fmt.Sprintf("invalid booking status transition from %s to %s", from, e.To)
+}
+
+// ErrInvalidEventTransition is returned when an event status change is not
+// allowed by the transition table.
+type ErrInvalidEventTransition struct {
+	From EventStatus
+	To   EventStatus
+}
+
+func (e *ErrInvalidEventTransition) Error() string {
+	from := e.From
+	if from == "" {
+		from = "new"
+	}
+	return fmt.Sprintf("invalid event status transition from %s to %s", from, e.To)
+}
+
+// ErrInvalidPaymentTransition is returned when a payment status change is not
+// allowed by the transition table.
+type ErrInvalidPaymentTransition struct {
+	From PaymentStatus
+	To   PaymentStatus
+}
+
+func (e *ErrInvalidPaymentTransition) Error() string {
+	return fmt.Sprintf("invalid payment status transition from %s to %s", e.From, e.To)
+}
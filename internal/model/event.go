@@ -8,20 +8,29 @@ import (
 )
 
 type Event struct {
-	ID               uuid.UUID `json:"id" db:"id"`
-	Title            string    `json:"title" db:"title"`
-	Description      string    `json:"description" db:"description"`
-	StartTime        time.Time `json:"start_time" db:"start_time"`
-	EndTime          time.Time `json:"end_time" db:"end_time"`
-	Location         string    `json:"location" db:"location"`
-	Capacity         int       `json:"capacity" db:"capacity"`
-	AvailableTickets int       `json:"available_tickets" db:"available_tickets"`
-	Price            float64   `json:"price" db:"price"`
-	OrganizerId      uuid.UUID `json:"organizer_id" db:"organizer_id"`
-	CategoryId       uuid.UUID `json:"category_id" db:"category_id"`
-	Status           string    `json:"status" db:"status"`
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID   `json:"id" db:"id"`
+	Title            string      `json:"title" db:"title"`
+	Description      string      `json:"description" db:"description"`
+	StartTime        time.Time   `json:"start_time" db:"start_time"`
+	EndTime          time.Time   `json:"end_time" db:"end_time"`
+	Location         string      `json:"location" db:"location"`
+	Capacity         int         `json:"capacity" db:"capacity"`
+	AvailableTickets int         `json:"available_tickets" db:"available_tickets"`
+	Price            Money       `json:"price" db:"price"`
+	OrganizerId      uuid.UUID   `json:"organizer_id" db:"organizer_id"`
+	CategoryId       uuid.UUID   `json:"category_id" db:"category_id"`
+	Status           EventStatus `json:"status" db:"status"`
+	WaitingRoom      bool        `json:"waiting_room" db:"waiting_room"`
+	Version          int         `json:"version" db:"version"`
+	CreatedAt        time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Purchase limits, zero means unlimited.
+	MaxTicketsPerUser  int `json:"max_tickets_per_user" db:"max_tickets_per_user"`
+	MaxTicketsPerOrder int `json:"max_tickets_per_order" db:"max_tickets_per_order"`
+
+	// TicketTypes are only loaded by the repository methods that say so.
+	TicketTypes []*TicketType `json:"ticket_types,omitempty" db:"-"`
 }
 
 type EventStatus string
@@ -33,6 +42,23 @@ const (
 	EventStatusCompleted EventStatus = "completed"
 )
 
+// eventTransitions lists the statuses each event status may move to. The
+// empty status is the origin of a new event, cancelled and completed are final.
+var eventTransitions = map[EventStatus][]EventStatus{
+	"": {
+		EventStatusDraft,
+		EventStatusPublished,
+	},
+	EventStatusDraft: {
+		EventStatusPublished,
+		EventStatusCancelled,
+	},
+	EventStatusPublished: {
+		EventStatusCancelled,
+		EventStatusCompleted,
+	},
+}
+
 func (s EventStatus) Validate() bool {
 	switch s {
 	case EventStatusDraft, EventStatusPublished, EventStatusCancelled, EventStatusCompleted:
@@ -41,13 +67,91 @@ func (s EventStatus) Validate() bool {
 	return false
 }
 
+// CanTransitionTo reports whether the transition table allows moving to next
+func (s EventStatus) CanTransitionTo(next EventStatus) bool {
+	for _, allowed := range eventTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo returns an *ErrInvalidEventTransition if moving to next is not allowed
+func (s EventStatus) TransitionTo(next EventStatus) error {
+	if !s.CanTransitionTo(next) {
+		return &ErrInvalidEventTransition{From: s, To: next}
+	}
+	return nil
+}
+
+// OnSale reports whether holds and bookings are accepted for the event
+func (s EventStatus) OnSale() bool {
+	return s == EventStatusPublished
+}
+
+// Editable reports whether the event and its ticket types may still be
+// changed. Cancelled and completed events are kept as they ended.
+func (s EventStatus) Editable() bool {
+	return s == EventStatusDraft || s == EventStatusPublished
+}
+
 // CheckTicketAvailability verifies if the requested number of tickets is available
 func (e *Event) CheckTicketAvailability(requestedTickets int) error {
 	if requestedTickets <= 0 {
 		return errors.New("requested tickets must be greater than zero")
 	}
 	if requestedTickets > e.AvailableTickets {
-		return errors.New("not enough tickets available")
+		return ErrNotEnoughTickets
+	}
+	return nil
+}
+
+// CheckPurchaseLimits verifies that a user who already owns the given tickets
+// per ticket type may buy the line items in a single order. The limits of
+// ticket types that are not loaded are not checked.
+func (e *Event) CheckPurchaseLimits(owned map[uuid.UUID]int, items []LineItem) error {
+	quantity := TotalQuantity(items)
+	if e.MaxTicketsPerOrder > 0 && quantity > e.MaxTicketsPerOrder {
+		return ErrOrderLimitExceeded
+	}
+	total := 0
+	for _, n := range owned {
+		total += n
+	}
+	if e.MaxTicketsPerUser > 0 && total+quantity > e.MaxTicketsPerUser {
+		return ErrUserLimitExceeded
+	}
+	for _, item := range items {
+		if ticketType := e.TicketType(item.TicketTypeID); ticketType != nil {
+			if err := ticketType.CheckPurchaseLimit(owned[item.TicketTypeID], item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HasUserLimits reports whether the event or one of its loaded ticket types
+// limits the tickets per user.
+func (e *Event) HasUserLimits() bool {
+	if e.MaxTicketsPerUser > 0 {
+		return true
+	}
+	for _, ticketType := range e.TicketTypes {
+		if ticketType.MaxTicketsPerUser > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketType returns the loaded ticket type with the given id, or nil.
+func (e *Event) TicketType(id uuid.UUID) *TicketType {
+	for _, ticketType := range e.TicketTypes {
+		if ticketType.ID == id {
+			return ticketType
+		}
 	}
 	return nil
 }
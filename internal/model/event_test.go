@@ -0,0 +1,109 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEventStatusTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    EventStatus
+		to      EventStatus
+		allowed bool
+	}{
+		{"", EventStatusDraft, true},
+		{"", EventStatusPublished, true},
+		{"", EventStatusCancelled, false},
+		{"", EventStatusCompleted, false},
+		{EventStatusDraft, EventStatusPublished, true},
+		{EventStatusDraft, EventStatusCancelled, true},
+		{EventStatusDraft, EventStatusCompleted, false},
+		{EventStatusPublished, EventStatusCancelled, true},
+		{EventStatusPublished, EventStatusCompleted, true},
+		{EventStatusPublished, EventStatusDraft, false},
+		{EventStatusCancelled, EventStatusPublished, false},
+		{EventStatusCancelled, EventStatusDraft, false},
+		{EventStatusCompleted, EventStatusCancelled, false},
+		{EventStatusCompleted, EventStatusPublished, false},
+		{EventStatusPublished, EventStatusPublished, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := tt.from.TransitionTo(tt.to)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("TransitionTo() = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *ErrInvalidEventTransition
+			if !errors.As(err, &invalid) {
+				t.Fatalf("TransitionTo() = %v, want *ErrInvalidEventTransition", err)
+			}
+			if invalid.From != tt.from || invalid.To != tt.to {
+				t.Fatalf("TransitionTo() = %+v, want from %q to %q", invalid, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestEventStatusOnSale(t *testing.T) {
+	for _, status := range []EventStatus{EventStatusDraft, EventStatusPublished, EventStatusCancelled, EventStatusCompleted} {
+		if got, want := status.OnSale(), status == EventStatusPublished; got != want {
+			t.Errorf("%s.OnSale() = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestEventStatusEditable(t *testing.T) {
+	tests := map[EventStatus]bool{
+		EventStatusDraft:     true,
+		EventStatusPublished: true,
+		EventStatusCancelled: false,
+		EventStatusCompleted: false,
+	}
+	for status, want := range tests {
+		if got := status.Editable(); got != want {
+			t.Errorf("%s.Editable() = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestEventCheckPurchaseLimits(t *testing.T) {
+	vip, general := uuid.New(), uuid.New()
+	event := &Event{
+		MaxTicketsPerOrder: 4,
+		MaxTicketsPerUser:  6,
+		TicketTypes: []*TicketType{
+			{ID: vip, MaxTicketsPerUser: 2},
+			{ID: general},
+		},
+	}
+	tests := []struct {
+		name  string
+		owned map[uuid.UUID]int
+		items []LineItem
+		want  error
+	}{
+		{"within every limit", nil, []LineItem{{vip, 2}, {general, 2}}, nil},
+		{"order limit", nil, []LineItem{{general, 5}}, ErrOrderLimitExceeded},
+		{"order limit across tiers", nil, []LineItem{{vip, 1}, {general, 4}}, ErrOrderLimitExceeded},
+		{"user limit counts owned tickets", map[uuid.UUID]int{general: 3}, []LineItem{{general, 4}}, ErrUserLimitExceeded},
+		{"user limit reached exactly", map[uuid.UUID]int{general: 2}, []LineItem{{general, 4}}, nil},
+		{"tier limit", map[uuid.UUID]int{vip: 1}, []LineItem{{vip, 2}}, ErrUserLimitExceeded},
+		{"tier limit ignores other tiers", map[uuid.UUID]int{general: 3}, []LineItem{{vip, 2}}, nil},
+		{"unloaded tier", nil, []LineItem{{uuid.New(), 3}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := event.CheckPurchaseLimits(tt.owned, tt.items); !errors.Is(err, tt.want) {
+				t.Errorf("CheckPurchaseLimits() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if !event.HasUserLimits() || (&Event{TicketTypes: []*TicketType{{ID: general}}}).HasUserLimits() {
+		t.Error("HasUserLimits() does not follow the event and tier limits")
+	}
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Money is an amount in the minor units of its ISO 4217 currency, e.g. 1250
+// USD is $12.50. Amounts of different currencies never mix.
+type Money struct {
+	Amount   int64  `json:"amount" db:"amount"`
+	Currency string `json:"currency" db:"currency"`
+}
+
+// minorUnitExponents lists the currencies whose minor unit is not a hundredth.
+var minorUnitExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// MinorUnitExponent is the number of decimals of the currency.
+func MinorUnitExponent(currency string) int {
+	if exponent, ok := minorUnitExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Add sums two amounts of the same currency. The zero Money adopts the
+// currency of the other amount.
+func (m Money) Add(other Money) (Money, error) {
+	switch {
+	case m.Currency == "":
+		m.Currency = other.Currency
+	case other.Currency != "" && other.Currency != m.Currency:
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	m.Amount += other.Amount
+	return m, nil
+}
+
+// Sub subtracts an amount of the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	other.Amount = -other.Amount
+	return m.Add(other)
+}
+
+// Mul multiplies the amount by a quantity.
+func (m Money) Mul(quantity int) Money {
+	m.Amount *= int64(quantity)
+	return m
+}
+
+// Less reports whether m is smaller than other, both of the same currency.
+func (m Money) Less(other Money) bool {
+	return m.Amount < other.Amount
+}
+
+func (m Money) String() string {
+	exponent := MinorUnitExponent(m.Currency)
+	if exponent == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	unit := int64(1)
+	for i := 0; i < exponent; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, exponent, amount%unit, m.Currency)
+}
+
+// FeeRate is a fee in basis points of the subtotal, 250 is 2.5%.
+type FeeRate int64
+
+// Of returns the fee on the subtotal, rounded half up to the minor unit.
+func (r FeeRate) Of(subtotal Money) Money {
+	return Money{
+		Amount:   (subtotal.Amount*int64(r) + 5000) / 10000,
+		Currency: subtotal.Currency,
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	usd := NewMoney(1250, "usd")
+	if usd.Currency != "USD" {
+		t.Fatalf("NewMoney() currency = %q, want USD", usd.Currency)
+	}
+
+	sum, err := Money{}.Add(usd)
+	if err != nil || sum != usd {
+		t.Fatalf("zero Add() = %v, %v, want %v", sum, err, usd)
+	}
+	diff, err := usd.Sub(NewMoney(250, "USD"))
+	if err != nil || diff != NewMoney(1000, "USD") {
+		t.Fatalf("Sub() = %v, %v, want 10.00 USD", diff, err)
+	}
+	if _, err := usd.Add(NewMoney(100, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("Add() of another currency = %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if got := usd.Mul(3); got != NewMoney(3750, "USD") {
+		t.Fatalf("Mul() = %v, want 37.50 USD", got)
+	}
+	if !NewMoney(999, "USD").Less(usd) || usd.Less(usd) {
+		t.Fatal("Less() does not compare the amounts")
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(1250, "USD"), "12.50 USD"},
+		{NewMoney(5, "EUR"), "0.05 EUR"},
+		{NewMoney(-1250, "USD"), "-12.50 USD"},
+		{NewMoney(250000, "VND"), "250000 VND"},
+		{NewMoney(1500, "JPY"), "1500 JPY"},
+		{NewMoney(12345, "KWD"), "12.345 KWD"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestFeeRateOf(t *testing.T) {
+	tests := []struct {
+		rate     FeeRate
+		subtotal int64
+		want     int64
+	}{
+		{250, 10000, 250},
+		{250, 199, 5},
+		{250, 180, 5},
+		{250, 179, 4},
+		{0, 10000, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.rate.Of(NewMoney(tt.subtotal, "USD")); got != NewMoney(tt.want, "USD") {
+			t.Errorf("FeeRate(%d).Of(%d) = %v, want %d", tt.rate, tt.subtotal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification records a message sent, or being sent, for a booking. There
+// is at most one per booking and kind, which keeps a restarted scheduler from
+// sending it twice.
+type Notification struct {
+	ID        uuid.UUID          `json:"id" db:"id"`
+	BookingID uuid.UUID          `json:"booking_id" db:"booking_id"`
+	Kind      string             `json:"kind" db:"kind"`
+	Channel   string             `json:"channel" db:"channel"`
+	Recipient string             `json:"recipient" db:"recipient"`
+	Status    NotificationStatus `json:"status" db:"status"`
+	Attempts  int                `json:"attempts" db:"attempts"`
+	Error     *string            `json:"error,omitempty" db:"error"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" db:"updated_at"`
+	SentAt    *time.Time         `json:"sent_at,omitempty" db:"sent_at"`
+	// ClaimedAt is when the last attempt started sending the notification
+	ClaimedAt *time.Time `json:"claimed_at,omitempty" db:"claimed_at"`
+}
+
+type NotificationStatus string
+
+const (
+	// NotificationStatusSending is stored before the message is handed to the
+	// sender. A crash leaves it in this status until the lease of its claim
+	// expires, then it is claimed and sent again, possibly a second time.
+	NotificationStatusSending NotificationStatus = "sending"
+	NotificationStatusSent    NotificationStatus = "sent"
+	// NotificationStatusFailed is retried until the attempts run out
+	NotificationStatusFailed NotificationStatus = "failed"
+)
+
+// NotificationTarget is a booking due for a notification together with the
+// event fields the message shows.
+type NotificationTarget struct {
+	Booking
+	EventTitle     string    `db:"event_title"`
+	EventStartTime time.Time `db:"event_start_time"`
+	EventLocation  string    `db:"event_location"`
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is a domain event written in the same transaction as the
+// change it describes and published afterwards by the outbox relay.
+type OutboxMessage struct {
+	ID            int64           `json:"id" db:"id"`
+	AggregateType AggregateType   `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id" db:"aggregate_id"`
+	EventType     OutboxEventType `json:"event_type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty" db:"published_at"`
+}
+
+type AggregateType string
+
+const (
+	AggregateBooking AggregateType = "booking"
+	AggregateEvent   AggregateType = "event"
+)
+
+type OutboxEventType string
+
+const (
+	BookingCreated         OutboxEventType = "BookingCreated"
+	BookingAwaitingPayment OutboxEventType = "BookingAwaitingPayment"
+	BookingConfirmed       OutboxEventType = "BookingConfirmed"
+	BookingCancelled       OutboxEventType = "BookingCancelled"
+	BookingRefundPending   OutboxEventType = "BookingRefundPending"
+	BookingRefunded        OutboxEventType = "BookingRefunded"
+	BookingExpired         OutboxEventType = "BookingExpired"
+	BookingCheckedIn       OutboxEventType = "BookingCheckedIn"
+
+	EventCreated   OutboxEventType = "EventCreated"
+	EventUpdated   OutboxEventType = "EventUpdated"
+	EventPublished OutboxEventType = "EventPublished"
+	EventCancelled OutboxEventType = "EventCancelled"
+	EventCompleted OutboxEventType = "EventCompleted"
+)
+
+var bookingEventTypes = map[BookingStatus]OutboxEventType{
+	BookingStatusAwaitingPayment: BookingAwaitingPayment,
+	BookingStatusConfirmed:       BookingConfirmed,
+	BookingStatusCancelled:       BookingCancelled,
+	BookingStatusRefundPending:   BookingRefundPending,
+	BookingStatusRefunded:        BookingRefunded,
+	BookingStatusExpired:         BookingExpired,
+	BookingStatusCheckedIn:       BookingCheckedIn,
+}
+
+var eventEventTypes = map[EventStatus]OutboxEventType{
+	EventStatusPublished: EventPublished,
+	EventStatusCancelled: EventCancelled,
+	EventStatusCompleted: EventCompleted,
+}
+
+// BookingEventType returns the event emitted when a booking moves from one
+// status to another. Leaving the empty status means the booking was created.
+func BookingEventType(from, to BookingStatus) OutboxEventType {
+	if from == "" {
+		return BookingCreated
+	}
+	return bookingEventTypes[to]
+}
+
+// EventEventType returns the event emitted when an event moves to status to.
+func EventEventType(to EventStatus) OutboxEventType {
+	return eventEventTypes[to]
+}
+
+// BookingChanged is the payload of the booking events. Streams are read by
+// other services, so it carries IDs and statuses only and never personal
+// data like the contact email or a free text reason. Consumers that need
+// more fetch the booking.
+type BookingChanged struct {
+	BookingID  uuid.UUID     `json:"booking_id"`
+	EventID    uuid.UUID     `json:"event_id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	Status     BookingStatus `json:"status"`
+	FromStatus BookingStatus `json:"from_status,omitempty"`
+	// ReleasedTickets is the number of tickets the change gave back
+	ReleasedTickets int `json:"released_tickets,omitempty"`
+}
+
+// NewBookingChanged returns the payload of a change of booking from status
+// from.
+func NewBookingChanged(booking *Booking, from BookingStatus, releasedTickets int) *BookingChanged {
+	return &BookingChanged{
+		BookingID:       booking.ID,
+		EventID:         booking.EventID,
+		UserID:          booking.UserID,
+		Status:          booking.Status,
+		FromStatus:      from,
+		ReleasedTickets: releasedTickets,
+	}
+}
+
+// EventChanged is the payload of the event events, like BookingChanged it
+// only carries IDs and statuses.
+type EventChanged struct {
+	EventID     uuid.UUID   `json:"event_id"`
+	OrganizerID uuid.UUID   `json:"organizer_id"`
+	Version     int         `json:"version"`
+	Status      EventStatus `json:"status"`
+	FromStatus  EventStatus `json:"from_status,omitempty"`
+}
+
+// NewEventChanged returns the payload of a change of event from status from.
+func NewEventChanged(event *Event, from EventStatus) *EventChanged {
+	return &EventChanged{
+		EventID:     event.ID,
+		OrganizerID: event.OrganizerId,
+		Version:     event.Version,
+		Status:      event.Status,
+		FromStatus:  from,
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBookingChangedCarriesNoPersonalData(t *testing.T) {
+	email := "jane@example.com"
+	reason := "call me on +1 555 0100"
+	booking := &Booking{
+		ID:                 uuid.New(),
+		EventID:            uuid.New(),
+		UserID:             uuid.New(),
+		Status:             BookingStatusCancelled,
+		ContactEmail:       &email,
+		CancellationReason: &reason,
+	}
+	body, err := json.Marshal(NewBookingChanged(booking, BookingStatusConfirmed, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{email, reason, "contact_email"} {
+		if strings.Contains(string(body), leaked) {
+			t.Fatalf("payload %s contains %q", body, leaked)
+		}
+	}
+
+	var payload BookingChanged
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := BookingChanged{
+		BookingID:       booking.ID,
+		EventID:         booking.EventID,
+		UserID:          booking.UserID,
+		Status:          BookingStatusCancelled,
+		FromStatus:      BookingStatusConfirmed,
+		ReleasedTickets: 2,
+	}
+	if payload != want {
+		t.Fatalf("payload = %+v, want %+v", payload, want)
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Payment struct {
+	ID               uuid.UUID     `json:"id" db:"id"`
+	BookingID        uuid.UUID     `json:"booking_id" db:"booking_id"`
+	Provider         string        `json:"provider" db:"provider"`
+	ProviderIntentID string        `json:"provider_intent_id" db:"provider_intent_id"`
+	Amount           Money         `json:"amount" db:"amount"`
+	Status           PaymentStatus `json:"status" db:"status"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+	PaymentStatusRefunded  PaymentStatus = "refunded"
+)
+
+// paymentTransitions lists the statuses each payment status may move to.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending: {
+		PaymentStatusSucceeded,
+		PaymentStatusFailed,
+	},
+	PaymentStatusSucceeded: {
+		PaymentStatusRefunded,
+	},
+}
+
+// CanTransitionTo reports whether the transition table allows moving to next
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo returns an *ErrInvalidPaymentTransition if moving to next is not allowed
+func (s PaymentStatus) TransitionTo(next PaymentStatus) error {
+	if !s.CanTransitionTo(next) {
+		return &ErrInvalidPaymentTransition{From: s, To: next}
+	}
+	return nil
+}
+
+// PaymentSettlement is the outcome of a payment status change together with
+// the status change it caused on the booking.
+type PaymentSettlement struct {
+	Payment    *Payment
+	Transition *BookingTransition
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPaymentStatusTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    PaymentStatus
+		to      PaymentStatus
+		allowed bool
+	}{
+		{PaymentStatusPending, PaymentStatusSucceeded, true},
+		{PaymentStatusPending, PaymentStatusFailed, true},
+		{PaymentStatusPending, PaymentStatusRefunded, false},
+		{PaymentStatusSucceeded, PaymentStatusRefunded, true},
+		{PaymentStatusSucceeded, PaymentStatusFailed, false},
+		{PaymentStatusSucceeded, PaymentStatusSucceeded, false},
+		{PaymentStatusFailed, PaymentStatusSucceeded, false},
+		{PaymentStatusFailed, PaymentStatusRefunded, false},
+		{PaymentStatusRefunded, PaymentStatusSucceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := tt.from.TransitionTo(tt.to)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("TransitionTo() = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *ErrInvalidPaymentTransition
+			if !errors.As(err, &invalid) {
+				t.Fatalf("TransitionTo() = %v, want *ErrInvalidPaymentTransition", err)
+			}
+		})
+	}
+}
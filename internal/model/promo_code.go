@@ -0,0 +1,116 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage"
+	DiscountTypeFixed      DiscountType = "fixed"
+)
+
+// PromoCode discounts the subtotal of a booking. Zero caps and a zero minimum
+// quantity do not restrict the code, open validity bounds do not restrict the
+// window.
+type PromoCode struct {
+	ID   uuid.UUID `json:"id" db:"id"`
+	Code string    `json:"code" db:"code"`
+	// EventID scopes the code to one event, nil applies it to every event
+	EventID      *uuid.UUID   `json:"event_id,omitempty" db:"event_id"`
+	DiscountType DiscountType `json:"discount_type" db:"discount_type"`
+	// PercentOff is the discount of percentage codes in basis points, 1000 is 10%
+	PercentOff int64 `json:"percent_off" db:"percent_off"`
+	// AmountOff is the discount of fixed codes
+	AmountOff             Money      `json:"amount_off" db:"amount_off"`
+	MaxRedemptions        int        `json:"max_redemptions" db:"max_redemptions"`
+	MaxRedemptionsPerUser int        `json:"max_redemptions_per_user" db:"max_redemptions_per_user"`
+	RedemptionCount       int        `json:"redemption_count" db:"redemption_count"`
+	MinQuantity           int        `json:"min_quantity" db:"min_quantity"`
+	ValidFrom             *time.Time `json:"valid_from,omitempty" db:"valid_from"`
+	ValidUntil            *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	Active                bool       `json:"active" db:"active"`
+	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NormalizePromoCode is the stored form of a code, codes are case-insensitive.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks the discount and the validity window of the code.
+func (p *PromoCode) Validate() error {
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		if p.PercentOff <= 0 || p.PercentOff > 10000 {
+			return ErrInvalidDiscount
+		}
+	case DiscountTypeFixed:
+		if p.AmountOff.Amount <= 0 || p.AmountOff.Currency == "" {
+			return ErrInvalidDiscount
+		}
+	default:
+		return ErrInvalidDiscount
+	}
+	if p.ValidFrom != nil && p.ValidUntil != nil && !p.ValidUntil.After(*p.ValidFrom) {
+		return ErrInvalidPromoWindow
+	}
+	return nil
+}
+
+// CheckApplicable verifies that the code can discount an order of quantity
+// tickets of the event at the given time.
+func (p *PromoCode) CheckApplicable(eventID uuid.UUID, quantity int, at time.Time) error {
+	if !p.Active {
+		return ErrInvalidPromoCode
+	}
+	if p.ValidFrom != nil && at.Before(*p.ValidFrom) {
+		return ErrInvalidPromoCode
+	}
+	if p.ValidUntil != nil && !at.Before(*p.ValidUntil) {
+		return ErrInvalidPromoCode
+	}
+	if p.EventID != nil && *p.EventID != eventID {
+		return ErrPromoCodeNotApplicable
+	}
+	if quantity < p.MinQuantity {
+		return ErrPromoCodeNotApplicable
+	}
+	return nil
+}
+
+// CheckRedemptions verifies the caps of the code for a user who already
+// redeemed it userRedemptions times.
+func (p *PromoCode) CheckRedemptions(userRedemptions int) error {
+	if p.MaxRedemptions > 0 && p.RedemptionCount >= p.MaxRedemptions {
+		return ErrPromoCodeExhausted
+	}
+	if p.MaxRedemptionsPerUser > 0 && userRedemptions >= p.MaxRedemptionsPerUser {
+		return ErrPromoCodeExhausted
+	}
+	return nil
+}
+
+// Discount returns the discount of the code on the subtotal, rounded half up
+// to the minor unit and never more than the subtotal.
+func (p *PromoCode) Discount(subtotal Money) (Money, error) {
+	discount := Money{Currency: subtotal.Currency}
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		discount.Amount = (subtotal.Amount*p.PercentOff + 5000) / 10000
+	case DiscountTypeFixed:
+		if p.AmountOff.Currency != subtotal.Currency {
+			return Money{}, ErrPromoCodeNotApplicable
+		}
+		discount.Amount = p.AmountOff.Amount
+	}
+	if discount.Amount > subtotal.Amount {
+		discount.Amount = subtotal.Amount
+	}
+	return discount, nil
+}
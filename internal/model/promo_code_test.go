@@ -0,0 +1,141 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPromoCodeValidate(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	tests := []struct {
+		name  string
+		promo PromoCode
+		want  error
+	}{
+		{"percentage", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 1000}, nil},
+		{"full percentage", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 10000}, nil},
+		{"over a hundred percent", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 10001}, ErrInvalidDiscount},
+		{"no percentage", PromoCode{DiscountType: DiscountTypePercentage}, ErrInvalidDiscount},
+		{"fixed", PromoCode{DiscountType: DiscountTypeFixed, AmountOff: NewMoney(500, "USD")}, nil},
+		{"fixed without currency", PromoCode{DiscountType: DiscountTypeFixed, AmountOff: Money{Amount: 500}}, ErrInvalidDiscount},
+		{"unknown type", PromoCode{DiscountType: "bogo", PercentOff: 1000}, ErrInvalidDiscount},
+		{"window", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 1000, ValidFrom: &now, ValidUntil: &later}, nil},
+		{"empty window", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 1000, ValidFrom: &now, ValidUntil: &now}, ErrInvalidPromoWindow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.promo.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoCodeCheckApplicable(t *testing.T) {
+	now := time.Now()
+	from, until := now.Add(-time.Hour), now.Add(time.Hour)
+	eventID := uuid.New()
+	promo := PromoCode{Active: true, EventID: &eventID, MinQuantity: 2, ValidFrom: &from, ValidUntil: &until}
+	tests := []struct {
+		name     string
+		mutate   func(p *PromoCode)
+		eventID  uuid.UUID
+		quantity int
+		at       time.Time
+		want     error
+	}{
+		{"applicable", nil, eventID, 2, now, nil},
+		{"inactive", func(p *PromoCode) { p.Active = false }, eventID, 2, now, ErrInvalidPromoCode},
+		{"not yet valid", nil, eventID, 2, from.Add(-time.Second), ErrInvalidPromoCode},
+		{"expired", nil, eventID, 2, until, ErrInvalidPromoCode},
+		{"other event", nil, uuid.New(), 2, now, ErrPromoCodeNotApplicable},
+		{"any event", func(p *PromoCode) { p.EventID = nil }, uuid.New(), 2, now, nil},
+		{"below minimum quantity", nil, eventID, 1, now, ErrPromoCodeNotApplicable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := promo
+			if tt.mutate != nil {
+				tt.mutate(&p)
+			}
+			if err := p.CheckApplicable(tt.eventID, tt.quantity, tt.at); !errors.Is(err, tt.want) {
+				t.Errorf("CheckApplicable() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoCodeCheckRedemptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		promo           PromoCode
+		userRedemptions int
+		want            error
+	}{
+		{"uncapped", PromoCode{RedemptionCount: 1000}, 50, nil},
+		{"below the cap", PromoCode{MaxRedemptions: 10, RedemptionCount: 9}, 0, nil},
+		{"cap reached", PromoCode{MaxRedemptions: 10, RedemptionCount: 10}, 0, ErrPromoCodeExhausted},
+		{"below the user cap", PromoCode{MaxRedemptionsPerUser: 2}, 1, nil},
+		{"user cap reached", PromoCode{MaxRedemptionsPerUser: 2}, 2, ErrPromoCodeExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.promo.CheckRedemptions(tt.userRedemptions); !errors.Is(err, tt.want) {
+				t.Errorf("CheckRedemptions() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoCodeDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		promo    PromoCode
+		subtotal Money
+		want     Money
+		err      error
+	}{
+		{"percentage", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 1000}, NewMoney(14000, "USD"), NewMoney(1400, "USD"), nil},
+		{"percentage rounds half up", PromoCode{DiscountType: DiscountTypePercentage, PercentOff: 1250}, NewMoney(1004, "USD"), NewMoney(126, "USD"), nil},
+		{"fixed", PromoCode{DiscountType: DiscountTypeFixed, AmountOff: NewMoney(500, "USD")}, NewMoney(14000, "USD"), NewMoney(500, "USD"), nil},
+		{"fixed capped at the subtotal", PromoCode{DiscountType: DiscountTypeFixed, AmountOff: NewMoney(5000, "USD")}, NewMoney(2500, "USD"), NewMoney(2500, "USD"), nil},
+		{"fixed in another currency", PromoCode{DiscountType: DiscountTypeFixed, AmountOff: NewMoney(500, "EUR")}, NewMoney(2500, "USD"), Money{}, ErrPromoCodeNotApplicable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.promo.Discount(tt.subtotal)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("Discount() = %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestBookingCalculateTotalsWithPromoCode(t *testing.T) {
+	promo := &PromoCode{ID: uuid.New(), DiscountType: DiscountTypePercentage, PercentOff: 2000}
+	booking := &Booking{Items: []*BookingItem{
+		{LineItem: LineItem{Quantity: 4}, UnitPrice: NewMoney(2500, "USD")},
+	}}
+	if err := booking.CalculateTotals(250, promo); err != nil {
+		t.Fatalf("CalculateTotals() = %v", err)
+	}
+	// Fees are charged on the discounted subtotal.
+	want := []Money{NewMoney(10000, "USD"), NewMoney(2000, "USD"), NewMoney(200, "USD"), NewMoney(8200, "USD")}
+	got := []Money{booking.Subtotal, booking.Discount, booking.Fees, booking.Total}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("subtotal, discount, fees, total = %v, want %v", got, want)
+		}
+	}
+	if booking.PromoCodeID == nil || *booking.PromoCodeID != promo.ID {
+		t.Fatalf("promo code = %v, want %s", booking.PromoCodeID, promo.ID)
+	}
+
+	if err := booking.CalculateTotals(250, nil); err != nil || booking.PromoCodeID != nil || booking.Total != NewMoney(10250, "USD") {
+		t.Fatalf("CalculateTotals() without a code = %v, %v, want 102.50 USD and no code", booking.Total, err)
+	}
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTicketTypeName is the tier created for events that are created
+// without ticket types.
+const DefaultTicketTypeName = "General"
+
+// TicketType is a priced tier of an event with its own inventory. The
+// capacity and available tickets of the event are the sums over its tiers.
+type TicketType struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	EventID          uuid.UUID  `json:"event_id" db:"event_id"`
+	Name             string     `json:"name" db:"name"`
+	Price            Money      `json:"price" db:"price"`
+	Capacity         int        `json:"capacity" db:"capacity"`
+	AvailableTickets int        `json:"available_tickets" db:"available_tickets"`
+	SaleStartsAt     *time.Time `json:"sale_starts_at,omitempty" db:"sale_starts_at"`
+	SaleEndsAt       *time.Time `json:"sale_ends_at,omitempty" db:"sale_ends_at"`
+	// MaxTicketsPerUser limits the tickets of this tier per user, zero means unlimited
+	MaxTicketsPerUser int       `json:"max_tickets_per_user" db:"max_tickets_per_user"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate checks the inventory and the sale window of the tier.
+func (t *TicketType) Validate() error {
+	if t.AvailableTickets > t.Capacity {
+		return ErrAvailableExceedsCapacity
+	}
+	if t.SaleStartsAt != nil && t.SaleEndsAt != nil && !t.SaleEndsAt.After(*t.SaleStartsAt) {
+		return ErrInvalidSaleWindow
+	}
+	return nil
+}
+
+// OnSaleAt reports whether at falls into the sale window of the tier. An open
+// bound does not restrict the window.
+func (t *TicketType) OnSaleAt(at time.Time) bool {
+	if t.SaleStartsAt != nil && at.Before(*t.SaleStartsAt) {
+		return false
+	}
+	if t.SaleEndsAt != nil && !at.Before(*t.SaleEndsAt) {
+		return false
+	}
+	return true
+}
+
+// CheckPurchaseLimit verifies that a user who already owns owned tickets of
+// the tier may buy quantity more.
+func (t *TicketType) CheckPurchaseLimit(owned, quantity int) error {
+	if t.MaxTicketsPerUser > 0 && owned+quantity > t.MaxTicketsPerUser {
+		return ErrUserLimitExceeded
+	}
+	return nil
+}
+
+// LineItem is a quantity of tickets of one ticket type.
+type LineItem struct {
+	TicketTypeID uuid.UUID `json:"ticket_type_id" db:"ticket_type_id"`
+	Quantity     int       `json:"quantity" db:"quantity"`
+}
+
+// BookingItem is a line item of a booking priced at the time of booking.
+type BookingItem struct {
+	LineItem
+	UnitPrice Money `json:"unit_price" db:"unit_price"`
+}
+
+// TotalQuantity sums the quantities of the line items.
+func TotalQuantity(items []LineItem) int {
+	total := 0
+	for _, item := range items {
+		total += item.Quantity
+	}
+	return total
+}
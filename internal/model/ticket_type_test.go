@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTicketTypeOnSaleAt(t *testing.T) {
+	starts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	ends := starts.Add(48 * time.Hour)
+	tests := []struct {
+		name   string
+		starts *time.Time
+		ends   *time.Time
+		at     time.Time
+		want   bool
+	}{
+		{"open window", nil, nil, starts, true},
+		{"before the start", &starts, &ends, starts.Add(-time.Second), false},
+		{"at the start", &starts, &ends, starts, true},
+		{"just before the end", &starts, &ends, ends.Add(-time.Second), true},
+		{"at the end", &starts, &ends, ends, false},
+		{"no end", &starts, nil, ends.Add(time.Hour), true},
+		{"no start", nil, &ends, starts.Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticketType := &TicketType{SaleStartsAt: tt.starts, SaleEndsAt: tt.ends}
+			if got := ticketType.OnSaleAt(tt.at); got != tt.want {
+				t.Errorf("OnSaleAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
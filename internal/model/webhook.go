@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent is a payment provider notification as it was received, kept
+// for deduplication and for support.
+type WebhookEvent struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	Provider    string             `json:"provider" db:"provider"`
+	EventID     string             `json:"event_id" db:"event_id"`
+	EventType   string             `json:"event_type" db:"event_type"`
+	IntentID    string             `json:"intent_id" db:"intent_id"`
+	Payload     json.RawMessage    `json:"payload" db:"payload"`
+	Status      WebhookEventStatus `json:"status" db:"status"`
+	Error       *string            `json:"error,omitempty" db:"error"`
+	ReceivedAt  time.Time          `json:"received_at" db:"received_at"`
+	ProcessedAt *time.Time         `json:"processed_at,omitempty" db:"processed_at"`
+}
+
+type WebhookEventStatus string
+
+const (
+	// WebhookEventStatusReceived is stored before processing starts
+	WebhookEventStatusReceived WebhookEventStatus = "received"
+	// WebhookEventStatusProcessed means the event changed a payment
+	WebhookEventStatusProcessed WebhookEventStatus = "processed"
+	// WebhookEventStatusIgnored means the event was valid but stale, e.g. it
+	// arrived out of order or its payment already moved on
+	WebhookEventStatusIgnored WebhookEventStatus = "ignored"
+	// WebhookEventStatusFailed means processing failed and the provider
+	// should retry
+	WebhookEventStatusFailed WebhookEventStatus = "failed"
+)
+
+// Done reports whether a redelivery of the event can be acknowledged
+// without processing it again.
+func (s WebhookEventStatus) Done() bool {
+	return s == WebhookEventStatusProcessed || s == WebhookEventStatusIgnored
+}
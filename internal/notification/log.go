@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+// LogSender is a sink for local development. Messages are appended to the
+// file at path, or written to the logger when no path is set.
+type LogSender struct {
+	path   string
+	logger logger.Logger
+	mu     sync.Mutex
+}
+
+func NewLogSender(path string, logger logger.Logger) *LogSender {
+	return &LogSender{path: path, logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, message *Message) error {
+	if s.path == "" {
+		s.logger.WithContext(ctx).Infof("NOTIFICATION: to %s: %s\n%s", message.To, message.Subject, message.Text)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open notification log: %w", err)
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "=== %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), message.To, message.Subject, message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to write notification log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+const (
+	SenderSMTP = "smtp"
+	SenderLog  = "log"
+
+	// ChannelEmail is the channel of every built in sender
+	ChannelEmail = "email"
+)
+
+var ErrUnknownSender = errors.New("unknown notification sender")
+
+// Message is a rendered notification ready to be sent.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages to their recipient. Implementations must be safe
+// for concurrent use.
+type Sender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// NewSender returns the sender configured in cfg.Notification.Sender.
+func NewSender(cfg *config.Config, logger logger.Logger) (Sender, error) {
+	switch cfg.Notification.Sender {
+	case "", SenderLog:
+		return NewLogSender(cfg.Notification.LogPath, logger), nil
+	case SenderSMTP:
+		return NewSMTPSender(cfg.Notification.SMTP, cfg.Notification.From), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSender, cfg.Notification.Sender)
+	}
+}
@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kind names a notification template. Every kind has "<kind>.subject" and
+// "<kind>.text" in templates/<kind>.txt and "<kind>.html" in
+// templates/<kind>.html.
+type Kind string
+
+const (
+	KindBookingConfirmed Kind = "booking_confirmed"
+	KindEventReminder    Kind = "event_reminder"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// TemplateData is what the templates can render.
+type TemplateData struct {
+	BookingID      uuid.UUID
+	Quantity       int
+	EventTitle     string
+	EventStartTime time.Time
+	EventLocation  string
+	// StartsIn is the reminder offset in words, e.g. "24 hours"
+	StartsIn string
+}
+
+// Templates renders messages from the embedded templates.
+type Templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+func NewTemplates() (*Templates, error) {
+	text, err := texttemplate.ParseFS(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse text templates: %w", err)
+	}
+	html, err := htmltemplate.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html templates: %w", err)
+	}
+	return &Templates{text: text, html: html}, nil
+}
+
+// Render builds the message of the given kind for the recipient.
+func (t *Templates) Render(kind Kind, to string, data *TemplateData) (*Message, error) {
+	subject, err := t.execute(t.text.ExecuteTemplate, string(kind)+".subject", data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := t.execute(t.text.ExecuteTemplate, string(kind)+".text", data)
+	if err != nil {
+		return nil, err
+	}
+	html, err := t.execute(t.html.ExecuteTemplate, string(kind)+".html", data)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject),
+		Text:    text,
+		HTML:    html,
+	}, nil
+}
+
+func (t *Templates) execute(
+	execute func(wr io.Writer, name string, data any) error,
+	name string,
+	data *TemplateData,
+) (string, error) {
+	var buf bytes.Buffer
+	if err := execute(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
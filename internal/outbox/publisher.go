@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const PublisherRedisStreams = "redis"
+
+var ErrUnknownPublisher = errors.New("unknown outbox publisher")
+
+// Publisher delivers outbox messages to a message broker. Delivery is at least
+// once, consumers deduplicate on model.OutboxMessage.ID. Implementations must
+// be safe for concurrent use.
+type Publisher interface {
+	Publish(ctx context.Context, message *model.OutboxMessage) error
+}
+
+// NewPublisher returns the publisher configured in cfg.Outbox.Publisher.
+func NewPublisher(cfg *config.Config, client *redis.Client) (Publisher, error) {
+	switch cfg.Outbox.Publisher {
+	case "", PublisherRedisStreams:
+		return NewRedisStreamPublisher(client, cfg.Outbox.StreamPrefix, cfg.Outbox.StreamMaxLen), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPublisher, cfg.Outbox.Publisher)
+	}
+}
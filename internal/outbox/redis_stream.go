@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStreamPublisher appends messages to one Redis stream per aggregate
+// type, "<prefix>:booking" and "<prefix>:event".
+type RedisStreamPublisher struct {
+	client *redis.Client
+	prefix string
+	// maxLen approximately caps each stream, zero keeps every entry
+	maxLen int64
+}
+
+func NewRedisStreamPublisher(client *redis.Client, prefix string, maxLen int64) *RedisStreamPublisher {
+	return &RedisStreamPublisher{client: client, prefix: prefix, maxLen: maxLen}
+}
+
+func (p *RedisStreamPublisher) Publish(ctx context.Context, message *model.OutboxMessage) error {
+	err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.Stream(message.AggregateType),
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: map[string]interface{}{
+			"id":             strconv.FormatInt(message.ID, 10),
+			"event_type":     string(message.EventType),
+			"aggregate_type": string(message.AggregateType),
+			"aggregate_id":   message.AggregateID.String(),
+			"payload":        string(message.Payload),
+			"created_at":     message.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add outbox message %d to stream: %w", message.ID, err)
+	}
+	return nil
+}
+
+// Stream returns the name of the stream of an aggregate type.
+func (p *RedisStreamPublisher) Stream(aggregateType model.AggregateType) string {
+	return p.prefix + ":" + string(aggregateType)
+}
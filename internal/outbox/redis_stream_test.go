@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStreamPublisher(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	cfg := &config.Config{}
+	cfg.Outbox.StreamPrefix = "outbox"
+	publisher, err := NewPublisher(cfg, client)
+	if err != nil {
+		t.Fatalf("NewPublisher() = %v", err)
+	}
+	message := &model.OutboxMessage{
+		ID:            7,
+		AggregateType: model.AggregateBooking,
+		AggregateID:   uuid.New(),
+		EventType:     model.BookingCreated,
+		Payload:       []byte(`{"booking_id":"b"}`),
+		CreatedAt:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	// Delivery is at least once, a message published twice is added twice.
+	for i := 0; i < 2; i++ {
+		if err := publisher.Publish(ctx, message); err != nil {
+			t.Fatalf("Publish() = %v", err)
+		}
+	}
+
+	entries, err := client.XRange(ctx, "outbox:booking", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("stream has %d entries, want 2", len(entries))
+	}
+	want := map[string]interface{}{
+		"id":             strconv.FormatInt(message.ID, 10),
+		"event_type":     string(model.BookingCreated),
+		"aggregate_type": string(model.AggregateBooking),
+		"aggregate_id":   message.AggregateID.String(),
+		"payload":        `{"booking_id":"b"}`,
+		"created_at":     "2026-01-02T03:04:05Z",
+	}
+	for field, value := range want {
+		if entries[0].Values[field] != value {
+			t.Errorf("%s = %v, want %v", field, entries[0].Values[field], value)
+		}
+	}
+}
+
+func TestNewPublisherRejectsUnknownBroker(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Outbox.Publisher = "kafka"
+	if _, err := NewPublisher(cfg, nil); !errors.Is(err, ErrUnknownPublisher) {
+		t.Fatalf("NewPublisher() = %v, want %v", err, ErrUnknownPublisher)
+	}
+}
@@ -0,0 +1,140 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeGateway is an in-process Gateway that keeps intents in memory. It lets
+// the whole booking flow run without network access.
+type FakeGateway struct {
+	webhookSecret    string
+	webhookTolerance time.Duration
+
+	mu       sync.Mutex
+	intents  map[string]*Intent
+	declined map[string]bool
+}
+
+// NewFakeGateway returns a gateway whose webhooks are signed with
+// webhookSecret, see SignatureHeader.
+func NewFakeGateway(webhookSecret string, webhookTolerance time.Duration) *FakeGateway {
+	return &FakeGateway{
+		webhookSecret:    webhookSecret,
+		webhookTolerance: webhookTolerance,
+		intents:          map[string]*Intent{},
+		declined:         map[string]bool{},
+	}
+}
+
+func (g *FakeGateway) Provider() string {
+	return ProviderFake
+}
+
+func (g *FakeGateway) CreateIntent(_ context.Context, req *IntentRequest) (*Intent, error) {
+	id := "pi_" + uuid.NewString()
+	intent := &Intent{
+		ID:           id,
+		Status:       IntentStatusRequiresCapture,
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+		ClientSecret: id + "_secret_" + uuid.NewString(),
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.intents[id] = intent
+	copied := *intent
+	return &copied, nil
+}
+
+func (g *FakeGateway) Capture(_ context.Context, intentID string) (*Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentStatusRequiresCapture {
+		return nil, ErrInvalidIntent
+	}
+	if g.declined[intentID] {
+		intent.Status = IntentStatusFailed
+		return nil, ErrPaymentDeclined
+	}
+	intent.Status = IntentStatusSucceeded
+	copied := *intent
+	return &copied, nil
+}
+
+func (g *FakeGateway) Refund(_ context.Context, intentID string) (*Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentStatusSucceeded {
+		return nil, ErrInvalidIntent
+	}
+	intent.Status = IntentStatusRefunded
+	return &Refund{
+		ID:       "re_" + uuid.NewString(),
+		IntentID: intentID,
+		Amount:   intent.Amount,
+	}, nil
+}
+
+// ParseWebhook verifies the SignatureHeader and decodes a JSON payload of the
+// form {"id": "...", "type": "payment.succeeded", "intent_id": "..."}.
+func (g *FakeGateway) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	err := VerifySignature(payload, header.Get(SignatureHeader), g.webhookSecret, g.webhookTolerance, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		ID       string           `json:"id"`
+		Type     WebhookEventType `json:"type"`
+		IntentID string           `json:"intent_id"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if body.ID == "" || body.Type == "" || body.IntentID == "" {
+		return nil, fmt.Errorf("%w: id, type and intent_id are required", ErrInvalidWebhook)
+	}
+	return &WebhookEvent{ID: body.ID, Type: body.Type, IntentID: body.IntentID}, nil
+}
+
+// SignWebhook returns the SignatureHeader value the gateway would send
+// with payload.
+func (g *FakeGateway) SignWebhook(payload []byte) string {
+	return SignPayload(payload, g.webhookSecret, time.Now())
+}
+
+// Decline makes the next capture of the intent fail with ErrPaymentDeclined.
+func (g *FakeGateway) Decline(intentID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.declined[intentID] = true
+}
+
+// Intent returns a copy of the stored intent.
+func (g *FakeGateway) Intent(intentID string) (*Intent, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return nil, false
+	}
+	copied := *intent
+	return &copied, true
+}
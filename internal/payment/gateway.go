@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+)
+
+const ProviderFake = "fake"
+
+var (
+	ErrIntentNotFound  = errors.New("payment intent not found")
+	ErrPaymentDeclined = errors.New("payment was declined")
+	ErrInvalidIntent   = errors.New("payment intent is not in a state that allows this operation")
+	ErrInvalidWebhook  = errors.New("invalid webhook payload")
+	ErrUnknownProvider = errors.New("unknown payment provider")
+)
+
+type IntentStatus string
+
+const (
+	IntentStatusRequiresCapture IntentStatus = "requires_capture"
+	IntentStatusSucceeded       IntentStatus = "succeeded"
+	IntentStatusFailed          IntentStatus = "failed"
+	IntentStatusRefunded        IntentStatus = "refunded"
+)
+
+// IntentRequest describes the charge to authorise for a booking.
+type IntentRequest struct {
+	BookingID uuid.UUID
+	// Amount is in the minor units of Currency
+	Amount   int64
+	Currency string
+}
+
+// Intent is the provider side state of a charge.
+type Intent struct {
+	ID       string
+	Status   IntentStatus
+	Amount   int64
+	Currency string
+	// ClientSecret lets the client confirm the intent with the provider
+	ClientSecret string
+}
+
+type Refund struct {
+	ID       string
+	IntentID string
+	Amount   int64
+}
+
+type WebhookEventType string
+
+const (
+	WebhookPaymentSucceeded WebhookEventType = "payment.succeeded"
+	WebhookPaymentFailed    WebhookEventType = "payment.failed"
+	WebhookRefundSucceeded  WebhookEventType = "refund.succeeded"
+)
+
+// WebhookEvent is a provider notification about an intent.
+type WebhookEvent struct {
+	ID       string
+	Type     WebhookEventType
+	IntentID string
+}
+
+// Gateway is a payment provider. Implementations must be safe for
+// concurrent use.
+type Gateway interface {
+	// Provider names the gateway, it is stored with every payment
+	Provider() string
+	// CreateIntent authorises the amount without capturing it
+	CreateIntent(ctx context.Context, req *IntentRequest) (*Intent, error)
+	// Capture charges an authorised intent, ErrPaymentDeclined if it fails
+	Capture(ctx context.Context, intentID string) (*Intent, error)
+	// Refund gives back the captured amount of an intent
+	Refund(ctx context.Context, intentID string) (*Refund, error)
+	// ParseWebhook authenticates and decodes a notification sent by the
+	// provider, ErrInvalidSignature or ErrStaleWebhook if it cannot be trusted
+	ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+// NewGateway returns the gateway configured in cfg.Payment.Provider.
+func NewGateway(cfg *config.Config) (Gateway, error) {
+	switch cfg.Payment.Provider {
+	case "", ProviderFake:
+		return NewFakeGateway(cfg.Payment.WebhookSecret, cfg.Payment.WebhookTolerance), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, cfg.Payment.Provider)
+	}
+}
@@ -0,0 +1,83 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex hmac>" where the HMAC is
+// SHA-256 over "<unix seconds>.<payload>" keyed with the webhook secret.
+const SignatureHeader = "Payment-Signature"
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleWebhook     = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// SignPayload returns the SignatureHeader value for payload sent at ts.
+func SignPayload(payload []byte, secret string, ts time.Time) string {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, computeSignature(payload, secret, timestamp))
+}
+
+// VerifySignature checks header against payload and rejects timestamps more
+// than tolerance away from now, which stops captured requests being replayed.
+// Several v1 entries are accepted so the secret can be rotated.
+func VerifySignature(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("%w: no webhook secret configured", ErrInvalidSignature)
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed %s header", ErrInvalidSignature, SignatureHeader)
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+
+	expected := computeSignature(payload, secret, timestamp)
+	valid := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleWebhook
+	}
+	return nil
+}
+
+func computeSignature(payload []byte, secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := SignPayload(payload, secret, sentAt)
+
+	if err := VerifySignature(payload, header, secret, 5*time.Minute, sentAt.Add(time.Minute)); err != nil {
+		t.Fatalf("VerifySignature() = %v, want nil", err)
+	}
+
+	// A rotated secret is accepted next to the old one.
+	rotated := header + ",v1=" + computeSignature(payload, "whsec_new", "1700000000")
+	if err := VerifySignature(payload, rotated, "whsec_new", 5*time.Minute, sentAt); err != nil {
+		t.Fatalf("VerifySignature() with a rotated secret = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  string
+		secret  string
+		now     time.Time
+		want    error
+	}{
+		{"tampered payload", []byte(`{"id":"evt_2"}`), header, secret, sentAt, ErrInvalidSignature},
+		{"wrong secret", payload, header, "whsec_other", sentAt, ErrInvalidSignature},
+		{"no secret", payload, header, "", sentAt, ErrInvalidSignature},
+		{"missing signature", payload, "t=1700000000", secret, sentAt, ErrInvalidSignature},
+		{"missing timestamp", payload, "v1=" + computeSignature(payload, secret, "1700000000"), secret, sentAt, ErrInvalidSignature},
+		{"malformed timestamp", payload, "t=soon,v1=" + computeSignature(payload, secret, "soon"), secret, sentAt, ErrInvalidSignature},
+		{"changed timestamp", payload, "t=1700000060,v1=" + computeSignature(payload, secret, "1700000000"), secret, sentAt, ErrInvalidSignature},
+		{"replayed late", payload, header, secret, sentAt.Add(6 * time.Minute), ErrStaleWebhook},
+		{"from the future", payload, header, secret, sentAt.Add(-6 * time.Minute), ErrStaleWebhook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifySignature(tt.payload, tt.header, tt.secret, 5*time.Minute, tt.now); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
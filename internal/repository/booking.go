@@ -11,8 +11,16 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/phamdinhha/event-booking-service/internal/model"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 )
 
+const bookingColumns = `id, event_id, user_id, status, quantity, created_at, updated_at,
+	cancellation_reason, cancelled_at, contact_email,
+	subtotal AS "subtotal.amount", currency AS "subtotal.currency", discount AS "discount.amount",
+	currency AS "discount.currency", fees AS "fees.amount", currency AS "fees.currency",
+	total AS "total.amount", currency AS "total.currency", promo_code_id`
+
 type BookingRepository struct {
 	db     *sqlx.DB
 	logger logger.Logger
@@ -25,10 +33,21 @@ func NewBookingRepository(db *sqlx.DB, logger logger.Logger) BookingRepositoryIn
 	}
 }
 
+// CreateBooking reserves the items of the booking in Postgres and prices it at
+// the current prices of their ticket types, less the discount of promoCode if
+// given, plus the fees.
 func (r *BookingRepository) CreateBooking(
 	ctx context.Context,
 	booking *model.Booking,
-) (*model.Booking, error) {
+	feeRate model.FeeRate,
+	promoCode string,
+) (_ *model.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.CreateBooking")
+	defer func() { tracing.End(span, err) }()
+	if err := model.BookingStatus("").TransitionTo(booking.Status); err != nil {
+		return nil, err
+	}
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -36,21 +55,82 @@ func (r *BookingRepository) CreateBooking(
 	defer tx.Rollback()
 
 	updateEventQuery := `
-		UPDATE events SET available_tickets = available_tickets - $1 WHERE id = $2"
+		UPDATE events SET available_tickets = available_tickets - $1 WHERE id = $2 AND status = $3
+		RETURNING max_tickets_per_user, max_tickets_per_order
 	`
 
-	_, err = tx.ExecContext(ctx, updateEventQuery,
+	var event model.Event
+	err = tx.QueryRowxContext(ctx, updateEventQuery,
 		booking.Quantity,
 		booking.EventID,
-	)
-
+		model.EventStatusPublished,
+	).StructScan(&event)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrEventNotOnSale
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update available tickets: %w", err)
 	}
 
+	updateTicketTypeQuery := `
+		UPDATE ticket_types SET available_tickets = available_tickets - $3, updated_at = $4
+		WHERE id = $1 AND event_id = $2 AND available_tickets >= $3
+		RETURNING ` + ticketTypeColumns
+	for _, item := range booking.Items {
+		var ticketType model.TicketType
+		err := tx.GetContext(ctx, &ticketType, updateTicketTypeQuery,
+			item.TicketTypeID,
+			booking.EventID,
+			item.Quantity,
+			booking.CreatedAt,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			var exists bool
+			err = tx.GetContext(ctx, &exists,
+				`SELECT EXISTS (SELECT 1 FROM ticket_types WHERE id = $1 AND event_id = $2)`,
+				item.TicketTypeID,
+				booking.EventID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get ticket type: %w", err)
+			}
+			if !exists {
+				return nil, model.ErrUnknownTicketType
+			}
+			return nil, model.ErrNotEnoughTickets
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update available tickets: %w", err)
+		}
+		item.UnitPrice = ticketType.Price
+		event.TicketTypes = append(event.TicketTypes, &ticketType)
+	}
+
+	// The event row stays locked until commit, so concurrent bookings of the
+	// event queue up here and each one counts the bookings committed before it.
+	owned := map[uuid.UUID]int{}
+	if event.HasUserLimits() {
+		if owned, err = countActiveTickets(ctx, tx, booking.EventID, booking.UserID); err != nil {
+			return nil, err
+		}
+	}
+	if err := event.CheckPurchaseLimits(owned, booking.LineItems()); err != nil {
+		return nil, err
+	}
+	var promo *model.PromoCode
+	if promoCode != "" {
+		if promo, err = lockPromoCode(ctx, tx, promoCode, booking); err != nil {
+			return nil, err
+		}
+	}
+	if err := booking.CalculateTotals(feeRate, promo); err != nil {
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO bookings (id, event_id, user_id, status, quantity, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO bookings (id, event_id, user_id, status, quantity, created_at, updated_at, contact_email,
+			currency, subtotal, discount, fees, total, promo_code_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 	_, err = tx.ExecContext(ctx, query,
 		booking.ID,
@@ -60,6 +140,13 @@ func (r *BookingRepository) CreateBooking(
 		booking.Quantity,
 		booking.CreatedAt,
 		booking.UpdatedAt,
+		booking.ContactEmail,
+		booking.Total.Currency,
+		booking.Subtotal.Amount,
+		booking.Discount.Amount,
+		booking.Fees.Amount,
+		booking.Total.Amount,
+		booking.PromoCodeID,
 	)
 
 	if err != nil {
@@ -69,6 +156,19 @@ func (r *BookingRepository) CreateBooking(
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
 
+	if err := insertBookingItems(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+	if err := insertPromoRedemption(ctx, tx, booking); err != nil {
+		return nil, err
+	}
+	if err := insertStatusChange(ctx, tx, booking.ID, "", booking.Status, "", booking.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := insertBookingChanged(ctx, tx, booking, "", 0, booking.CreatedAt); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -76,61 +176,244 @@ func (r *BookingRepository) CreateBooking(
 	return booking, nil
 }
 
-func (r *BookingRepository) GetBookingByID(ctx context.Context, id uuid.UUID) (*model.Booking, error) {
+// CountActiveTickets sums the tickets per ticket type of the bookings of the
+// user that still occupy inventory of the event.
+func (r *BookingRepository) CountActiveTickets(ctx context.Context, eventID, userID uuid.UUID) (_ map[uuid.UUID]int, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.CountActiveTickets")
+	defer func() { tracing.End(span, err) }()
+	return countActiveTickets(ctx, r.db, eventID, userID)
+}
+
+func countActiveTickets(ctx context.Context, q sqlx.QueryerContext, eventID, userID uuid.UUID) (map[uuid.UUID]int, error) {
 	query := `
-		SELECT id, event_id, user_id, status, quantity, created_at, updated_at
-		FROM bookings
-		WHERE id = $1
+		SELECT i.ticket_type_id, SUM(i.quantity) AS quantity
+		FROM booking_items i
+		JOIN bookings b ON b.id = i.booking_id
+		WHERE b.event_id = $1 AND b.user_id = $2 AND b.status IN ($3, $4, $5, $6)
+		GROUP BY i.ticket_type_id
 	`
+	var items []model.LineItem
+	err := sqlx.SelectContext(ctx, q, &items, query,
+		eventID,
+		userID,
+		model.BookingStatusPending,
+		model.BookingStatusAwaitingPayment,
+		model.BookingStatusConfirmed,
+		model.BookingStatusCheckedIn,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active tickets: %w", err)
+	}
+	owned := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		owned[item.TicketTypeID] = item.Quantity
+	}
+	return owned, nil
+}
+
+func (r *BookingRepository) GetBookingByID(ctx context.Context, id uuid.UUID) (_ *model.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.GetBookingByID")
+	defer func() { tracing.End(span, err) }()
+	query := `SELECT ` + bookingColumns + ` FROM bookings WHERE id = $1`
 
 	var booking model.Booking
-	err := r.db.GetContext(ctx, &booking, query, id)
+	err = r.db.GetContext(ctx, &booking, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("booking not found: %w", err)
 		}
 		return nil, fmt.Errorf("failed to get booking: %w", err)
 	}
+	if booking.Items, err = listBookingItems(ctx, r.db, id); err != nil {
+		return nil, err
+	}
 
 	return &booking, nil
 }
 
-func (r *BookingRepository) UpdateBooking(ctx context.Context, booking *model.Booking) error {
-	query := `
-		UPDATE bookings
-		SET event_id = $2, user_id = $3, status = $4, quantity = $5, updated_at = $6
-		WHERE id = $1
-	`
+// TransitionBooking moves the booking to the given status. Leaving a status
+// that occupies tickets returns them to the event in the same transaction.
+func (r *BookingRepository) TransitionBooking(
+	ctx context.Context,
+	id uuid.UUID,
+	to model.BookingStatus,
+	reason string,
+	at time.Time,
+) (_ *model.BookingTransition, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.TransitionBooking")
+	defer func() { tracing.End(span, err) }()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	booking, err := getBookingForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := applyTransition(ctx, tx, booking, to, reason, at)
+	if err != nil {
+		return nil, err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return transition, nil
+}
+
+// CancelBooking moves the booking to cancelled, or to refund_pending when its
+// payment was captured, returns its tickets to the event and records why and
+// when it was cancelled.
+func (r *BookingRepository) CancelBooking(
+	ctx context.Context,
+	id uuid.UUID,
+	reason string,
+	cancelledAt time.Time,
+) (_ *model.BookingTransition, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.CancelBooking")
+	defer func() { tracing.End(span, err) }()
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, query,
+	booking, err := getBookingForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := hasCapturedPayment(ctx, tx, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := applyTransition(ctx, tx, booking, booking.Status.OnCancelled(paid), reason, cancelledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	booking.CancellationReason = &reason
+	booking.CancelledAt = &cancelledAt
+	_, err = tx.ExecContext(ctx,
+		`UPDATE bookings SET cancellation_reason = $2, cancelled_at = $3 WHERE id = $1`,
 		booking.ID,
-		booking.EventID,
-		booking.UserID,
-		booking.Status,
-		booking.Quantity,
-		time.Now(),
+		booking.CancellationReason,
+		booking.CancelledAt,
 	)
-
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
-		}
-		return fmt.Errorf("failed to update booking: %w", err)
+		return nil, fmt.Errorf("failed to cancel booking: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return transition, nil
+}
+
+// BookingFilter narrows ListUserBookings. Zero values do not filter.
+type BookingFilter struct {
+	UserID  uuid.UUID
+	EventID uuid.UUID
+	Status  model.BookingStatus
+	From    time.Time
+	To      time.Time
+}
+
+// bookingOrders maps the accepted orderBy values to SQL
+var bookingOrders = map[string]string{
+	"created_at":  "b.created_at ASC",
+	"-created_at": "b.created_at DESC",
+	"start_time":  "e.start_time ASC",
+	"-start_time": "e.start_time DESC",
+}
+
+func BookingOrderChoices() []string {
+	return []string{"created_at", "-created_at", "start_time", "-start_time"}
 }
 
-func (r *BookingRepository) DeleteBooking(ctx context.Context, id uuid.UUID) error {
+// ListUserBookings returns a page of bookings joined with their event and the
+// total number of bookings matching the filter.
+func (r *BookingRepository) ListUserBookings(
+	ctx context.Context,
+	filter *BookingFilter,
+	pagination *utils.Pagination,
+) (_ []*model.BookingWithEvent, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.ListUserBookings")
+	defer func() { tracing.End(span, err) }()
+	where := &whereClause{}
+	where.add("b.user_id = ?", filter.UserID)
+	if filter.EventID != uuid.Nil {
+		where.add("b.event_id = ?", filter.EventID)
+	}
+	if filter.Status != "" {
+		where.add("b.status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		where.add("b.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where.add("b.created_at < ?", filter.To)
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM bookings b ` + where.String()
+	if err := r.db.GetContext(ctx, &total, countQuery, where.args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count bookings: %w", err)
+	}
+
+	orderBy, ok := bookingOrders[pagination.GetOrderBy()]
+	if !ok {
+		orderBy = bookingOrders["-created_at"]
+	}
+	query := `
+		SELECT b.id, b.event_id, b.user_id, b.status, b.quantity, b.created_at, b.updated_at,
+			b.cancellation_reason, b.cancelled_at, b.contact_email,
+			b.subtotal AS "subtotal.amount", b.currency AS "subtotal.currency", b.discount AS "discount.amount",
+			b.currency AS "discount.currency", b.fees AS "fees.amount", b.currency AS "fees.currency",
+			b.total AS "total.amount", b.currency AS "total.currency", b.promo_code_id,
+			e.title AS event_title, e.start_time AS event_start_time
+		FROM bookings b
+		JOIN events e ON e.id = b.event_id
+		` + where.String() + `
+		ORDER BY ` + orderBy + `, b.id
+		LIMIT ` + where.placeholder(pagination.GetLimit()) + ` OFFSET ` + where.placeholder(pagination.GetOffset())
+
+	bookings := []*model.BookingWithEvent{}
+	if err := r.db.SelectContext(ctx, &bookings, query, where.args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list bookings: %w", err)
+	}
+
+	return bookings, total, nil
+}
+
+func (r *BookingRepository) GetBookingHistory(
+	ctx context.Context,
+	id uuid.UUID,
+) (_ []*model.BookingStatusChange, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.GetBookingHistory")
+	defer func() { tracing.End(span, err) }()
+	query := `
+		SELECT id, booking_id, from_status, to_status, reason, created_at
+		FROM booking_status_history
+		WHERE booking_id = $1
+		ORDER BY created_at, id
+	`
+
+	var history []*model.BookingStatusChange
+	err = r.db.SelectContext(ctx, &history, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking history: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *BookingRepository) DeleteBooking(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.DeleteBooking")
+	defer func() { tracing.End(span, err) }()
 	query := `DELETE FROM bookings WHERE id = $1`
 
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -138,7 +421,10 @@ func (r *BookingRepository) DeleteBooking(ctx context.Context, id uuid.UUID) err
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	_, err = tx.ExecContext(ctx, query, id)
+	err = releasePromoRedemption(ctx, tx, id, time.Now())
+	if err == nil {
+		_, err = tx.ExecContext(ctx, query, id)
+	}
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
@@ -153,19 +439,221 @@ func (r *BookingRepository) DeleteBooking(ctx context.Context, id uuid.UUID) err
 	return nil
 }
 
-func (r *BookingRepository) ListBookings(ctx context.Context, limit, offset int) ([]*model.Booking, error) {
+func (r *BookingRepository) ListBookings(ctx context.Context, limit, offset int) (_ []*model.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.ListBookings")
+	defer func() { tracing.End(span, err) }()
 	query := `
-		SELECT id, event_id, user_id, status, quantity, created_at, updated_at
+		SELECT ` + bookingColumns + `
 		FROM bookings
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
 	var bookings []*model.Booking
-	err := r.db.SelectContext(ctx, &bookings, query, limit, offset)
+	err = r.db.SelectContext(ctx, &bookings, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list bookings: %w", err)
 	}
 
 	return bookings, nil
 }
+
+// ListStaleBookings returns the oldest bookings that have been in status since
+// before updatedBefore.
+func (r *BookingRepository) ListStaleBookings(
+	ctx context.Context,
+	status model.BookingStatus,
+	updatedBefore time.Time,
+	limit int,
+) (_ []*model.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingRepository.ListStaleBookings")
+	defer func() { tracing.End(span, err) }()
+	query := `
+		SELECT ` + bookingColumns + `
+		FROM bookings
+		WHERE status = $1 AND updated_at < $2
+		ORDER BY updated_at
+		LIMIT $3
+	`
+
+	var bookings []*model.Booking
+	err = r.db.SelectContext(ctx, &bookings, query, status, updatedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale bookings: %w", err)
+	}
+
+	return bookings, nil
+}
+
+func getBookingForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*model.Booking, error) {
+	var booking model.Booking
+	err := tx.GetContext(ctx, &booking, `SELECT `+bookingColumns+` FROM bookings WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("booking not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	return &booking, nil
+}
+
+// applyTransition validates and writes a status change of a locked booking,
+// releasing its tickets when it stops occupying inventory.
+func applyTransition(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	booking *model.Booking,
+	to model.BookingStatus,
+	reason string,
+	at time.Time,
+) (*model.BookingTransition, error) {
+	from := booking.Status
+	if err := from.TransitionTo(to); err != nil {
+		return nil, err
+	}
+
+	transition := &model.BookingTransition{Booking: booking, From: from}
+	if from.HoldsInventory() && !to.HoldsInventory() {
+		if err := releaseEventTickets(ctx, tx, booking, at); err != nil {
+			return nil, err
+		}
+		if err := releasePromoRedemption(ctx, tx, booking.ID, at); err != nil {
+			return nil, err
+		}
+		transition.ReleasedTickets = booking.Quantity
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE bookings SET status = $2, updated_at = $3 WHERE id = $1`,
+		booking.ID,
+		to,
+		at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update booking status: %w", err)
+	}
+	if err := insertStatusChange(ctx, tx, booking.ID, from, to, reason, at); err != nil {
+		return nil, err
+	}
+
+	booking.Status = to
+	booking.UpdatedAt = at
+	if err := insertBookingChanged(ctx, tx, booking, from, transition.ReleasedTickets, at); err != nil {
+		return nil, err
+	}
+	return transition, nil
+}
+
+// releaseEventTickets gives the tickets of a booking back to its locked event
+// row following model.Event.ReleaseTickets semantics, and to the ticket types
+// of its line items. booking.Items is reloaded with what was released.
+func releaseEventTickets(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	booking *model.Booking,
+	at time.Time,
+) error {
+	var event model.Event
+	err := tx.GetContext(ctx, &event,
+		`SELECT id, capacity, available_tickets FROM events WHERE id = $1 FOR UPDATE`,
+		booking.EventID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get event: %w", err)
+	}
+	if err := event.ReleaseTickets(booking.Quantity); err != nil {
+		return fmt.Errorf("failed to release tickets: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE events SET available_tickets = $2, updated_at = $3 WHERE id = $1`,
+		event.ID,
+		event.AvailableTickets,
+		at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update available tickets: %w", err)
+	}
+
+	if booking.Items, err = listBookingItems(ctx, tx, booking.ID); err != nil {
+		return err
+	}
+	for _, item := range booking.Items {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE ticket_types SET available_tickets = available_tickets + $2, updated_at = $3
+			WHERE id = $1 AND available_tickets + $2 <= capacity
+		`,
+			item.TicketTypeID,
+			item.Quantity,
+			at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update available tickets: %w", err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to update available tickets: %w", err)
+		}
+		if updated == 0 {
+			return errors.New("failed to release tickets: cannot release more tickets than the ticket type capacity")
+		}
+	}
+	return nil
+}
+
+func listBookingItems(ctx context.Context, q sqlx.QueryerContext, bookingID uuid.UUID) ([]*model.BookingItem, error) {
+	items := []*model.BookingItem{}
+	err := sqlx.SelectContext(ctx, q, &items,
+		`SELECT i.ticket_type_id, i.quantity, i.unit_price AS "unit_price.amount", b.currency AS "unit_price.currency"
+		FROM booking_items i
+		JOIN bookings b ON b.id = i.booking_id
+		WHERE i.booking_id = $1
+		ORDER BY i.ticket_type_id`,
+		bookingID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking items: %w", err)
+	}
+	return items, nil
+}
+
+func insertBookingItems(ctx context.Context, tx *sqlx.Tx, booking *model.Booking) error {
+	for _, item := range booking.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO booking_items (booking_id, ticket_type_id, quantity, unit_price)
+			VALUES ($1, $2, $3, $4)
+		`,
+			booking.ID,
+			item.TicketTypeID,
+			item.Quantity,
+			item.UnitPrice.Amount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create booking item: %w", err)
+		}
+	}
+	return nil
+}
+
+func insertStatusChange(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	bookingID uuid.UUID,
+	from, to model.BookingStatus,
+	reason string,
+	at time.Time,
+) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO booking_status_history (booking_id, from_status, to_status, reason, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5)
+	`,
+		bookingID,
+		from,
+		to,
+		reason,
+		at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record booking status change: %w", err)
+	}
+	return nil
+}
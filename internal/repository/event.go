@@ -2,14 +2,23 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/phamdinhha/event-booking-service/internal/model"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 )
 
+const eventColumns = `id, title, description, start_time, end_time, location, capacity, available_tickets,
+	price AS "price.amount", currency AS "price.currency", organizer_id, category_id, status, waiting_room,
+	version, created_at, updated_at, max_tickets_per_user, max_tickets_per_order`
+
 type EventRepository struct {
 	db     *sqlx.DB
 	logger logger.Logger
@@ -19,15 +28,19 @@ func NewEventRepository(db *sqlx.DB, logger logger.Logger) EventRepositoryInterf
 	return &EventRepository{db: db, logger: logger}
 }
 
-func (r *EventRepository) CreateEvent(ctx context.Context, event *model.Event) error {
+func (r *EventRepository) CreateEvent(ctx context.Context, event *model.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.CreateEvent")
+	defer func() { tracing.End(span, err) }()
 	query := `
-		INSERT INTO events (id, title, description, start_time, end_time, location, capacity, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO events (id, title, description, start_time, end_time, location, capacity, available_tickets,
+			price, currency, organizer_id, category_id, status, created_at, updated_at, waiting_room,
+			max_tickets_per_user, max_tickets_per_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
 
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("EVENT_REPOSITORY.CREATE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_EVENT.Error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
@@ -39,107 +52,496 @@ func (r *EventRepository) CreateEvent(ctx context.Context, event *model.Event) e
 		event.EndTime,
 		event.Location,
 		event.Capacity,
+		event.AvailableTickets,
+		event.Price.Amount,
+		event.Price.Currency,
+		event.OrganizerId,
+		event.CategoryId,
+		event.Status,
 		event.CreatedAt,
 		event.UpdatedAt,
+		event.WaitingRoom,
+		event.MaxTicketsPerUser,
+		event.MaxTicketsPerOrder,
 	)
 
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			r.logger.Error("EVENT_REPOSITORY.CREATE_EVENT.Error", rbErr)
+			r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_EVENT.Error", rbErr)
 			return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
 		}
 		return fmt.Errorf("failed to create event: %w", err)
 	}
+	for _, ticketType := range event.TicketTypes {
+		if err := insertTicketType(ctx, tx, ticketType); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_EVENT.Error", rbErr)
+				return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
+			}
+			return err
+		}
+	}
+
+	eventTypes := []model.OutboxEventType{model.EventCreated}
+	if event.Status == model.EventStatusPublished {
+		eventTypes = append(eventTypes, model.EventPublished)
+	}
+	for _, eventType := range eventTypes {
+		if err := insertEventChanged(ctx, tx, event, eventType, "", event.CreatedAt); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_EVENT.Error", rbErr)
+				return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
+			}
+			return err
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("EVENT_REPOSITORY.CREATE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_EVENT.Error", err)
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-func (r *EventRepository) GetEventByID(ctx context.Context, id uuid.UUID) (*model.Event, error) {
-	query := `
-		SELECT id, title, description, start_time, end_time, location, capacity, created_at, updated_at
-		FROM events
-		WHERE id = $1
-	`
+// GetEventByID returns the event with its ticket types.
+func (r *EventRepository) GetEventByID(ctx context.Context, id uuid.UUID) (_ *model.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.GetEventByID")
+	defer func() { tracing.End(span, err) }()
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
 
 	var event model.Event
-	err := r.db.GetContext(ctx, &event, query, id)
+	err = r.db.GetContext(ctx, &event, query, id)
 	if err != nil {
-		r.logger.Error("EVENT_REPOSITORY.GET_EVENT_BY_ID.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.GET_EVENT_BY_ID.Error", err)
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
+	if event.TicketTypes, err = listTicketTypes(ctx, r.db, id); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.GET_EVENT_BY_ID.Error", err)
+		return nil, err
+	}
 
 	return &event, nil
 }
 
-func (r *EventRepository) UpdateEvent(ctx context.Context, event *model.Event) error {
+// EventFilter narrows ListEvents. Zero values do not filter.
+type EventFilter struct {
+	CategoryID  uuid.UUID
+	OrganizerID uuid.UUID
+	Status      string
+	StartFrom   time.Time
+	StartTo     time.Time
+	Currency    string
+	PriceMin    *int64
+	PriceMax    *int64
+	Location    string
+	// Query is matched against the title and description search vector
+	Query string
+	// Drafts are left out unless AllDrafts is set, DraftsOf keeps the drafts
+	// of that organizer.
+	AllDrafts bool
+	DraftsOf  uuid.UUID
+}
+
+// eventOrders maps the accepted orderBy values to SQL. relevance falls back to
+// start_time when there is no search query to rank against.
+var eventOrders = map[string]string{
+	"start_time":  "start_time ASC",
+	"-start_time": "start_time DESC",
+}
+
+func EventOrderChoices() []string {
+	return []string{"relevance", "start_time", "-start_time"}
+}
+
+// ListEvents returns a page of events matching the filter and the total number
+// of matching events.
+func (r *EventRepository) ListEvents(
+	ctx context.Context,
+	filter *EventFilter,
+	pagination *utils.Pagination,
+) (_ []*model.Event, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.ListEvents")
+	defer func() { tracing.End(span, err) }()
+	where := &whereClause{}
+	if filter.CategoryID != uuid.Nil {
+		where.add("category_id = ?", filter.CategoryID)
+	}
+	if filter.OrganizerID != uuid.Nil {
+		where.add("organizer_id = ?", filter.OrganizerID)
+	}
+	if filter.Status != "" {
+		where.add("status = ?", filter.Status)
+	}
+	if !filter.AllDrafts {
+		where.add("(status <> ? OR organizer_id = ?)", model.EventStatusDraft, filter.DraftsOf)
+	}
+	if !filter.StartFrom.IsZero() {
+		where.add("start_time >= ?", filter.StartFrom)
+	}
+	if !filter.StartTo.IsZero() {
+		where.add("start_time < ?", filter.StartTo)
+	}
+	if filter.Currency != "" {
+		where.add("currency = ?", filter.Currency)
+	}
+	if filter.PriceMin != nil {
+		where.add("price >= ?", *filter.PriceMin)
+	}
+	if filter.PriceMax != nil {
+		where.add("price <= ?", *filter.PriceMax)
+	}
+	if filter.Location != "" {
+		where.add("location ILIKE ?", "%"+escapeLike(filter.Location)+"%")
+	}
+	var tsQuery string
+	if filter.Query != "" {
+		tsQuery = "websearch_to_tsquery('english', " + where.placeholder(filter.Query) + ")"
+		where.add("search_vector @@ " + tsQuery)
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM events ` + where.String()
+	if err := r.db.GetContext(ctx, &total, countQuery, where.args...); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.LIST_EVENTS.Error", err)
+		return nil, 0, fmt.Errorf("failed to count events: %w", err)
+	}
+
+	orderBy, ok := eventOrders[pagination.GetOrderBy()]
+	if !ok {
+		orderBy = eventOrders["start_time"]
+		if tsQuery != "" {
+			orderBy = "ts_rank(search_vector, " + tsQuery + ") DESC, start_time ASC"
+		}
+	}
+	query := `SELECT ` + eventColumns + ` FROM events ` + where.String() + `
+		ORDER BY ` + orderBy + `, id
+		LIMIT ` + where.placeholder(pagination.GetLimit()) + ` OFFSET ` + where.placeholder(pagination.GetOffset())
+
+	events := []*model.Event{}
+	if err := r.db.SelectContext(ctx, &events, query, where.args...); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.LIST_EVENTS.Error", err)
+		return nil, 0, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// ListOnSaleEvents returns the published events that have not ended yet with
+// their ticket types.
+func (r *EventRepository) ListOnSaleEvents(ctx context.Context) (_ []*model.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.ListOnSaleEvents")
+	defer func() { tracing.End(span, err) }()
+	query := `SELECT ` + eventColumns + ` FROM events WHERE status = $1 AND end_time > $2 ORDER BY start_time`
+
+	var events []*model.Event
+	err = r.db.SelectContext(ctx, &events, query, model.EventStatusPublished, time.Now())
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.LIST_ON_SALE_EVENTS.Error", err)
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	for _, event := range events {
+		if event.TicketTypes, err = listTicketTypes(ctx, r.db, event.ID); err != nil {
+			r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.LIST_ON_SALE_EVENTS.Error", err)
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// ListWaitingRoomEvents returns the draft and published events that have a
+// waiting room.
+func (r *EventRepository) ListWaitingRoomEvents(ctx context.Context) (_ []*model.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.ListWaitingRoomEvents")
+	defer func() { tracing.End(span, err) }()
+	query := `SELECT ` + eventColumns + ` FROM events WHERE waiting_room AND status IN ($1, $2) ORDER BY start_time`
+
+	var events []*model.Event
+	err = r.db.SelectContext(ctx, &events, query, model.EventStatusDraft, model.EventStatusPublished)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.LIST_WAITING_ROOM_EVENTS.Error", err)
+		return nil, fmt.Errorf("failed to list waiting room events: %w", err)
+	}
+
+	return events, nil
+}
+
+// TransitionEvent moves the event to the given status if the transition table
+// allows it.
+func (r *EventRepository) TransitionEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	to model.EventStatus,
+	at time.Time,
+) (_ *model.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.TransitionEvent")
+	defer func() { tracing.End(span, err) }()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.TRANSITION_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	event, err := getEventForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyEventTransition(ctx, tx, event, to, at); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.TRANSITION_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return event, nil
+}
+
+// CancelEvent moves the event to cancelled and cascades the cancellation to
+// its bookings following model.BookingStatus.OnEventCancelled, all in one
+// transaction.
+func (r *EventRepository) CancelEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	reason string,
+	cancelledAt time.Time,
+) (_ *model.EventCancellation, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.CancelEvent")
+	defer func() { tracing.End(span, err) }()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CANCEL_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	event, err := getEventForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyEventTransition(ctx, tx, event, model.EventStatusCancelled, cancelledAt); err != nil {
+		return nil, err
+	}
+
+	var bookings []*model.Booking
+	err = tx.SelectContext(ctx, &bookings,
+		`SELECT `+bookingColumns+` FROM bookings WHERE event_id = $1 ORDER BY created_at, id FOR UPDATE`,
+		id,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CANCEL_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+
+	cancellation := &model.EventCancellation{Event: event}
+	for _, booking := range bookings {
+		next, ok := booking.Status.OnEventCancelled()
+		if !ok {
+			continue
+		}
+		transition, err := applyTransition(ctx, tx, booking, next, reason, cancelledAt)
+		if err != nil {
+			return nil, err
+		}
+		booking.CancellationReason = &reason
+		booking.CancelledAt = &cancelledAt
+		_, err = tx.ExecContext(ctx,
+			`UPDATE bookings SET cancellation_reason = $2, cancelled_at = $3 WHERE id = $1`,
+			booking.ID,
+			booking.CancellationReason,
+			booking.CancelledAt,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CANCEL_EVENT.Error", err)
+			return nil, fmt.Errorf("failed to cancel booking: %w", err)
+		}
+		cancellation.Bookings = append(cancellation.Bookings, transition)
+	}
+	// Released tickets were written to the event row, re-read what was stored.
+	if err := tx.GetContext(ctx, &event.AvailableTickets,
+		`SELECT available_tickets FROM events WHERE id = $1`, id); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CANCEL_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CANCEL_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return cancellation, nil
+}
+
+// CompleteEndedEvents moves every published event whose end time is before at
+// to completed and returns their ids.
+func (r *EventRepository) CompleteEndedEvents(ctx context.Context, at time.Time) (_ []uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.CompleteEndedEvents")
+	defer func() { tracing.End(span, err) }()
 	query := `
 		UPDATE events
-		SET title = $2, description = $3, start_time = $4, end_time = $5, location = $6, capacity = $7, updated_at = $8
-		WHERE id = $1
+		SET status = $1, version = version + 1, updated_at = $3
+		WHERE status = $2 AND end_time <= $3
+		RETURNING ` + eventColumns
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.COMPLETE_ENDED_EVENTS.Error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var events []*model.Event
+	err = tx.SelectContext(ctx, &events, query, model.EventStatusCompleted, model.EventStatusPublished, at)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.COMPLETE_ENDED_EVENTS.Error", err)
+		return nil, fmt.Errorf("failed to complete events: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, event := range events {
+		if err := insertEventChanged(ctx, tx, event, model.EventCompleted, model.EventStatusPublished, at); err != nil {
+			return nil, err
+		}
+		ids = append(ids, event.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.COMPLETE_ENDED_EVENTS.Error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ids, nil
+}
+
+// UpdateEvent writes the event if its version still matches event.Version. On
+// success event.Version holds the stored value. Capacity, available tickets
+// and price follow the ticket types and are not written.
+func (r *EventRepository) UpdateEvent(ctx context.Context, event *model.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.UpdateEvent")
+	defer func() { tracing.End(span, err) }()
+	query := `
+		UPDATE events
+		SET title = $2, description = $3, start_time = $4, end_time = $5, location = $6,
+			category_id = $7, waiting_room = $10, max_tickets_per_user = $11, max_tickets_per_order = $12,
+			version = version + 1, updated_at = $8
+		WHERE id = $1 AND version = $9
+		RETURNING version
 	`
 
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, query,
+	err = tx.QueryRowxContext(ctx, query,
 		event.ID,
 		event.Title,
 		event.Description,
 		event.StartTime,
 		event.EndTime,
 		event.Location,
-		event.Capacity,
+		event.CategoryId,
 		event.UpdatedAt,
-	)
+		event.Version,
+		event.WaitingRoom,
+		event.MaxTicketsPerUser,
+		event.MaxTicketsPerOrder,
+	).Scan(&event.Version)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrVersionConflict
+	}
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			r.logger.Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", rbErr)
-			return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
-		}
-		r.logger.Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", err)
 		return fmt.Errorf("failed to update event: %w", err)
 	}
+	if err := insertEventChanged(ctx, tx, event, model.EventUpdated, "", event.UpdatedAt); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_EVENT.Error", err)
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-func (r *EventRepository) DeleteEvent(ctx context.Context, id uuid.UUID) error {
+// DeleteEvent deletes a draft event. Events that were published keep their
+// bookings and payments and are cancelled instead, see CancelEvent.
+func (r *EventRepository) DeleteEvent(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.DeleteEvent")
+	defer func() { tracing.End(span, err) }()
 	query := `DELETE FROM events WHERE id = $1`
 
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("EVENT_REPOSITORY.DELETE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.DELETE_EVENT.Error", err)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
+
+	event, err := getEventForUpdate(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if event.Status != model.EventStatusDraft {
+		return model.ErrEventNotDraft
+	}
 
 	_, err = tx.ExecContext(ctx, query, id)
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			r.logger.Error("EVENT_REPOSITORY.DELETE_EVENT.Error", rbErr)
-			return fmt.Errorf("failed to rollback: %v (original error: %w)", rbErr, err)
-		}
-		r.logger.Error("EVENT_REPOSITORY.DELETE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.DELETE_EVENT.Error", err)
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("EVENT_REPOSITORY.DELETE_EVENT.Error", err)
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.DELETE_EVENT.Error", err)
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
+
+func getEventForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*model.Event, error) {
+	var event model.Event
+	err := tx.GetContext(ctx, &event, `SELECT `+eventColumns+` FROM events WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("event not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	return &event, nil
+}
+
+// applyEventTransition validates and writes a status change of a locked event.
+func applyEventTransition(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	event *model.Event,
+	to model.EventStatus,
+	at time.Time,
+) error {
+	from := event.Status
+	if err := from.TransitionTo(to); err != nil {
+		return err
+	}
+	err := tx.QueryRowxContext(ctx,
+		`UPDATE events SET status = $2, version = version + 1, updated_at = $3 WHERE id = $1 RETURNING version`,
+		event.ID,
+		to,
+		at,
+	).Scan(&event.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update event status: %w", err)
+	}
+	event.Status = to
+	event.UpdatedAt = at
+	return insertEventChanged(ctx, tx, event, model.EventEventType(to), from, at)
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+const notificationTargetColumns = `b.id, b.event_id, b.user_id, b.status, b.quantity, b.created_at, b.updated_at,
+	b.cancellation_reason, b.cancelled_at, b.contact_email,
+	b.subtotal AS "subtotal.amount", b.currency AS "subtotal.currency", b.discount AS "discount.amount",
+	b.currency AS "discount.currency", b.fees AS "fees.amount", b.currency AS "fees.currency",
+	b.total AS "total.amount", b.currency AS "total.currency", b.promo_code_id,
+	e.title AS event_title, e.start_time AS event_start_time, e.location AS event_location`
+
+// notificationDue excludes bookings that already have the notification of
+// kind $1, unless it has attempts left below $2 and either failed before $3
+// or was claimed before $4 by a sender that never finished it.
+const notificationDue = `NOT EXISTS (
+	SELECT 1 FROM notifications n
+	WHERE n.booking_id = b.id AND n.kind = $1
+		AND (n.attempts >= $2 OR NOT (
+			(n.status = '` + string(model.NotificationStatusFailed) + `' AND n.updated_at <= $3)
+			OR (n.status = '` + string(model.NotificationStatusSending) + `' AND n.claimed_at <= $4)
+		))
+)`
+
+// NotificationTargetFilter selects the bookings due for a notification.
+type NotificationTargetFilter struct {
+	Kind        string
+	MaxAttempts int
+	// RetryBefore is the latest failure that is retried
+	RetryBefore time.Time
+	// ClaimedBefore is the latest claim whose lease expired, its
+	// notification is sent again
+	ClaimedBefore time.Time
+	Now           time.Time
+}
+
+type NotificationRepository struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewNotificationRepository(db *sqlx.DB, logger logger.Logger) NotificationRepositoryInterface {
+	return &NotificationRepository{db: db, logger: logger}
+}
+
+// ListConfirmationTargets returns confirmed bookings of upcoming events that
+// still need the notification of filter.Kind.
+func (r *NotificationRepository) ListConfirmationTargets(
+	ctx context.Context,
+	filter *NotificationTargetFilter,
+	limit int,
+) ([]*model.NotificationTarget, error) {
+	query := `
+		SELECT ` + notificationTargetColumns + `
+		FROM bookings b
+		JOIN events e ON e.id = b.event_id
+		WHERE b.status = $5 AND b.contact_email IS NOT NULL AND e.start_time > $6
+			AND ` + notificationDue + `
+		ORDER BY b.updated_at, b.id
+		LIMIT $7
+	`
+
+	var targets []*model.NotificationTarget
+	err := r.db.SelectContext(ctx, &targets, query,
+		filter.Kind,
+		filter.MaxAttempts,
+		filter.RetryBefore,
+		filter.ClaimedBefore,
+		model.BookingStatusConfirmed,
+		filter.Now,
+		limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("NOTIFICATION_REPOSITORY.LIST_CONFIRMATION_TARGETS.Error", err)
+		return nil, fmt.Errorf("failed to list confirmation targets: %w", err)
+	}
+	return targets, nil
+}
+
+// ListReminderTargets returns confirmed bookings of published events starting
+// within offset that still need the reminder of filter.Kind. Bookings made
+// after the reminder was due are skipped, a smaller offset reminds them.
+func (r *NotificationRepository) ListReminderTargets(
+	ctx context.Context,
+	filter *NotificationTargetFilter,
+	offset time.Duration,
+	limit int,
+) ([]*model.NotificationTarget, error) {
+	query := `
+		SELECT ` + notificationTargetColumns + `
+		FROM bookings b
+		JOIN events e ON e.id = b.event_id
+		WHERE b.status = $5 AND b.contact_email IS NOT NULL AND e.status = $6
+			AND e.start_time > $7 AND e.start_time <= $8
+			AND b.created_at <= e.start_time - $9 * INTERVAL '1 second'
+			AND ` + notificationDue + `
+		ORDER BY e.start_time, b.id
+		LIMIT $10
+	`
+
+	var targets []*model.NotificationTarget
+	err := r.db.SelectContext(ctx, &targets, query,
+		filter.Kind,
+		filter.MaxAttempts,
+		filter.RetryBefore,
+		filter.ClaimedBefore,
+		model.BookingStatusConfirmed,
+		model.EventStatusPublished,
+		filter.Now,
+		filter.Now.Add(offset),
+		offset.Seconds(),
+		limit,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("NOTIFICATION_REPOSITORY.LIST_REMINDER_TARGETS.Error", err)
+		return nil, fmt.Errorf("failed to list reminder targets: %w", err)
+	}
+	return targets, nil
+}
+
+// ClaimNotification stores the notification as sending, claimed at
+// notification.CreatedAt. A notification that failed before, or whose claim
+// was made before claimedBefore and never finished, is claimed again while
+// it has attempts left. It returns false when the notification was sent or
+// is being sent already, otherwise notification.ID and Attempts hold the
+// stored values.
+func (r *NotificationRepository) ClaimNotification(
+	ctx context.Context,
+	notification *model.Notification,
+	maxAttempts int,
+	claimedBefore time.Time,
+) (bool, error) {
+	query := `
+		INSERT INTO notifications (id, booking_id, kind, channel, recipient, status, attempts, created_at, updated_at, claimed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $7, $7)
+		ON CONFLICT (booking_id, kind) DO UPDATE
+		SET status = EXCLUDED.status, channel = EXCLUDED.channel, recipient = EXCLUDED.recipient,
+			attempts = notifications.attempts + 1, error = NULL, updated_at = EXCLUDED.updated_at,
+			claimed_at = EXCLUDED.claimed_at
+		WHERE notifications.attempts < $9 AND (notifications.status = $8
+			OR (notifications.status = $6 AND notifications.claimed_at <= $10))
+		RETURNING id, attempts
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		notification.ID,
+		notification.BookingID,
+		notification.Kind,
+		notification.Channel,
+		notification.Recipient,
+		model.NotificationStatusSending,
+		notification.CreatedAt,
+		model.NotificationStatusFailed,
+		maxAttempts,
+		claimedBefore,
+	).Scan(&notification.ID, &notification.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).Error("NOTIFICATION_REPOSITORY.CLAIM_NOTIFICATION.Error", err)
+		return false, fmt.Errorf("failed to claim notification: %w", err)
+	}
+	notification.Status = model.NotificationStatusSending
+	notification.ClaimedAt = &notification.CreatedAt
+	return true, nil
+}
+
+// FinishNotification records the outcome of sending a claimed notification.
+func (r *NotificationRepository) FinishNotification(
+	ctx context.Context,
+	id uuid.UUID,
+	status model.NotificationStatus,
+	sendErr *string,
+	at time.Time,
+) error {
+	var sentAt *time.Time
+	if status == model.NotificationStatusSent {
+		sentAt = &at
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE notifications SET status = $2, error = $3, sent_at = $4, updated_at = $5 WHERE id = $1`,
+		id,
+		status,
+		sendErr,
+		sentAt,
+		at,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("NOTIFICATION_REPOSITORY.FINISH_NOTIFICATION.Error", err)
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+const outboxColumns = `id, aggregate_type, aggregate_id, event_type, payload, attempts, last_error,
+	created_at, next_attempt_at, published_at`
+
+type OutboxRepository struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewOutboxRepository(db *sqlx.DB, logger logger.Logger) OutboxRepositoryInterface {
+	return &OutboxRepository{db: db, logger: logger}
+}
+
+// LeaseOutboxMessages returns up to limit unpublished messages that are due at
+// now, oldest first, and hides them from other relays until now+lease. A
+// message whose relay dies is therefore delivered again once the lease ends.
+func (r *OutboxRepository) LeaseOutboxMessages(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+	now time.Time,
+) ([]*model.OutboxMessage, error) {
+	query := `
+		UPDATE outbox SET next_attempt_at = $3
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	var messages []*model.OutboxMessage
+	if err := r.db.SelectContext(ctx, &messages, query, now, limit, now.Add(lease)); err != nil {
+		r.logger.WithContext(ctx).Error("OUTBOX_REPOSITORY.LEASE_OUTBOX_MESSAGES.Error", err)
+		return nil, fmt.Errorf("failed to lease outbox messages: %w", err)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkOutboxPublished(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET published_at = $2, attempts = attempts + 1, last_error = NULL WHERE id = $1`,
+		id,
+		at,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("OUTBOX_REPOSITORY.MARK_OUTBOX_PUBLISHED.Error", err)
+		return fmt.Errorf("failed to mark outbox message published: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboxFailed records a failed delivery and schedules the next attempt.
+func (r *OutboxRepository) MarkOutboxFailed(
+	ctx context.Context,
+	id int64,
+	publishErr string,
+	nextAttemptAt time.Time,
+) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		id,
+		publishErr,
+		nextAttemptAt,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("OUTBOX_REPOSITORY.MARK_OUTBOX_FAILED.Error", err)
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+// DeletePublishedOutbox removes messages published before the given time and
+// returns how many were removed.
+func (r *OutboxRepository) DeletePublishedOutbox(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM outbox WHERE published_at < $1`, before)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("OUTBOX_REPOSITORY.DELETE_PUBLISHED_OUTBOX.Error", err)
+		return 0, fmt.Errorf("failed to delete published outbox messages: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox messages: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// insertOutboxMessage writes a domain event in the transaction of the change
+// it describes, so the event exists if and only if the change was committed.
+func insertOutboxMessage(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	aggregateType model.AggregateType,
+	aggregateID uuid.UUID,
+	eventType model.OutboxEventType,
+	payload any,
+	at time.Time,
+) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`,
+		aggregateType,
+		aggregateID,
+		eventType,
+		string(body),
+		at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox message: %w", err)
+	}
+	return nil
+}
+
+// insertBookingChanged writes the event of a booking status change.
+func insertBookingChanged(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	booking *model.Booking,
+	from model.BookingStatus,
+	releasedTickets int,
+	at time.Time,
+) error {
+	return insertOutboxMessage(ctx, tx,
+		model.AggregateBooking,
+		booking.ID,
+		model.BookingEventType(from, booking.Status),
+		model.NewBookingChanged(booking, from, releasedTickets),
+		at,
+	)
+}
+
+// insertEventChanged writes an event about a change of an event.
+func insertEventChanged(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	event *model.Event,
+	eventType model.OutboxEventType,
+	from model.EventStatus,
+	at time.Time,
+) error {
+	return insertOutboxMessage(ctx, tx,
+		model.AggregateEvent,
+		event.ID,
+		eventType,
+		model.NewEventChanged(event, from),
+		at,
+	)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+const paymentColumns = `id, booking_id, provider, provider_intent_id,
+	amount AS "amount.amount", currency AS "amount.currency", status, created_at, updated_at`
+
+type PaymentRepository struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewPaymentRepository(db *sqlx.DB, logger logger.Logger) PaymentRepositoryInterface {
+	return &PaymentRepository{db: db, logger: logger}
+}
+
+func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *model.Payment) error {
+	query := `
+		INSERT INTO payments (id, booking_id, provider, provider_intent_id, amount, currency, status,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		payment.ID,
+		payment.BookingID,
+		payment.Provider,
+		payment.ProviderIntentID,
+		payment.Amount.Amount,
+		payment.Amount.Currency,
+		payment.Status,
+		payment.CreatedAt,
+		payment.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("PAYMENT_REPOSITORY.CREATE_PAYMENT.Error", err)
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	return nil
+}
+
+// GetPaymentByBookingID returns the most recent payment of the booking.
+func (r *PaymentRepository) GetPaymentByBookingID(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE booking_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	var payment model.Payment
+	err := r.db.GetContext(ctx, &payment, query, bookingID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("payment not found: %w", err)
+		}
+		r.logger.WithContext(ctx).Error("PAYMENT_REPOSITORY.GET_PAYMENT_BY_BOOKING_ID.Error", err)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return &payment, nil
+}
+
+func (r *PaymentRepository) GetPaymentByIntentID(
+	ctx context.Context,
+	provider, intentID string,
+) (*model.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE provider = $1 AND provider_intent_id = $2`
+
+	var payment model.Payment
+	err := r.db.GetContext(ctx, &payment, query, provider, intentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("payment not found: %w", err)
+		}
+		r.logger.WithContext(ctx).Error("PAYMENT_REPOSITORY.GET_PAYMENT_BY_INTENT_ID.Error", err)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return &payment, nil
+}
+
+// SettlePayment moves the payment to the given status and its booking to
+// bookingStatus in one transaction, so a booking is never confirmed without
+// its payment being recorded.
+// hasCapturedPayment reports whether the booking has a payment whose money
+// was taken and not given back yet.
+func hasCapturedPayment(ctx context.Context, tx *sqlx.Tx, bookingID uuid.UUID) (bool, error) {
+	var captured bool
+	err := tx.GetContext(ctx, &captured,
+		`SELECT EXISTS (SELECT 1 FROM payments WHERE booking_id = $1 AND status = $2)`,
+		bookingID,
+		model.PaymentStatusSucceeded,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to check payment: %w", err)
+	}
+	return captured, nil
+}
+
+func (r *PaymentRepository) SettlePayment(
+	ctx context.Context,
+	id uuid.UUID,
+	status model.PaymentStatus,
+	bookingStatus model.BookingStatus,
+	reason string,
+	at time.Time,
+) (*model.PaymentSettlement, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("PAYMENT_REPOSITORY.SETTLE_PAYMENT.Error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var payment model.Payment
+	err = tx.GetContext(ctx, &payment, `SELECT `+paymentColumns+` FROM payments WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("payment not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if err := payment.Status.TransitionTo(status); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE payments SET status = $2, updated_at = $3 WHERE id = $1`,
+		payment.ID,
+		status,
+		at,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("PAYMENT_REPOSITORY.SETTLE_PAYMENT.Error", err)
+		return nil, fmt.Errorf("failed to update payment status: %w", err)
+	}
+	payment.Status = status
+	payment.UpdatedAt = at
+
+	booking, err := getBookingForUpdate(ctx, tx, payment.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := applyTransition(ctx, tx, booking, bookingStatus, reason, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).Error("PAYMENT_REPOSITORY.SETTLE_PAYMENT.Error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &model.PaymentSettlement{Payment: &payment, Transition: transition}, nil
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+const promoCodeColumns = `id, code, event_id, discount_type, percent_off,
+	amount_off AS "amount_off.amount", COALESCE(currency, '') AS "amount_off.currency",
+	max_redemptions, max_redemptions_per_user, redemption_count, min_quantity, valid_from, valid_until,
+	active, created_at, updated_at`
+
+type PromoCodeRepository struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewPromoCodeRepository(db *sqlx.DB, logger logger.Logger) PromoCodeRepositoryInterface {
+	return &PromoCodeRepository{db: db, logger: logger}
+}
+
+// CreatePromoCode stores the code, refusing codes that are already taken.
+func (r *PromoCodeRepository) CreatePromoCode(ctx context.Context, promo *model.PromoCode) error {
+	query := `
+		INSERT INTO promo_codes (id, code, event_id, discount_type, percent_off, amount_off, currency,
+			max_redemptions, max_redemptions_per_user, min_quantity, valid_from, valid_until, active,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (code) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		promo.ID,
+		promo.Code,
+		promo.EventID,
+		promo.DiscountType,
+		promo.PercentOff,
+		promo.AmountOff.Amount,
+		promo.AmountOff.Currency,
+		promo.MaxRedemptions,
+		promo.MaxRedemptionsPerUser,
+		promo.MinQuantity,
+		promo.ValidFrom,
+		promo.ValidUntil,
+		promo.Active,
+		promo.CreatedAt,
+		promo.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("PROMO_CODE_REPOSITORY.CREATE_PROMO_CODE.Error", err)
+		return fmt.Errorf("failed to create promo code: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create promo code: %w", err)
+	}
+	if inserted == 0 {
+		return model.ErrPromoCodeExists
+	}
+	return nil
+}
+
+func (r *PromoCodeRepository) GetPromoCodeByID(ctx context.Context, id uuid.UUID) (*model.PromoCode, error) {
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE id = $1`
+
+	var promo model.PromoCode
+	err := r.db.GetContext(ctx, &promo, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("promo code not found: %w", err)
+		}
+		r.logger.WithContext(ctx).Error("PROMO_CODE_REPOSITORY.GET_PROMO_CODE_BY_ID.Error", err)
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	return &promo, nil
+}
+
+// GetPromoCodeByCode looks up a code entered by a customer, an unknown code
+// is model.ErrInvalidPromoCode.
+func (r *PromoCodeRepository) GetPromoCodeByCode(ctx context.Context, code string) (_ *model.PromoCode, err error) {
+	ctx, span := tracing.Start(ctx, "PromoCodeRepository.GetPromoCodeByCode")
+	defer func() { tracing.End(span, err) }()
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE code = $1`
+
+	var promo model.PromoCode
+	err = r.db.GetContext(ctx, &promo, query, model.NormalizePromoCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrInvalidPromoCode
+		}
+		r.logger.WithContext(ctx).Error("PROMO_CODE_REPOSITORY.GET_PROMO_CODE_BY_CODE.Error", err)
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	return &promo, nil
+}
+
+// PromoCodeFilter narrows ListPromoCodes. Zero values do not filter.
+type PromoCodeFilter struct {
+	EventID uuid.UUID
+	Active  *bool
+}
+
+// ListPromoCodes returns a page of promo codes, newest first, and the total
+// number of matching codes.
+func (r *PromoCodeRepository) ListPromoCodes(
+	ctx context.Context,
+	filter *PromoCodeFilter,
+	pagination *utils.Pagination,
+) ([]*model.PromoCode, int, error) {
+	where := &whereClause{}
+	if filter.EventID != uuid.Nil {
+		where.add("event_id = ?", filter.EventID)
+	}
+	if filter.Active != nil {
+		where.add("active = ?", *filter.Active)
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM promo_codes ` + where.String()
+	if err := r.db.GetContext(ctx, &total, countQuery, where.args...); err != nil {
+		r.logger.WithContext(ctx).Error("PROMO_CODE_REPOSITORY.LIST_PROMO_CODES.Error", err)
+		return nil, 0, fmt.Errorf("failed to count promo codes: %w", err)
+	}
+
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes ` + where.String() + `
+		ORDER BY created_at DESC, id
+		LIMIT ` + where.placeholder(pagination.GetLimit()) + ` OFFSET ` + where.placeholder(pagination.GetOffset())
+
+	promos := []*model.PromoCode{}
+	if err := r.db.SelectContext(ctx, &promos, query, where.args...); err != nil {
+		r.logger.WithContext(ctx).Error("PROMO_CODE_REPOSITORY.LIST_PROMO_CODES.Error", err)
+		return nil, 0, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+
+	return promos, total, nil
+}
+
+// UpdatePromoCode writes the caps, the validity window and the active flag of
+// the code. A total cap cannot drop below the redemptions already made. On
+// success promo holds the stored code.
+func (r *PromoCodeRepository) UpdatePromoCode(ctx context.Context, promo *model.PromoCode) error {
+	query := `
+		UPDATE promo_codes
+		SET max_redemptions = $2, max_redemptions_per_user = $3, min_quantity = $4,
+			valid_from = $5, valid_until = $6, active = $7, updated_at = $8
+		WHERE id = $1 AND ($2 = 0 OR redemption_count <= $2)
+		RETURNING ` + promoCodeColumns
+
+	err := r.db.GetContext(ctx, promo, query,
+		promo.ID,
+		promo.MaxRedemptions,
+		promo.MaxRedemptionsPerUser,
+		promo.MinQuantity,
+		promo.ValidFrom,
+		promo.ValidUntil,
+		promo.Active,
+		promo.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.GetPromoCodeByID(ctx, promo.ID); err != nil {
+			return err
+		}
+		return model.ErrRedemptionsAboveCap
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).Error("PROMO_CODE_REPOSITORY.UPDATE_PROMO_CODE.Error", err)
+		return fmt.Errorf("failed to update promo code: %w", err)
+	}
+	return nil
+}
+
+// CountUserRedemptions returns how many live bookings of the user redeemed
+// the code.
+func (r *PromoCodeRepository) CountUserRedemptions(ctx context.Context, promoCodeID, userID uuid.UUID) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "PromoCodeRepository.CountUserRedemptions")
+	defer func() { tracing.End(span, err) }()
+	return countUserRedemptions(ctx, r.db, promoCodeID, userID)
+}
+
+func countUserRedemptions(ctx context.Context, q sqlx.QueryerContext, promoCodeID, userID uuid.UUID) (int, error) {
+	var count int
+	err := sqlx.GetContext(ctx, q, &count,
+		`SELECT COUNT(*) FROM promo_redemptions WHERE promo_code_id = $1 AND user_id = $2`,
+		promoCodeID,
+		userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count promo redemptions: %w", err)
+	}
+	return count, nil
+}
+
+// lockPromoCode loads the code for redemption by the booking. The row lock
+// serialises concurrent redemptions, so the caps checked here hold until the
+// redemption is inserted in the same transaction.
+func lockPromoCode(ctx context.Context, tx *sqlx.Tx, code string, booking *model.Booking) (*model.PromoCode, error) {
+	var promo model.PromoCode
+	err := tx.GetContext(ctx, &promo,
+		`SELECT `+promoCodeColumns+` FROM promo_codes WHERE code = $1 FOR UPDATE`,
+		model.NormalizePromoCode(code),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrInvalidPromoCode
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	if err := promo.CheckApplicable(booking.EventID, booking.Quantity, booking.CreatedAt); err != nil {
+		return nil, err
+	}
+	redeemed, err := countUserRedemptions(ctx, tx, promo.ID, booking.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := promo.CheckRedemptions(redeemed); err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// insertPromoRedemption counts the booking against the caps of its code.
+func insertPromoRedemption(ctx context.Context, tx *sqlx.Tx, booking *model.Booking) error {
+	if booking.PromoCodeID == nil {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO promo_redemptions (booking_id, promo_code_id, user_id, created_at) VALUES ($1, $2, $3, $4)`,
+		booking.ID,
+		*booking.PromoCodeID,
+		booking.UserID,
+		booking.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create promo redemption: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE promo_codes SET redemption_count = redemption_count + 1, updated_at = $2 WHERE id = $1`,
+		*booking.PromoCodeID,
+		booking.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update promo code: %w", err)
+	}
+	return nil
+}
+
+// releasePromoRedemption frees the redemption of a booking that gave its
+// tickets back, so it no longer counts against the caps of its code. The
+// booking keeps the discount it was charged.
+func releasePromoRedemption(ctx context.Context, tx *sqlx.Tx, bookingID uuid.UUID, at time.Time) error {
+	var promoCodeID uuid.UUID
+	err := tx.GetContext(ctx, &promoCodeID,
+		`DELETE FROM promo_redemptions WHERE booking_id = $1 RETURNING promo_code_id`,
+		bookingID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete promo redemption: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE promo_codes SET redemption_count = redemption_count - 1, updated_at = $2 WHERE id = $1`,
+		promoCodeID,
+		at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update promo code: %w", err)
+	}
+	return nil
+}
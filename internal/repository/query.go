@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereClause collects SQL conditions together with their positional
+// arguments. Every ? in a condition becomes the next $n placeholder.
+type whereClause struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (w *whereClause) add(condition string, args ...interface{}) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conditions = append(w.conditions, condition)
+}
+
+// placeholder registers an argument outside of the conditions, e.g. for
+// LIMIT and OFFSET, and returns its $n placeholder.
+func (w *whereClause) placeholder(arg interface{}) string {
+	w.args = append(w.args, arg)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards of a user supplied substring.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
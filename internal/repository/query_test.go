@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereClause(t *testing.T) {
+	where := &whereClause{}
+	if got := where.String(); got != "" {
+		t.Fatalf("empty String() = %q, want \"\"", got)
+	}
+
+	where.add("status = ?", "published")
+	where.add("(status <> ? OR organizer_id = ?)", "draft", "organizer")
+	search := "websearch_to_tsquery('english', " + where.placeholder("jazz") + ")"
+	where.add("search_vector @@ " + search)
+	limit := where.placeholder(10)
+
+	want := "WHERE status = $1 AND (status <> $2 OR organizer_id = $3) AND " +
+		"search_vector @@ websearch_to_tsquery('english', $4)"
+	if got := where.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if limit != "$5" {
+		t.Errorf("placeholder() = %q, want $5", limit)
+	}
+	if wantArgs := []interface{}{"published", "draft", "organizer", "jazz", 10}; !reflect.DeepEqual(where.args, wantArgs) {
+		t.Errorf("args = %v, want %v", where.args, wantArgs)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"Hanoi":        "Hanoi",
+		"100%":         `100\%`,
+		"hall_a":       `hall\_a`,
+		`back\slash`:   `back\\slash`,
+		`50%_off\deal`: `50\%\_off\\deal`,
+	}
+	for in, want := range tests {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
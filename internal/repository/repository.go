@@ -2,22 +2,75 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 )
 
 type BookingRepositoryInterface interface {
-	CreateBooking(ctx context.Context, booking *model.Booking) (*model.Booking, error)
+	CreateBooking(ctx context.Context, booking *model.Booking, feeRate model.FeeRate, promoCode string) (*model.Booking, error)
 	GetBookingByID(ctx context.Context, id uuid.UUID) (*model.Booking, error)
-	UpdateBooking(ctx context.Context, booking *model.Booking) error
+	TransitionBooking(ctx context.Context, id uuid.UUID, to model.BookingStatus, reason string, at time.Time) (*model.BookingTransition, error)
+	CancelBooking(ctx context.Context, id uuid.UUID, reason string, cancelledAt time.Time) (*model.BookingTransition, error)
+	GetBookingHistory(ctx context.Context, id uuid.UUID) ([]*model.BookingStatusChange, error)
 	DeleteBooking(ctx context.Context, id uuid.UUID) error
 	ListBookings(ctx context.Context, limit, offset int) ([]*model.Booking, error)
+	ListUserBookings(ctx context.Context, filter *BookingFilter, pagination *utils.Pagination) ([]*model.BookingWithEvent, int, error)
+	ListStaleBookings(ctx context.Context, status model.BookingStatus, updatedBefore time.Time, limit int) ([]*model.Booking, error)
+	CountActiveTickets(ctx context.Context, eventID, userID uuid.UUID) (map[uuid.UUID]int, error)
 }
 
 type EventRepositoryInterface interface {
 	CreateEvent(ctx context.Context, event *model.Event) error
 	GetEventByID(ctx context.Context, id uuid.UUID) (*model.Event, error)
+	ListEvents(ctx context.Context, filter *EventFilter, pagination *utils.Pagination) ([]*model.Event, int, error)
+	ListOnSaleEvents(ctx context.Context) ([]*model.Event, error)
+	ListWaitingRoomEvents(ctx context.Context) ([]*model.Event, error)
+	TransitionEvent(ctx context.Context, id uuid.UUID, to model.EventStatus, at time.Time) (*model.Event, error)
+	CancelEvent(ctx context.Context, id uuid.UUID, reason string, cancelledAt time.Time) (*model.EventCancellation, error)
+	CompleteEndedEvents(ctx context.Context, at time.Time) ([]uuid.UUID, error)
 	UpdateEvent(ctx context.Context, event *model.Event) error
+	ListTicketTypes(ctx context.Context, eventID uuid.UUID) ([]*model.TicketType, error)
+	CreateTicketType(ctx context.Context, ticketType *model.TicketType) (*model.Event, error)
+	UpdateTicketType(ctx context.Context, ticketType *model.TicketType, capacityDelta int) (*model.Event, error)
 	DeleteEvent(ctx context.Context, id uuid.UUID) error
 }
+
+type PaymentRepositoryInterface interface {
+	CreatePayment(ctx context.Context, payment *model.Payment) error
+	GetPaymentByBookingID(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error)
+	GetPaymentByIntentID(ctx context.Context, provider, intentID string) (*model.Payment, error)
+	SettlePayment(ctx context.Context, id uuid.UUID, status model.PaymentStatus, bookingStatus model.BookingStatus, reason string, at time.Time) (*model.PaymentSettlement, error)
+}
+
+type WebhookRepositoryInterface interface {
+	RecordWebhookEvent(ctx context.Context, event *model.WebhookEvent) (bool, error)
+	FinishWebhookEvent(ctx context.Context, id uuid.UUID, status model.WebhookEventStatus, processingErr *string, at time.Time) error
+	GetWebhookEvent(ctx context.Context, id uuid.UUID) (*model.WebhookEvent, error)
+	ListWebhookEvents(ctx context.Context, filter *WebhookEventFilter, pagination *utils.Pagination) ([]*model.WebhookEvent, int, error)
+}
+
+type OutboxRepositoryInterface interface {
+	LeaseOutboxMessages(ctx context.Context, limit int, lease time.Duration, now time.Time) ([]*model.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, id int64, at time.Time) error
+	MarkOutboxFailed(ctx context.Context, id int64, publishErr string, nextAttemptAt time.Time) error
+	DeletePublishedOutbox(ctx context.Context, before time.Time) (int, error)
+}
+
+type NotificationRepositoryInterface interface {
+	ListConfirmationTargets(ctx context.Context, filter *NotificationTargetFilter, limit int) ([]*model.NotificationTarget, error)
+	ListReminderTargets(ctx context.Context, filter *NotificationTargetFilter, offset time.Duration, limit int) ([]*model.NotificationTarget, error)
+	ClaimNotification(ctx context.Context, notification *model.Notification, maxAttempts int, claimedBefore time.Time) (bool, error)
+	FinishNotification(ctx context.Context, id uuid.UUID, status model.NotificationStatus, sendErr *string, at time.Time) error
+}
+
+type PromoCodeRepositoryInterface interface {
+	CreatePromoCode(ctx context.Context, promo *model.PromoCode) error
+	GetPromoCodeByID(ctx context.Context, id uuid.UUID) (*model.PromoCode, error)
+	GetPromoCodeByCode(ctx context.Context, code string) (*model.PromoCode, error)
+	ListPromoCodes(ctx context.Context, filter *PromoCodeFilter, pagination *utils.Pagination) ([]*model.PromoCode, int, error)
+	UpdatePromoCode(ctx context.Context, promo *model.PromoCode) error
+	CountUserRedemptions(ctx context.Context, promoCodeID, userID uuid.UUID) (int, error)
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+)
+
+const ticketTypeColumns = `id, event_id, name, price AS "price.amount", currency AS "price.currency",
+	capacity, available_tickets, sale_starts_at, sale_ends_at, max_tickets_per_user, created_at, updated_at`
+
+// ListTicketTypes returns the ticket types of the event, cheapest first.
+func (r *EventRepository) ListTicketTypes(ctx context.Context, eventID uuid.UUID) (_ []*model.TicketType, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.ListTicketTypes")
+	defer func() { tracing.End(span, err) }()
+	ticketTypes, err := listTicketTypes(ctx, r.db, eventID)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.LIST_TICKET_TYPES.Error", err)
+		return nil, err
+	}
+	return ticketTypes, nil
+}
+
+// CreateTicketType adds a tier to a draft or published event and grows the
+// capacity and available tickets of the event by those of the tier. The tier
+// must be priced in the currency of the event.
+func (r *EventRepository) CreateTicketType(ctx context.Context, ticketType *model.TicketType) (_ *model.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.CreateTicketType")
+	defer func() { tracing.End(span, err) }()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_TICKET_TYPE.Error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	event, err := getEventForUpdate(ctx, tx, ticketType.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if !event.Status.Editable() {
+		return nil, model.ErrEventNotEditable
+	}
+	if ticketType.Price.Currency != event.Price.Currency {
+		return nil, model.ErrCurrencyMismatch
+	}
+	if err := insertTicketType(ctx, tx, ticketType); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_TICKET_TYPE.Error", err)
+		return nil, err
+	}
+	event, err = updateEventTotals(ctx, tx, event.ID, ticketType.Capacity, ticketType.AvailableTickets, ticketType.CreatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_TICKET_TYPE.Error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.CREATE_TICKET_TYPE.Error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return event, nil
+}
+
+// UpdateTicketType writes the tier and moves its available tickets, and those
+// of its event, by capacityDelta, refusing to go below zero. On success
+// ticketType.AvailableTickets holds the stored value.
+func (r *EventRepository) UpdateTicketType(
+	ctx context.Context,
+	ticketType *model.TicketType,
+	capacityDelta int,
+) (_ *model.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventRepository.UpdateTicketType")
+	defer func() { tracing.End(span, err) }()
+	query := `
+		UPDATE ticket_types
+		SET name = $3, price = $4, capacity = $5, available_tickets = available_tickets + $6,
+			sale_starts_at = $7, sale_ends_at = $8, max_tickets_per_user = $9, updated_at = $10
+		WHERE id = $1 AND event_id = $2 AND available_tickets + $6 >= 0
+		RETURNING available_tickets
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_TICKET_TYPE.Error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the event before the tier, in the same order as bookings do.
+	event, err := getEventForUpdate(ctx, tx, ticketType.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if !event.Status.Editable() {
+		return nil, model.ErrEventNotEditable
+	}
+	err = tx.QueryRowxContext(ctx, query,
+		ticketType.ID,
+		ticketType.EventID,
+		ticketType.Name,
+		ticketType.Price.Amount,
+		ticketType.Capacity,
+		capacityDelta,
+		ticketType.SaleStartsAt,
+		ticketType.SaleEndsAt,
+		ticketType.MaxTicketsPerUser,
+		ticketType.UpdatedAt,
+	).Scan(&ticketType.AvailableTickets)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		err = tx.GetContext(ctx, &exists,
+			`SELECT EXISTS (SELECT 1 FROM ticket_types WHERE id = $1 AND event_id = $2)`,
+			ticketType.ID,
+			ticketType.EventID,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_TICKET_TYPE.Error", err)
+			return nil, fmt.Errorf("failed to get ticket type: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("ticket type not found: %w", sql.ErrNoRows)
+		}
+		return nil, model.ErrCapacityBelowCommitted
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_TICKET_TYPE.Error", err)
+		return nil, fmt.Errorf("failed to update ticket type: %w", err)
+	}
+	event, err = updateEventTotals(ctx, tx, ticketType.EventID, capacityDelta, capacityDelta, ticketType.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_TICKET_TYPE.Error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).Error("EVENT_REPOSITORY.UPDATE_TICKET_TYPE.Error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return event, nil
+}
+
+func listTicketTypes(ctx context.Context, q sqlx.QueryerContext, eventID uuid.UUID) ([]*model.TicketType, error) {
+	query := `SELECT ` + ticketTypeColumns + ` FROM ticket_types WHERE event_id = $1 ORDER BY price, name`
+
+	ticketTypes := []*model.TicketType{}
+	if err := sqlx.SelectContext(ctx, q, &ticketTypes, query, eventID); err != nil {
+		return nil, fmt.Errorf("failed to list ticket types: %w", err)
+	}
+	return ticketTypes, nil
+}
+
+func insertTicketType(ctx context.Context, tx *sqlx.Tx, ticketType *model.TicketType) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ticket_types (id, event_id, name, price, currency, capacity, available_tickets,
+			sale_starts_at, sale_ends_at, max_tickets_per_user, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		ticketType.ID,
+		ticketType.EventID,
+		ticketType.Name,
+		ticketType.Price.Amount,
+		ticketType.Price.Currency,
+		ticketType.Capacity,
+		ticketType.AvailableTickets,
+		ticketType.SaleStartsAt,
+		ticketType.SaleEndsAt,
+		ticketType.MaxTicketsPerUser,
+		ticketType.CreatedAt,
+		ticketType.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket type: %w", err)
+	}
+	return nil
+}
+
+// updateEventTotals moves the capacity and available tickets of a locked event
+// after a change to its tiers, lists it from its cheapest tier and records the
+// update in the outbox.
+func updateEventTotals(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	eventID uuid.UUID,
+	capacityDelta, availableDelta int,
+	at time.Time,
+) (*model.Event, error) {
+	query := `
+		UPDATE events
+		SET capacity = capacity + $2, available_tickets = available_tickets + $3,
+			price = (SELECT MIN(price) FROM ticket_types WHERE event_id = $1),
+			version = version + 1, updated_at = $4
+		WHERE id = $1
+		RETURNING ` + eventColumns
+
+	var event model.Event
+	if err := tx.GetContext(ctx, &event, query, eventID, capacityDelta, availableDelta, at); err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+	if err := insertEventChanged(ctx, tx, &event, model.EventUpdated, "", at); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+const webhookEventColumns = `id, provider, event_id, event_type, intent_id, payload, status, error,
+	received_at, processed_at`
+
+type WebhookRepository struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewWebhookRepository(db *sqlx.DB, logger logger.Logger) WebhookRepositoryInterface {
+	return &WebhookRepository{db: db, logger: logger}
+}
+
+// RecordWebhookEvent stores the event unless the provider already delivered
+// it. It returns false and loads the stored event into event for a redelivery.
+func (r *WebhookRepository) RecordWebhookEvent(ctx context.Context, event *model.WebhookEvent) (bool, error) {
+	query := `
+		INSERT INTO webhook_events (id, provider, event_id, event_type, intent_id, payload, status, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.ID,
+		event.Provider,
+		event.EventID,
+		event.EventType,
+		event.IntentID,
+		string(event.Payload),
+		event.Status,
+		event.ReceivedAt,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("WEBHOOK_REPOSITORY.RECORD_WEBHOOK_EVENT.Error", err)
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+	if inserted == 1 {
+		return true, nil
+	}
+
+	err = r.db.GetContext(ctx, event,
+		`SELECT `+webhookEventColumns+` FROM webhook_events WHERE provider = $1 AND event_id = $2`,
+		event.Provider,
+		event.EventID,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("WEBHOOK_REPOSITORY.RECORD_WEBHOOK_EVENT.Error", err)
+		return false, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+	return false, nil
+}
+
+// FinishWebhookEvent records the outcome of processing the event.
+func (r *WebhookRepository) FinishWebhookEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	status model.WebhookEventStatus,
+	processingErr *string,
+	at time.Time,
+) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_events SET status = $2, error = $3, processed_at = $4 WHERE id = $1`,
+		id,
+		status,
+		processingErr,
+		at,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("WEBHOOK_REPOSITORY.FINISH_WEBHOOK_EVENT.Error", err)
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhookEvent(ctx context.Context, id uuid.UUID) (*model.WebhookEvent, error) {
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events WHERE id = $1`
+
+	var event model.WebhookEvent
+	err := r.db.GetContext(ctx, &event, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("webhook event not found: %w", err)
+		}
+		r.logger.WithContext(ctx).Error("WEBHOOK_REPOSITORY.GET_WEBHOOK_EVENT.Error", err)
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+
+	return &event, nil
+}
+
+// WebhookEventFilter narrows ListWebhookEvents. Zero values do not filter.
+type WebhookEventFilter struct {
+	Provider  string
+	EventType string
+	IntentID  string
+	Status    model.WebhookEventStatus
+	From      time.Time
+	To        time.Time
+}
+
+// ListWebhookEvents returns a page of webhook events, newest first, and the
+// total number of matching events.
+func (r *WebhookRepository) ListWebhookEvents(
+	ctx context.Context,
+	filter *WebhookEventFilter,
+	pagination *utils.Pagination,
+) ([]*model.WebhookEvent, int, error) {
+	where := &whereClause{}
+	if filter.Provider != "" {
+		where.add("provider = ?", filter.Provider)
+	}
+	if filter.EventType != "" {
+		where.add("event_type = ?", filter.EventType)
+	}
+	if filter.IntentID != "" {
+		where.add("intent_id = ?", filter.IntentID)
+	}
+	if filter.Status != "" {
+		where.add("status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		where.add("received_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where.add("received_at < ?", filter.To)
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM webhook_events ` + where.String()
+	if err := r.db.GetContext(ctx, &total, countQuery, where.args...); err != nil {
+		r.logger.WithContext(ctx).Error("WEBHOOK_REPOSITORY.LIST_WEBHOOK_EVENTS.Error", err)
+		return nil, 0, fmt.Errorf("failed to count webhook events: %w", err)
+	}
+
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events ` + where.String() + `
+		ORDER BY received_at DESC, id
+		LIMIT ` + where.placeholder(pagination.GetLimit()) + ` OFFSET ` + where.placeholder(pagination.GetOffset())
+
+	events := []*model.WebhookEvent{}
+	if err := r.db.SelectContext(ctx, &events, query, where.args...); err != nil {
+		r.logger.WithContext(ctx).Error("WEBHOOK_REPOSITORY.LIST_WEBHOOK_EVENTS.Error", err)
+		return nil, 0, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+
+	return events, total, nil
+}
@@ -12,17 +12,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
 	"github.com/phamdinhha/event-booking-service/internal/delivery/http_v1"
+	"github.com/phamdinhha/event-booking-service/internal/middleware"
+	"github.com/phamdinhha/event-booking-service/internal/payment"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
 	"github.com/phamdinhha/event-booking-service/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 )
 
 type Server struct {
-	logger logger.Logger
-	cfg    *config.Config
-	redis  *redis.Client
-	db     *sqlx.DB
+	logger   logger.Logger
+	cfg      *config.Config
+	redis    *redis.Client
+	db       *sqlx.DB
+	gateway  payment.Gateway
+	verifier *auth.Verifier
 }
 
 func NewServer(
@@ -30,12 +36,16 @@ func NewServer(
 	cfg *config.Config,
 	redis *redis.Client,
 	db *sqlx.DB,
+	gateway payment.Gateway,
+	verifier *auth.Verifier,
 ) *Server {
 	return &Server{
-		logger: logger,
-		cfg:    cfg,
-		redis:  redis,
-		db:     db,
+		logger:   logger,
+		cfg:      cfg,
+		redis:    redis,
+		db:       db,
+		gateway:  gateway,
+		verifier: verifier,
 	}
 }
 
@@ -77,9 +87,17 @@ func (s *Server) Run(ctx context.Context) (shutdown utils.Deamon, err error) {
 }
 
 func (s *Server) SetupHandlers() *gin.Engine {
-	ginEngine := gin.Default()
+	mw := middleware.NewMiddlewareManager(s.cfg, s.logger, s.redis, s.verifier)
+	ginEngine := gin.New()
+	if err := ginEngine.SetTrustedProxies(s.cfg.Server.TrustedProxies); err != nil {
+		s.logger.Error("SERVER.SETUP_HANDLERS.Error", err)
+	}
+	// The request span comes first so the access log and everything after
+	// it see its trace id. Metrics wrap the recovery to count panics as 500.
+	ginEngine.Use(mw.Tracing())
+	ginEngine.Use(mw.AccessLog())
+	ginEngine.Use(mw.Metrics())
 	ginEngine.Use(gin.Recovery())
-	ginEngine.Use(gin.Logger())
 
 	ginEngine.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -89,21 +107,50 @@ func (s *Server) SetupHandlers() *gin.Engine {
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
-	s.MapHandlers(ginEngine)
+	s.MapHandlers(ginEngine, mw)
 	return ginEngine
 }
 
-func (s *Server) MapHandlers(ginEngine *gin.Engine) {
-	factory := http_v1.NewControllerFactory(s.db, s.logger, s.redis)
+func (s *Server) MapHandlers(ginEngine *gin.Engine, mw *middleware.MiddlewareManager) {
+	factory := http_v1.NewControllerFactory(s.cfg, s.db, s.logger, s.redis, s.gateway)
+	ginEngine.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Everything but the health check, the metrics and the payment webhooks,
+	// which carry their own signature, requires an access token. Reads of the
+	// customer facing groups are rate limited per user, writes per route.
+	readLimit := mw.RateLimit(middleware.RateLimitReads)
 	healthCheckController := factory.NewHealthCheckController()
 	healthCheckGroup := ginEngine.Group("/health")
 	http_v1.MapHealthCheckRoutes(healthCheckGroup, healthCheckController)
 
 	bookingController := factory.NewBookingController()
-	bookingGroup := ginEngine.Group("/bookings")
-	http_v1.MapBookingRoutes(bookingGroup, bookingController)
+	bookingGroup := ginEngine.Group("/bookings", mw.Authenticate(), readLimit)
+	http_v1.MapBookingRoutes(bookingGroup, bookingController, mw)
+
+	paymentController := factory.NewPaymentController()
+	http_v1.MapPaymentRoutes(bookingGroup, paymentController, mw)
+
+	webhookGroup := ginEngine.Group("/webhooks")
+	http_v1.MapWebhookRoutes(webhookGroup, paymentController)
+
+	userGroup := ginEngine.Group("/users", mw.Authenticate(), readLimit)
+	http_v1.MapUserRoutes(userGroup, bookingController)
 
 	eventController := factory.NewEventController()
-	eventGroup := ginEngine.Group("/events")
-	http_v1.MapEventRoutes(eventGroup, eventController)
+	eventGroup := ginEngine.Group("/events", mw.Authenticate(), readLimit)
+	http_v1.MapEventRoutes(eventGroup, eventController, mw)
+
+	holdController := factory.NewHoldController()
+	http_v1.MapHoldRoutes(eventGroup, holdController, mw)
+
+	waitingRoomController := factory.NewWaitingRoomController()
+	http_v1.MapWaitingRoomRoutes(eventGroup, waitingRoomController)
+
+	promoCodeController := factory.NewPromoCodeController()
+	promoCodeGroup := ginEngine.Group("/promo-codes", mw.Authenticate(), mw.RequireRole(auth.RoleOrganizer, auth.RoleAdmin))
+	http_v1.MapPromoCodeRoutes(promoCodeGroup, promoCodeController)
+
+	adminController := factory.NewAdminController()
+	adminGroup := ginEngine.Group("/admin", mw.Authenticate(), mw.RequireRole(auth.RoleAdmin))
+	http_v1.MapAdminRoutes(adminGroup, adminController)
 }
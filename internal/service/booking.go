@@ -3,84 +3,179 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
 	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
 	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
 	"github.com/phamdinhha/event-booking-service/internal/model"
 	"github.com/phamdinhha/event-booking-service/internal/repository"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type BookingService struct {
+	cfg         *config.Config
 	bookingRepo repository.BookingRepositoryInterface
+	eventRepo   repository.EventRepositoryInterface
+	promoRepo   repository.PromoCodeRepositoryInterface
+	paymentSrv  PaymentServiceInterface
 	logger      logger.Logger
 	redis       *redis.Client
+	redsync     *redsync.Redsync
 }
 
 func NewBookingService(
+	cfg *config.Config,
 	bookingRepo repository.BookingRepositoryInterface,
+	eventRepo repository.EventRepositoryInterface,
+	promoRepo repository.PromoCodeRepositoryInterface,
+	paymentSrv PaymentServiceInterface,
 	logger logger.Logger,
 	redis *redis.Client,
 ) BookingServiceInterface {
 	return &BookingService{
+		cfg:         cfg,
 		bookingRepo: bookingRepo,
+		eventRepo:   eventRepo,
+		promoRepo:   promoRepo,
+		paymentSrv:  paymentSrv,
 		logger:      logger,
 		redis:       redis,
+		redsync:     redsync.New(goredis.NewPool(redis)),
 	}
 }
 
+// CreateBooking turns the hold of the user into a booking awaiting payment and
+// starts the payment. The booking is confirmed once the payment is captured.
 func (s *BookingService) CreateBooking(
 	ctx context.Context,
 	bookDTO *dto.CreateBookingDTO,
-) (*dto.BookingDTO, error) {
-	holdKey := fmt.Sprintf("hold:event:%s:user:%s", bookDTO.EventID.String(), bookDTO.UserID.String())
-	heldTickets, err := s.redis.Get(ctx, holdKey).Int()
+) (_ *dto.BookingDTO, err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.CreateBooking",
+		trace.WithAttributes(attribute.String("event.id", bookDTO.EventID.String())))
+	defer func() { tracing.End(span, err) }()
+	event, err := s.eventRepo.GetEventByID(ctx, bookDTO.EventID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get held tickets: %w", err)
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	// The booking repeats the limit check in Postgres, the lock keeps a
+	// concurrent hold of the user from slipping in between.
+	unlock, err := lockPurchases(ctx, s.redsync, event, bookDTO.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	promoCode := strings.TrimSpace(bookDTO.PromoCode)
+	if promoCode != "" {
+		// Reject a bad code before the hold is consumed so the user keeps it.
+		if err := s.checkHeldPromoCode(ctx, bookDTO, promoCode); err != nil {
+			return nil, err
+		}
+	}
+	heldItems, err := claimHold(ctx, s.redis, bookDTO.EventID, bookDTO.UserID, claimModeConsume)
+	if err != nil {
+		return nil, err
+	}
+	if len(heldItems) == 0 {
+		return nil, ErrHoldNotFound
+	}
+	// A code entered at checkout replaces the one entered with the hold.
+	heldPromoCode, err := s.redis.GetDel(ctx, holdPromoKey(bookDTO.EventID, bookDTO.UserID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		s.logger.WithContext(ctx).Error("BOOKING_SERVICE.CREATE_BOOKING.Error", err)
+	}
+	if promoCode == "" {
+		promoCode = heldPromoCode
 	}
 
+	now := time.Now()
 	booking := &model.Booking{
+		ID:        uuid.New(),
 		EventID:   bookDTO.EventID,
 		UserID:    bookDTO.UserID,
-		Quantity:  heldTickets,
-		Status:    "confirmed",
-		CreatedAt: time.Now(),
+		Quantity:  model.TotalQuantity(heldItems),
+		Status:    model.BookingStatusAwaitingPayment,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, item := range heldItems {
+		booking.Items = append(booking.Items, &model.BookingItem{LineItem: item})
+	}
+	if email := strings.TrimSpace(bookDTO.Email); email != "" {
+		booking.ContactEmail = &email
 	}
 
-	createdBooking, err := s.bookingRepo.CreateBooking(ctx, booking)
+	createdBooking, err := s.bookingRepo.CreateBooking(ctx, booking, model.FeeRate(s.cfg.Booking.FeeRate), promoCode)
 	if err != nil {
+		// The hold is already consumed, hand its tickets back to the pool.
+		if incrErr := creditAvailability(ctx, s.redis, bookDTO.EventID, heldItems); incrErr != nil {
+			s.logger.WithContext(ctx).Error("failed to return consumed hold", "error", incrErr)
+		}
+		if errors.Is(err, model.ErrNotEnoughTickets) {
+			metrics.SoldOutRejections.Inc()
+		}
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
-	s.cacheBooking(ctx, booking)
-	// Release the hold
-	s.redis.Del(ctx, holdKey)
-	return &dto.BookingDTO{
-		ID:        createdBooking.ID,
-		EventID:   createdBooking.EventID,
-		UserID:    createdBooking.UserID,
-		Quantity:  createdBooking.Quantity,
-		Status:    createdBooking.Status,
-		CreatedAt: createdBooking.CreatedAt,
-		UpdatedAt: createdBooking.UpdatedAt,
-	}, nil
-}
-
-func (s *BookingService) GetBooking(ctx context.Context, id uuid.UUID) (*dto.BookingDTO, error) {
+
+	paymentDTO, err := s.paymentSrv.CreatePayment(ctx, createdBooking, event)
+	if err != nil {
+		transition, cancelErr := s.bookingRepo.TransitionBooking(ctx,
+			createdBooking.ID, model.BookingStatusCancelled, "payment could not be started", time.Now())
+		if cancelErr != nil {
+			// Left awaiting_payment, the booking expires after the payment timeout.
+			s.logger.WithContext(ctx).Error("BOOKING_SERVICE.CREATE_BOOKING.Error", cancelErr)
+		} else {
+			s.afterTransition(ctx, transition)
+		}
+		return nil, err
+	}
+
+	s.cacheBooking(ctx, createdBooking)
+	bookingDTO := toBookingDTO(createdBooking)
+	bookingDTO.Payment = paymentDTO
+	return bookingDTO, nil
+}
+
+// checkHeldPromoCode checks a promo code entered at checkout against the hold
+// of the user.
+func (s *BookingService) checkHeldPromoCode(ctx context.Context, bookDTO *dto.CreateBookingDTO, promoCode string) error {
+	encoded, err := s.redis.Get(ctx, holdKey(bookDTO.EventID, bookDTO.UserID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrHoldNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get held tickets: %w", err)
+	}
+	items, err := decodeLineItems(encoded)
+	if err != nil {
+		return fmt.Errorf("failed to get held tickets: %w", err)
+	}
+	_, err = checkPromoCode(ctx, s.promoRepo, bookDTO.EventID, bookDTO.UserID, model.TotalQuantity(items), promoCode)
+	return err
+}
+
+func (s *BookingService) GetBooking(ctx context.Context, id uuid.UUID) (_ *dto.BookingDTO, err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.GetBooking")
+	defer func() { tracing.End(span, err) }()
 	// Try to get from cache first
 	cachedBooking, err := s.getCachedBooking(ctx, id)
 	if err == nil {
-		return &dto.BookingDTO{
-			ID:        cachedBooking.ID,
-			EventID:   cachedBooking.EventID,
-			UserID:    cachedBooking.UserID,
-			Quantity:  cachedBooking.Quantity,
-			Status:    cachedBooking.Status,
-			CreatedAt: cachedBooking.CreatedAt,
-			UpdatedAt: cachedBooking.UpdatedAt,
-		}, nil
+		if err := auth.CheckOwner(ctx, cachedBooking.UserID); err != nil {
+			return nil, err
+		}
+		return toBookingDTO(cachedBooking), nil
 	}
 	// If not in cache, get from database
 	booking, err := s.bookingRepo.GetBookingByID(ctx, id)
@@ -89,27 +184,96 @@ func (s *BookingService) GetBooking(ctx context.Context, id uuid.UUID) (*dto.Boo
 	}
 	// Cache the booking for future requests
 	s.cacheBooking(ctx, booking)
+	if err := auth.CheckOwner(ctx, booking.UserID); err != nil {
+		return nil, err
+	}
 
-	return &dto.BookingDTO{
-		ID:        booking.ID,
-		EventID:   booking.EventID,
-		UserID:    booking.UserID,
-		Quantity:  booking.Quantity,
-		Status:    booking.Status,
-		CreatedAt: booking.CreatedAt,
-		UpdatedAt: booking.UpdatedAt,
-	}, nil
+	return toBookingDTO(booking), nil
 }
 
-func (s *BookingService) UpdateBooking(ctx context.Context, booking *model.Booking) error {
-	if err := s.bookingRepo.UpdateBooking(ctx, booking); err != nil {
-		return fmt.Errorf("failed to update booking: %w", err)
+// CancelBooking cancels the booking and gives its tickets back to the event,
+// unless the event starts within the configured cancellation cutoff. A paid
+// booking is refunded through the payment gateway, when the refund fails it
+// stays refund_pending.
+func (s *BookingService) CancelBooking(
+	ctx context.Context,
+	id uuid.UUID,
+	cancelDTO *dto.CancelBookingDTO,
+) (_ *dto.BookingDTO, err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.CancelBooking")
+	defer func() { tracing.End(span, err) }()
+	booking, err := s.bookingRepo.GetBookingByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
 	}
-	s.cacheBooking(ctx, booking)
-	return nil
+	if err := auth.CheckOwner(ctx, booking.UserID); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetEventByID(ctx, booking.EventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	now := time.Now()
+	if now.After(event.StartTime.Add(-s.cfg.Booking.CancellationCutoff)) {
+		return nil, model.ErrCancellationWindowClosed
+	}
+
+	transition, err := s.bookingRepo.CancelBooking(ctx, id, cancelDTO.Reason, now)
+	if err != nil {
+		return nil, err
+	}
+	s.afterTransition(ctx, transition)
+	if transition.Booking.Status != model.BookingStatusRefundPending {
+		return toBookingDTO(transition.Booking), nil
+	}
+	refunded, err := s.paymentSrv.RefundPayment(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("BOOKING_SERVICE.CANCEL_BOOKING.Error", err)
+		return toBookingDTO(transition.Booking), nil
+	}
+	return refunded, nil
 }
 
-func (s *BookingService) DeleteBooking(ctx context.Context, id uuid.UUID) error {
+func (s *BookingService) GetBookingHistory(
+	ctx context.Context,
+	id uuid.UUID,
+) (_ []*model.BookingStatusChange, err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.GetBookingHistory")
+	defer func() { tracing.End(span, err) }()
+	booking, err := s.bookingRepo.GetBookingByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if err := auth.CheckOwner(ctx, booking.UserID); err != nil {
+		return nil, err
+	}
+	history, err := s.bookingRepo.GetBookingHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// afterTransition syncs Redis with a status change committed in Postgres.
+func (s *BookingService) afterTransition(ctx context.Context, transition *model.BookingTransition) {
+	afterBookingTransition(ctx, s.redis, s.logger, transition)
+}
+
+// DeleteBooking purges a finished booking together with its history and
+// payments. It is an admin operation, customers cancel their bookings.
+func (s *BookingService) DeleteBooking(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.DeleteBooking")
+	defer func() { tracing.End(span, err) }()
+	if err := auth.CheckRole(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+	booking, err := s.bookingRepo.GetBookingByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get booking: %w", err)
+	}
+	if !booking.Status.Purgeable() {
+		return model.ErrBookingNotPurgeable
+	}
 	if err := s.bookingRepo.DeleteBooking(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete booking: %w", err)
 	}
@@ -117,7 +281,9 @@ func (s *BookingService) DeleteBooking(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
-func (s *BookingService) ListBookings(ctx context.Context, limit, offset int) ([]*model.Booking, error) {
+func (s *BookingService) ListBookings(ctx context.Context, limit, offset int) (_ []*model.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.ListBookings")
+	defer func() { tracing.End(span, err) }()
 	bookings, err := s.bookingRepo.ListBookings(ctx, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list bookings: %w", err)
@@ -125,20 +291,59 @@ func (s *BookingService) ListBookings(ctx context.Context, limit, offset int) ([
 	return bookings, nil
 }
 
+func (s *BookingService) ListUserBookings(
+	ctx context.Context,
+	filter *dto.BookingFilterDTO,
+	pagination *utils.Pagination,
+) (_ *dto.BookingListDTO, err error) {
+	ctx, span := tracing.Start(ctx, "BookingService.ListUserBookings")
+	defer func() { tracing.End(span, err) }()
+	if err := auth.CheckOwner(ctx, filter.UserID); err != nil {
+		return nil, err
+	}
+	bookings, total, err := s.bookingRepo.ListUserBookings(ctx, &repository.BookingFilter{
+		UserID:  filter.UserID,
+		EventID: filter.EventID,
+		Status:  model.BookingStatus(filter.Status),
+		From:    filter.From,
+		To:      filter.To,
+	}, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &dto.BookingListDTO{
+		Bookings:   make([]*dto.UserBookingDTO, 0, len(bookings)),
+		TotalCount: total,
+		TotalPages: pagination.GetTotalPages(total),
+		Page:       pagination.GetPage(),
+		Size:       pagination.GetSize(),
+		HasMore:    pagination.GetHasMore(total),
+	}
+	for _, booking := range bookings {
+		list.Bookings = append(list.Bookings, &dto.UserBookingDTO{
+			BookingDTO:     *toBookingDTO(&booking.Booking),
+			EventTitle:     booking.EventTitle,
+			EventStartTime: booking.EventStartTime,
+		})
+	}
+	return list, nil
+}
+
 func (s *BookingService) cacheBooking(ctx context.Context, booking *model.Booking) {
 	bookingJSON, err := json.Marshal(booking)
 	if err != nil {
-		s.logger.Error("failed to marshal booking for caching", "error", err)
+		s.logger.WithContext(ctx).Error("failed to marshal booking for caching", "error", err)
 		return
 	}
-	err = s.redis.Set(ctx, fmt.Sprintf("booking:%s", booking.ID), bookingJSON, time.Hour).Err()
+	err = s.redis.Set(ctx, bookingCacheKey(booking.ID), bookingJSON, time.Hour).Err()
 	if err != nil {
-		s.logger.Error("failed to cache booking", "error", err)
+		s.logger.WithContext(ctx).Error("failed to cache booking", "error", err)
 	}
 }
 
 func (s *BookingService) getCachedBooking(ctx context.Context, id uuid.UUID) (*model.Booking, error) {
-	bookingJSON, err := s.redis.Get(ctx, fmt.Sprintf("booking:%s", id)).Result()
+	bookingJSON, err := s.redis.Get(ctx, bookingCacheKey(id)).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -153,8 +358,67 @@ func (s *BookingService) getCachedBooking(ctx context.Context, id uuid.UUID) (*m
 }
 
 func (s *BookingService) invalidateCache(ctx context.Context, id uuid.UUID) {
-	err := s.redis.Del(ctx, fmt.Sprintf("booking:%s", id)).Err()
+	err := s.redis.Del(ctx, bookingCacheKey(id)).Err()
 	if err != nil {
-		s.logger.Error("failed to invalidate booking cache", "error", err)
+		s.logger.WithContext(ctx).Error("failed to invalidate booking cache", "error", err)
+	}
+}
+
+// afterBookingTransition counts confirmed and cancelled bookings, gives
+// released tickets back to the availability counter and drops the cached
+// booking.
+func afterBookingTransition(
+	ctx context.Context,
+	client *redis.Client,
+	logger logger.Logger,
+	transition *model.BookingTransition,
+) {
+	booking := transition.Booking
+	switch booking.Status {
+	case model.BookingStatusConfirmed:
+		metrics.BookingsConfirmed.Inc()
+	case model.BookingStatusCancelled, model.BookingStatusRefundPending:
+		metrics.BookingsCancelled.Inc()
+	}
+	if transition.ReleasedTickets > 0 {
+		if err := creditAvailability(ctx, client, booking.EventID, booking.LineItems()); err != nil {
+			// The reconciler repairs the counter from Postgres.
+			logger.WithContext(ctx).Error("BOOKING_SERVICE.AFTER_TRANSITION.Error", err)
+		}
+	}
+	if err := client.Del(ctx, bookingCacheKey(booking.ID)).Err(); err != nil {
+		logger.WithContext(ctx).Error("failed to invalidate booking cache", "error", err)
+	}
+}
+
+func bookingCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("booking:%s", id)
+}
+
+func toBookingDTO(booking *model.Booking) *dto.BookingDTO {
+	bookingDTO := &dto.BookingDTO{
+		ID:                 booking.ID,
+		EventID:            booking.EventID,
+		UserID:             booking.UserID,
+		Quantity:           booking.Quantity,
+		Status:             string(booking.Status),
+		CreatedAt:          booking.CreatedAt,
+		UpdatedAt:          booking.UpdatedAt,
+		CancellationReason: booking.CancellationReason,
+		CancelledAt:        booking.CancelledAt,
+		ContactEmail:       booking.ContactEmail,
+		PromoCodeID:        booking.PromoCodeID,
+		Subtotal:           toMoneyDTO(booking.Subtotal),
+		Discount:           toMoneyDTO(booking.Discount),
+		Fees:               toMoneyDTO(booking.Fees),
+		Total:              toMoneyDTO(booking.Total),
+	}
+	for _, item := range booking.Items {
+		bookingDTO.Items = append(bookingDTO.Items, &dto.BookingItemDTO{
+			TicketTypeID: item.TicketTypeID,
+			Quantity:     item.Quantity,
+			UnitPrice:    toMoneyDTO(item.UnitPrice),
+		})
 	}
+	return bookingDTO
 }
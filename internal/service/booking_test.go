@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+)
+
+func TestCancelBookingReleasesTickets(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx, booking := f.book(t, 3)
+	if got := f.available(t); got != 7 {
+		t.Fatalf("available after checkout = %d, want 7", got)
+	}
+
+	cancelled, err := f.bookings.CancelBooking(ctx, booking.ID, &dto.CancelBookingDTO{Reason: "changed plans"})
+	if err != nil {
+		t.Fatalf("CancelBooking() = %v", err)
+	}
+	if cancelled.Status != string(model.BookingStatusCancelled) {
+		t.Fatalf("booking status = %s, want %s", cancelled.Status, model.BookingStatusCancelled)
+	}
+	if cancelled.CancellationReason == nil || *cancelled.CancellationReason != "changed plans" || cancelled.CancelledAt == nil {
+		t.Fatalf("booking = %+v, want the reason and time of the cancellation", cancelled)
+	}
+	if got := f.available(t); got != 10 {
+		t.Fatalf("available after cancel = %d, want 10", got)
+	}
+	event, err := f.repo.GetEventByID(ctx, f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.AvailableTickets != 10 || event.TicketTypes[0].AvailableTickets != 10 {
+		t.Fatalf("event has %d available tickets, want 10", event.AvailableTickets)
+	}
+
+	// The tickets were given back once, a second cancel is refused.
+	_, err = f.bookings.CancelBooking(ctx, booking.ID, &dto.CancelBookingDTO{})
+	if !errors.As(err, new(*model.ErrInvalidTransition)) {
+		t.Fatalf("second CancelBooking() = %v, want *model.ErrInvalidTransition", err)
+	}
+	if got := f.available(t); got != 10 {
+		t.Fatalf("available after second cancel = %d, want 10", got)
+	}
+}
+
+func TestCancelBookingChecks(t *testing.T) {
+	t.Run("another user", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		_, booking := f.book(t, 1)
+		other := customerContext(uuid.New())
+		if _, err := f.bookings.CancelBooking(other, booking.ID, &dto.CancelBookingDTO{}); !errors.Is(err, auth.ErrNotOwner) {
+			t.Fatalf("CancelBooking() = %v, want %v", err, auth.ErrNotOwner)
+		}
+		f.assertStatus(t, booking.ID, model.BookingStatusAwaitingPayment, model.PaymentStatusPending)
+	})
+	t.Run("admin", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		_, booking := f.book(t, 1)
+		if _, err := f.bookings.CancelBooking(adminContext(), booking.ID, &dto.CancelBookingDTO{}); err != nil {
+			t.Fatalf("CancelBooking() = %v", err)
+		}
+	})
+	t.Run("within the cutoff", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		ctx, booking := f.book(t, 1)
+		f.cfg.Booking.CancellationCutoff = 48 * time.Hour
+		if _, err := f.bookings.CancelBooking(ctx, booking.ID, &dto.CancelBookingDTO{}); !errors.Is(err, model.ErrCancellationWindowClosed) {
+			t.Fatalf("CancelBooking() = %v, want %v", err, model.ErrCancellationWindowClosed)
+		}
+		f.assertStatus(t, booking.ID, model.BookingStatusAwaitingPayment, model.PaymentStatusPending)
+		if got := f.available(t); got != 9 {
+			t.Fatalf("available after a refused cancel = %d, want 9", got)
+		}
+	})
+}
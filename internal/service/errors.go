@@ -0,0 +1,15 @@
+package service
+
+import "errors"
+
+var (
+	ErrHoldNotFound      = errors.New("hold not found")
+	ErrAlreadyHolding    = errors.New("user already holds tickets for this event")
+	ErrUnknownHoldResult = errors.New("unknown hold result")
+	ErrWebhookInProgress = errors.New("webhook event is already being processed")
+
+	ErrWaitingRoomDisabled = errors.New("event has no waiting room")
+	ErrQueueTokenNotFound  = errors.New("queue token not found or expired")
+	ErrAdmissionRequired   = errors.New("event requires an admission token from its waiting room")
+	ErrInvalidAdmission    = errors.New("admission token is invalid or expired")
+)
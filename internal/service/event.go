@@ -2,13 +2,20 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
 	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
 	"github.com/phamdinhha/event-booking-service/internal/model"
 	"github.com/phamdinhha/event-booking-service/internal/repository"
 	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -33,64 +40,564 @@ func NewEventService(
 func (s *EventService) CreateEvent(
 	ctx context.Context,
 	eventDTO *dto.CreateEventDTO,
-) (*dto.EventDTO, error) {
+) (_ *dto.EventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CreateEvent")
+	defer func() { tracing.End(span, err) }()
+	if err := auth.CheckOrganizer(ctx, eventDTO.OrganizerId); err != nil {
+		return nil, err
+	}
+	now := time.Now()
 	event := &model.Event{
-		ID:               uuid.New(),
-		Title:            eventDTO.Title,
-		Description:      eventDTO.Description,
-		StartTime:        eventDTO.StartTime,
-		EndTime:          eventDTO.EndTime,
-		Location:         eventDTO.Location,
-		Capacity:         eventDTO.Capacity,
-		Price:            eventDTO.Price,
-		OrganizerId:      eventDTO.OrganizerId,
-		CategoryId:       eventDTO.CategoryId,
-		Status:           eventDTO.Status,
-		AvailableTickets: eventDTO.AvailableTickets,
-		CreatedAt:        time.Now(),
+		ID:          uuid.New(),
+		Title:       eventDTO.Title,
+		Description: eventDTO.Description,
+		StartTime:   eventDTO.StartTime,
+		EndTime:     eventDTO.EndTime,
+		Location:    eventDTO.Location,
+		OrganizerId: eventDTO.OrganizerId,
+		CategoryId:  eventDTO.CategoryId,
+		Status:      model.EventStatus(eventDTO.Status),
+		WaitingRoom: eventDTO.WaitingRoom,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+
+		MaxTicketsPerUser:  eventDTO.MaxTicketsPerUser,
+		MaxTicketsPerOrder: eventDTO.MaxTicketsPerOrder,
+	}
+
+	if event.Status == "" {
+		event.Status = model.EventStatusDraft
+	}
+	if err := model.EventStatus("").TransitionTo(event.Status); err != nil {
+		return nil, err
+	}
+
+	ticketTypeDTOs := eventDTO.TicketTypes
+	if len(ticketTypeDTOs) == 0 {
+		available := &eventDTO.AvailableTickets
+		if eventDTO.AvailableTickets == 0 {
+			available = nil
+		}
+		ticketTypeDTOs = []dto.CreateTicketTypeDTO{{
+			Name:             model.DefaultTicketTypeName,
+			Price:            *eventDTO.Price,
+			Capacity:         eventDTO.Capacity,
+			AvailableTickets: available,
+		}}
+	}
+	// The event totals are derived from its ticket types and listed at the
+	// price of the cheapest one.
+	for i := range ticketTypeDTOs {
+		ticketType := newTicketType(event.ID, &ticketTypeDTOs[i], now)
+		if err := ticketType.Validate(); err != nil {
+			return nil, err
+		}
+		switch {
+		case i == 0:
+			event.Price = ticketType.Price
+		case ticketType.Price.Currency != event.Price.Currency:
+			return nil, model.ErrCurrencyMismatch
+		case ticketType.Price.Less(event.Price):
+			event.Price = ticketType.Price
+		}
+		event.Capacity += ticketType.Capacity
+		event.AvailableTickets += ticketType.AvailableTickets
+		event.TicketTypes = append(event.TicketTypes, ticketType)
 	}
 
 	if err := s.eventRepo.CreateEvent(ctx, event); err != nil {
 		return nil, err
 	}
+	s.syncWaitingRoom(ctx, event)
+	if event.Status.OnSale() {
+		s.seedAvailability(ctx, event)
+	}
 
-	return &dto.EventDTO{
-		ID:          event.ID,
-		Title:       event.Title,
-		Description: event.Description,
-		StartTime:   event.StartTime,
-		EndTime:     event.EndTime,
-		Location:    event.Location,
-		Capacity:    event.Capacity,
-		Price:       event.Price,
-		OrganizerId: event.OrganizerId,
-		CategoryId:  event.CategoryId,
-		Status:      event.Status,
-	}, nil
+	return toEventDTO(event), nil
+}
+
+// PublishEvent opens the event for sales and seeds its availability counter.
+func (s *EventService) PublishEvent(ctx context.Context, id uuid.UUID) (_ *dto.EventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.PublishEvent")
+	defer func() { tracing.End(span, err) }()
+	if err := s.authorizeEvent(ctx, id); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.TransitionEvent(ctx, id, model.EventStatusPublished, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.syncWaitingRoom(ctx, event)
+	if event.TicketTypes, err = s.eventRepo.ListTicketTypes(ctx, id); err != nil {
+		// Not fatal, the counters are rebuilt lazily on the first hold.
+		s.logger.WithContext(ctx).Error("EVENT_SERVICE.PUBLISH_EVENT.Error", err)
+	}
+	s.seedAvailability(ctx, event)
+	return toEventDTO(event), nil
+}
+
+// CancelEvent cancels the event, closes it for sales and cascades the
+// cancellation to its bookings. Paid bookings are left refund_pending.
+func (s *EventService) CancelEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	cancelDTO *dto.CancelEventDTO,
+) (_ *dto.EventCancellationDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CancelEvent")
+	defer func() { tracing.End(span, err) }()
+	if err := s.authorizeEvent(ctx, id); err != nil {
+		return nil, err
+	}
+	cancellation, err := s.eventRepo.CancelEvent(ctx, id, cancelDTO.Reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.closeSales(ctx, id)
+
+	result := &dto.EventCancellationDTO{Event: toEventDTO(cancellation.Event)}
+	keys := make([]string, 0, len(cancellation.Bookings))
+	for _, transition := range cancellation.Bookings {
+		if transition.Booking.Status == model.BookingStatusRefundPending {
+			result.RefundPendingBookings++
+		} else {
+			result.CancelledBookings++
+		}
+		metrics.BookingsCancelled.Inc()
+		keys = append(keys, bookingCacheKey(transition.Booking.ID))
+	}
+	if len(keys) > 0 {
+		if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+			s.logger.WithContext(ctx).Error("EVENT_SERVICE.CANCEL_EVENT.Error", err)
+		}
+	}
+	return result, nil
+}
+
+// CompleteEvent marks a published event as completed and closes it for sales.
+func (s *EventService) CompleteEvent(ctx context.Context, id uuid.UUID) (_ *dto.EventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CompleteEvent")
+	defer func() { tracing.End(span, err) }()
+	if err := s.authorizeEvent(ctx, id); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.TransitionEvent(ctx, id, model.EventStatusCompleted, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.closeSales(ctx, id)
+	return toEventDTO(event), nil
+}
+
+// CompleteEndedEvents completes every published event that has ended and
+// returns how many were completed.
+func (s *EventService) CompleteEndedEvents(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CompleteEndedEvents")
+	defer func() { tracing.End(span, err) }()
+	ids, err := s.eventRepo.CompleteEndedEvents(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		s.closeSales(ctx, id)
+	}
+	return len(ids), nil
+}
+
+func (s *EventService) seedAvailability(ctx context.Context, event *model.Event) {
+	if _, err := syncAvailability(ctx, s.redis, event, inventoryModeSeed); err != nil {
+		// Not fatal, the counter is rebuilt lazily on the first hold.
+		s.logger.WithContext(ctx).Error("EVENT_SERVICE.SEED_AVAILABILITY.Error", err)
+	}
+}
+
+func (s *EventService) closeSales(ctx context.Context, id uuid.UUID) {
+	if err := dropAvailability(ctx, s.redis, id); err != nil {
+		// Bookings are still refused by Postgres, holds fail at checkout.
+		s.logger.WithContext(ctx).Error("EVENT_SERVICE.CLOSE_SALES.Error", err)
+	}
+	if err := closeWaitingRoom(ctx, s.redis, id); err != nil {
+		// The admitter drops waiting rooms of closed events on its next run.
+		s.logger.WithContext(ctx).Error("EVENT_SERVICE.CLOSE_SALES.Error", err)
+	}
+}
+
+func (s *EventService) syncWaitingRoom(ctx context.Context, event *model.Event) {
+	if err := syncWaitingRoom(ctx, s.redis, event); err != nil {
+		// The admitter resyncs waiting rooms from Postgres on its next run.
+		s.logger.WithContext(ctx).Error("EVENT_SERVICE.SYNC_WAITING_ROOM.Error", err)
+	}
 }
 
 func (s *EventService) GetEventByID(
 	ctx context.Context,
 	id uuid.UUID,
-) (*dto.EventDTO, error) {
+) (_ *dto.EventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEventByID")
+	defer func() { tracing.End(span, err) }()
+	event, err := s.getVisibleEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	eventDTO := toEventDTO(event)
+	eventDTO.TicketTypes = s.toTicketTypeDTOs(ctx, event)
+	return eventDTO, nil
+}
+
+// UpdateEvent applies a partial update to a draft or published event. Capacity
+// and price are changed through the ticket types of the event.
+func (s *EventService) UpdateEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	eventDTO *dto.UpdateEventDTO,
+) (_ *dto.EventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.UpdateEvent")
+	defer func() { tracing.End(span, err) }()
 	event, err := s.eventRepo.GetEventByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return &dto.EventDTO{
-		ID:          event.ID,
-		Title:       event.Title,
-		Description: event.Description,
-		StartTime:   event.StartTime,
-		EndTime:     event.EndTime,
-		Location:    event.Location,
-		Capacity:    event.Capacity,
-	}, nil
+	if err := auth.CheckOrganizer(ctx, event.OrganizerId); err != nil {
+		return nil, err
+	}
+	if !event.Status.Editable() {
+		return nil, model.ErrEventNotEditable
+	}
+	if event.Version != eventDTO.Version {
+		return nil, model.ErrVersionConflict
+	}
+
+	if eventDTO.Title != nil {
+		event.Title = *eventDTO.Title
+	}
+	if eventDTO.Description != nil {
+		event.Description = *eventDTO.Description
+	}
+	if eventDTO.StartTime != nil {
+		event.StartTime = *eventDTO.StartTime
+	}
+	if eventDTO.EndTime != nil {
+		event.EndTime = *eventDTO.EndTime
+	}
+	if eventDTO.Location != nil {
+		event.Location = *eventDTO.Location
+	}
+	if eventDTO.CategoryId != nil {
+		event.CategoryId = *eventDTO.CategoryId
+	}
+	if eventDTO.WaitingRoom != nil {
+		event.WaitingRoom = *eventDTO.WaitingRoom
+	}
+	if eventDTO.MaxTicketsPerUser != nil {
+		event.MaxTicketsPerUser = *eventDTO.MaxTicketsPerUser
+	}
+	if eventDTO.MaxTicketsPerOrder != nil {
+		event.MaxTicketsPerOrder = *eventDTO.MaxTicketsPerOrder
+	}
+	if !event.EndTime.After(event.StartTime) {
+		return nil, model.ErrInvalidEventTime
+	}
+	event.UpdatedAt = time.Now()
+
+	if err := s.eventRepo.UpdateEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	s.syncWaitingRoom(ctx, event)
+
+	return toEventDTO(event), nil
 }
 
+// CreateTicketType adds a ticket type to a draft or published event. Tickets
+// of a published event go on sale right away.
+func (s *EventService) CreateTicketType(
+	ctx context.Context,
+	eventID uuid.UUID,
+	ticketTypeDTO *dto.CreateTicketTypeDTO,
+) (_ *dto.TicketTypeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CreateTicketType")
+	defer func() { tracing.End(span, err) }()
+	if err := s.authorizeEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+	ticketType := newTicketType(eventID, ticketTypeDTO, time.Now())
+	if err := ticketType.Validate(); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.CreateTicketType(ctx, ticketType)
+	if err != nil {
+		return nil, err
+	}
+	event.TicketTypes = []*model.TicketType{ticketType}
+	if event.Status.OnSale() {
+		s.seedAvailability(ctx, event)
+	}
+	return s.toTicketTypeDTOs(ctx, event)[0], nil
+}
+
+// ListTicketTypes returns the ticket types of the event with their live
+// availability.
+func (s *EventService) ListTicketTypes(ctx context.Context, eventID uuid.UUID) (_ []*dto.TicketTypeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.ListTicketTypes")
+	defer func() { tracing.End(span, err) }()
+	event, err := s.getVisibleEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toTicketTypeDTOs(ctx, event), nil
+}
+
+// UpdateTicketType applies a partial update to a ticket type. A capacity
+// change moves its available tickets by the same delta in Postgres and Redis
+// and is refused when it would drop below the tickets already sold or held.
+func (s *EventService) UpdateTicketType(
+	ctx context.Context,
+	eventID, id uuid.UUID,
+	ticketTypeDTO *dto.UpdateTicketTypeDTO,
+) (_ *dto.TicketTypeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.UpdateTicketType")
+	defer func() { tracing.End(span, err) }()
+	event, err := s.eventRepo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckOrganizer(ctx, event.OrganizerId); err != nil {
+		return nil, err
+	}
+	if !event.Status.Editable() {
+		return nil, model.ErrEventNotEditable
+	}
+	ticketType := event.TicketType(id)
+	if ticketType == nil {
+		return nil, fmt.Errorf("ticket type not found: %w", sql.ErrNoRows)
+	}
+
+	capacity := ticketType.Capacity
+	if ticketTypeDTO.Name != nil {
+		ticketType.Name = *ticketTypeDTO.Name
+	}
+	if ticketTypeDTO.Price != nil {
+		price := toMoney(*ticketTypeDTO.Price)
+		if price.Currency != ticketType.Price.Currency {
+			return nil, model.ErrCurrencyMismatch
+		}
+		ticketType.Price = price
+	}
+	if ticketTypeDTO.Capacity != nil {
+		ticketType.Capacity = *ticketTypeDTO.Capacity
+	}
+	if ticketTypeDTO.SaleStartsAt != nil {
+		ticketType.SaleStartsAt = ticketTypeDTO.SaleStartsAt
+	}
+	if ticketTypeDTO.SaleEndsAt != nil {
+		ticketType.SaleEndsAt = ticketTypeDTO.SaleEndsAt
+	}
+	if ticketTypeDTO.MaxTicketsPerUser != nil {
+		ticketType.MaxTicketsPerUser = *ticketTypeDTO.MaxTicketsPerUser
+	}
+	ticketType.UpdatedAt = time.Now()
+
+	delta := ticketType.Capacity - capacity
+	updated := *ticketType
+	updated.AvailableTickets += delta
+	if err := updated.Validate(); err != nil {
+		return nil, err
+	}
+
+	adjusted := false
+	if delta != 0 {
+		if adjusted, err = adjustAvailability(ctx, s.redis, ticketType, delta); err != nil {
+			return nil, err
+		}
+	}
+	event, err = s.eventRepo.UpdateTicketType(ctx, ticketType, delta)
+	if err != nil {
+		if adjusted {
+			undoErr := s.redis.HIncrBy(ctx, availableKey(eventID), id.String(), int64(-delta)).Err()
+			if undoErr != nil {
+				// The reconciler repairs the counter from Postgres.
+				s.logger.WithContext(ctx).Error("EVENT_SERVICE.UPDATE_TICKET_TYPE.Error", undoErr)
+			}
+		}
+		return nil, err
+	}
+	event.TicketTypes = []*model.TicketType{ticketType}
+	if event.Status.OnSale() {
+		s.seedAvailability(ctx, event)
+	}
+	return s.toTicketTypeDTOs(ctx, event)[0], nil
+}
+
+func (s *EventService) ListEvents(
+	ctx context.Context,
+	filter *dto.EventFilterDTO,
+	pagination *utils.Pagination,
+) (_ *dto.EventListDTO, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.ListEvents")
+	defer func() { tracing.End(span, err) }()
+	eventFilter := &repository.EventFilter{
+		CategoryID:  filter.CategoryID,
+		OrganizerID: filter.OrganizerID,
+		Status:      filter.Status,
+		StartFrom:   filter.StartFrom,
+		StartTo:     filter.StartTo,
+		Currency:    filter.Currency,
+		PriceMin:    filter.PriceMin,
+		PriceMax:    filter.PriceMax,
+		Location:    filter.Location,
+		Query:       filter.Query,
+	}
+	// Drafts are listed to admins and to the organizer who owns them.
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		switch {
+		case identity.HasRole(auth.RoleAdmin):
+			eventFilter.AllDrafts = true
+		case identity.HasRole(auth.RoleOrganizer):
+			eventFilter.DraftsOf = identity.UserID
+		}
+	}
+	events, total, err := s.eventRepo.ListEvents(ctx, eventFilter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &dto.EventListDTO{
+		Events:     make([]*dto.EventDTO, 0, len(events)),
+		TotalCount: total,
+		TotalPages: pagination.GetTotalPages(total),
+		Page:       pagination.GetPage(),
+		Size:       pagination.GetSize(),
+		HasMore:    pagination.GetHasMore(total),
+	}
+	for _, event := range events {
+		list.Events = append(list.Events, toEventDTO(event))
+	}
+	return list, nil
+}
+
+// DeleteEvent deletes a draft event together with whatever it left in Redis.
+// Published events are cancelled instead.
 func (s *EventService) DeleteEvent(
 	ctx context.Context,
 	id uuid.UUID,
-) error {
-	return s.eventRepo.DeleteEvent(ctx, id)
+) (err error) {
+	ctx, span := tracing.Start(ctx, "EventService.DeleteEvent")
+	defer func() { tracing.End(span, err) }()
+	if err := s.authorizeEvent(ctx, id); err != nil {
+		return err
+	}
+	if err := s.eventRepo.DeleteEvent(ctx, id); err != nil {
+		return err
+	}
+	s.closeSales(ctx, id)
+	if err := dropHolds(ctx, s.redis, id); err != nil {
+		// The reaper removes leftover deadlines, their counters are gone so
+		// nothing is credited.
+		s.logger.WithContext(ctx).Error("EVENT_SERVICE.DELETE_EVENT.Error", err)
+	}
+	return nil
+}
+
+// authorizeEvent allows the caller to manage the event, see
+// auth.CheckOrganizer.
+// getVisibleEvent loads an event for reading. A draft is only shown to its
+// organizer and to admins, everyone else gets a not found.
+func (s *EventService) getVisibleEvent(ctx context.Context, id uuid.UUID) (*model.Event, error) {
+	event, err := s.eventRepo.GetEventByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status == model.EventStatusDraft && auth.CheckOrganizer(ctx, event.OrganizerId) != nil {
+		return nil, fmt.Errorf("event not found: %w", sql.ErrNoRows)
+	}
+	return event, nil
+}
+
+func (s *EventService) authorizeEvent(ctx context.Context, id uuid.UUID) error {
+	event, err := s.eventRepo.GetEventByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return auth.CheckOrganizer(ctx, event.OrganizerId)
+}
+
+// toTicketTypeDTOs maps the loaded ticket types of the event with their live
+// availability, falling back to Postgres when the counters are not seeded.
+func (s *EventService) toTicketTypeDTOs(ctx context.Context, event *model.Event) []*dto.TicketTypeDTO {
+	counters := map[string]string{}
+	if event.Status.OnSale() {
+		var err error
+		if counters, err = s.redis.HGetAll(ctx, availableKey(event.ID)).Result(); err != nil {
+			s.logger.WithContext(ctx).Error("EVENT_SERVICE.TICKET_TYPES.Error", err)
+		}
+	}
+
+	now := time.Now()
+	ticketTypes := make([]*dto.TicketTypeDTO, 0, len(event.TicketTypes))
+	for _, ticketType := range event.TicketTypes {
+		available := ticketType.AvailableTickets
+		if counter, ok := counters[ticketType.ID.String()]; ok {
+			if value, err := strconv.Atoi(counter); err == nil {
+				available = value
+			}
+		}
+		ticketTypes = append(ticketTypes, &dto.TicketTypeDTO{
+			ID:                ticketType.ID,
+			EventID:           ticketType.EventID,
+			Name:              ticketType.Name,
+			Price:             toMoneyDTO(ticketType.Price),
+			Capacity:          ticketType.Capacity,
+			AvailableTickets:  available,
+			SaleStartsAt:      ticketType.SaleStartsAt,
+			SaleEndsAt:        ticketType.SaleEndsAt,
+			MaxTicketsPerUser: ticketType.MaxTicketsPerUser,
+			OnSale:            event.Status.OnSale() && ticketType.OnSaleAt(now),
+			CreatedAt:         ticketType.CreatedAt,
+			UpdatedAt:         ticketType.UpdatedAt,
+		})
+	}
+	return ticketTypes
+}
+
+func newTicketType(eventID uuid.UUID, ticketTypeDTO *dto.CreateTicketTypeDTO, now time.Time) *model.TicketType {
+	available := ticketTypeDTO.Capacity
+	if ticketTypeDTO.AvailableTickets != nil {
+		available = *ticketTypeDTO.AvailableTickets
+	}
+	return &model.TicketType{
+		ID:                uuid.New(),
+		EventID:           eventID,
+		Name:              ticketTypeDTO.Name,
+		Price:             toMoney(ticketTypeDTO.Price),
+		Capacity:          ticketTypeDTO.Capacity,
+		AvailableTickets:  available,
+		SaleStartsAt:      ticketTypeDTO.SaleStartsAt,
+		SaleEndsAt:        ticketTypeDTO.SaleEndsAt,
+		MaxTicketsPerUser: ticketTypeDTO.MaxTicketsPerUser,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+func toEventDTO(event *model.Event) *dto.EventDTO {
+	return &dto.EventDTO{
+		ID:               event.ID,
+		Title:            event.Title,
+		Description:      event.Description,
+		StartTime:        event.StartTime,
+		EndTime:          event.EndTime,
+		Location:         event.Location,
+		Capacity:         event.Capacity,
+		Price:            toMoneyDTO(event.Price),
+		OrganizerId:      event.OrganizerId,
+		CategoryId:       event.CategoryId,
+		Status:           string(event.Status),
+		AvailableTickets: event.AvailableTickets,
+		WaitingRoom:      event.WaitingRoom,
+		Version:          event.Version,
+		CreatedAt:        event.CreatedAt,
+		UpdatedAt:        event.UpdatedAt,
+
+		MaxTicketsPerUser:  event.MaxTicketsPerUser,
+		MaxTicketsPerOrder: event.MaxTicketsPerOrder,
+	}
 }
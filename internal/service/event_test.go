@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func adminContext() context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{
+		UserID: uuid.New(),
+		Roles:  []string{auth.RoleAdmin},
+	})
+}
+
+func TestCancelEventCascadesToBookings(t *testing.T) {
+	f := newPaymentFlow(t)
+	paidCtx, paid := f.book(t, 2)
+	if _, err := f.payments.CapturePayment(paidCtx, paid.ID); err != nil {
+		t.Fatalf("CapturePayment() = %v", err)
+	}
+	_, unpaid := f.book(t, 1)
+	before := testutil.ToFloat64(metrics.BookingsCancelled)
+
+	result, err := f.events.CancelEvent(adminContext(), f.event.ID, &dto.CancelEventDTO{Reason: "venue closed"})
+	if err != nil {
+		t.Fatalf("CancelEvent() = %v", err)
+	}
+	if result.CancelledBookings != 1 || result.RefundPendingBookings != 1 {
+		t.Fatalf("cancelled %d and refund_pending %d bookings, want 1 and 1",
+			result.CancelledBookings, result.RefundPendingBookings)
+	}
+	f.assertStatus(t, paid.ID, model.BookingStatusRefundPending, model.PaymentStatusSucceeded)
+	f.assertStatus(t, unpaid.ID, model.BookingStatusCancelled, model.PaymentStatusPending)
+	if got := testutil.ToFloat64(metrics.BookingsCancelled) - before; got != 2 {
+		t.Fatalf("bookings cancelled metric grew by %v, want 2", got)
+	}
+	if exists := f.redis.Exists(context.Background(), availableKey(f.event.ID)).Val(); exists != 0 {
+		t.Fatal("availability of the cancelled event is still seeded")
+	}
+}
+
+func TestGetEventByIDHidesDrafts(t *testing.T) {
+	f := newPaymentFlow(t)
+	organizerID := uuid.New()
+	draft := &model.Event{
+		ID:          uuid.New(),
+		Title:       "Secret show",
+		OrganizerId: organizerID,
+		Status:      model.EventStatusDraft,
+	}
+	f.repo.addEvent(draft)
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		visible  bool
+	}{
+		{"customer", &auth.Identity{UserID: uuid.New(), Roles: []string{auth.RoleCustomer}}, false},
+		{"other organizer", &auth.Identity{UserID: uuid.New(), Roles: []string{auth.RoleOrganizer}}, false},
+		{"owning organizer", &auth.Identity{UserID: organizerID, Roles: []string{auth.RoleOrganizer}}, true},
+		{"admin", &auth.Identity{UserID: uuid.New(), Roles: []string{auth.RoleAdmin}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.WithIdentity(context.Background(), tt.identity)
+			_, err := f.events.GetEventByID(ctx, draft.ID)
+			_, listErr := f.events.ListTicketTypes(ctx, draft.ID)
+			if tt.visible && (err != nil || listErr != nil) {
+				t.Fatalf("GetEventByID() = %v, ListTicketTypes() = %v, want nil", err, listErr)
+			}
+			if !tt.visible && (!errors.Is(err, sql.ErrNoRows) || !errors.Is(listErr, sql.ErrNoRows)) {
+				t.Fatalf("GetEventByID() = %v, ListTicketTypes() = %v, want %v", err, listErr, sql.ErrNoRows)
+			}
+		})
+	}
+
+	customer := auth.WithIdentity(context.Background(), &auth.Identity{UserID: uuid.New(), Roles: []string{auth.RoleCustomer}})
+	if _, err := f.events.GetEventByID(customer, f.event.ID); err != nil {
+		t.Fatalf("GetEventByID() of a published event = %v", err)
+	}
+}
+
+func TestUpdateEventRejectsEndedEvents(t *testing.T) {
+	for _, status := range []model.EventStatus{model.EventStatusCancelled, model.EventStatusCompleted} {
+		t.Run(string(status), func(t *testing.T) {
+			f := newPaymentFlow(t)
+			f.event.Status = status
+			f.event.Version = 1
+			title := "Renamed"
+
+			_, err := f.events.UpdateEvent(adminContext(), f.event.ID, &dto.UpdateEventDTO{Version: 1, Title: &title})
+			if !errors.Is(err, model.ErrEventNotEditable) {
+				t.Fatalf("UpdateEvent() = %v, want %v", err, model.ErrEventNotEditable)
+			}
+			capacity := 20
+			_, err = f.events.UpdateTicketType(adminContext(), f.event.ID, f.ticketType.ID,
+				&dto.UpdateTicketTypeDTO{Capacity: &capacity})
+			if !errors.Is(err, model.ErrEventNotEditable) {
+				t.Fatalf("UpdateTicketType() = %v, want %v", err, model.ErrEventNotEditable)
+			}
+		})
+	}
+}
+
+func TestGetEventByIDShowsLiveAvailability(t *testing.T) {
+	f := newPaymentFlow(t)
+	userID := uuid.New()
+	items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 3}}
+	if _, err := f.ticketService(HoldStrategyLua).HoldTickets(customerContext(userID), f.event.ID, userID, items, "", ""); err != nil {
+		t.Fatalf("HoldTickets() = %v", err)
+	}
+
+	event, err := f.events.GetEventByID(context.Background(), f.event.ID)
+	if err != nil {
+		t.Fatalf("GetEventByID() = %v", err)
+	}
+	if len(event.TicketTypes) != 1 {
+		t.Fatalf("event has %d ticket types, want 1", len(event.TicketTypes))
+	}
+	tier := event.TicketTypes[0]
+	if tier.ID != f.ticketType.ID || tier.Capacity != 10 || tier.AvailableTickets != 7 || !tier.OnSale {
+		t.Fatalf("ticket type = %+v, want 7 of 10 tickets available and on sale", tier)
+	}
+}
+
+func TestCancelEventChecksOrganizer(t *testing.T) {
+	f := newPaymentFlow(t)
+	f.event.OrganizerId = uuid.New()
+	organizer := func(userID uuid.UUID) context.Context {
+		return auth.WithIdentity(context.Background(), &auth.Identity{
+			UserID: userID,
+			Roles:  []string{auth.RoleOrganizer},
+		})
+	}
+	cancel := &dto.CancelEventDTO{Reason: "venue closed"}
+
+	if _, err := f.events.CancelEvent(customerContext(f.event.OrganizerId), f.event.ID, cancel); !errors.Is(err, auth.ErrInsufficientRole) {
+		t.Fatalf("CancelEvent() by a customer = %v, want %v", err, auth.ErrInsufficientRole)
+	}
+	if _, err := f.events.CancelEvent(organizer(uuid.New()), f.event.ID, cancel); !errors.Is(err, auth.ErrNotOwner) {
+		t.Fatalf("CancelEvent() by another organizer = %v, want %v", err, auth.ErrNotOwner)
+	}
+	if f.event.Status != model.EventStatusPublished {
+		t.Fatalf("event status = %s after refused cancellations, want %s", f.event.Status, model.EventStatusPublished)
+	}
+	if _, err := f.events.CancelEvent(organizer(f.event.OrganizerId), f.event.ID, cancel); err != nil {
+		t.Fatalf("CancelEvent() by its organizer = %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// holdDeadlinesKey is a sorted set of "<event_id>:<user_id>" members scored
+	// by the unix millisecond deadline of the hold. It outlives the hold keys
+	// themselves so that expired holds can still be found and reclaimed.
+	holdDeadlinesKey = "hold:deadlines"
+)
+
+// HoldResult is the outcome of an atomic hold reservation.
+type HoldResult int
+
+const (
+	HoldResultOK             HoldResult = 1
+	HoldResultSoldOut        HoldResult = 0
+	HoldResultAlreadyHolding HoldResult = -1
+	// HoldResultNotSeeded means the availability counter of a ticket type does
+	// not exist yet and has to be rebuilt from Postgres before retrying.
+	HoldResultNotSeeded HoldResult = -2
+)
+
+const (
+	claimModeRelease = "release"
+	claimModeReap    = "reap"
+	claimModeConsume = "consume"
+)
+
+// parseItemsLua is prepended to the scripts that read line items. Items are
+// stored as "<ticket type id>=<quantity>" pairs joined by ";", see
+// encodeLineItems.
+const parseItemsLua = `
+local function parse_items(encoded)
+	local items = {}
+	for ticket_type, qty in string.gmatch(encoded or '', '([^;=]+)=(%d+)') do
+		items[#items + 1] = {ticket_type, tonumber(qty)}
+	end
+	return items
+end
+
+local function credit_items(availability, encoded)
+	for _, item in ipairs(parse_items(encoded)) do
+		if redis.call('HEXISTS', availability, item[1]) == 1 then
+			redis.call('HINCRBY', availability, item[1], item[2])
+		end
+	end
+end
+`
+
+// claimHoldScript removes a hold exactly once. The ZREM on the deadlines set is
+// the gate: whichever caller removes the member owns the held items.
+// release and reap credit the items back to the availability of their ticket
+// types, consume hands them over to a booking. reap only claims holds whose
+// deadline has passed, consume only claims holds that are still live. Missing
+// counters are left alone, they are rebuilt from Postgres minus the remaining
+// holds.
+//
+// KEYS[1] hold deadlines, KEYS[2] hold items of the event,
+// KEYS[3] hold key, KEYS[4] availability of the event
+// ARGV[1] deadline member, ARGV[2] user id, ARGV[3] mode, ARGV[4] now (unix ms)
+//
+// Returns the claimed items or nil when there is no claimable hold.
+var claimHoldScript = redis.NewScript(parseItemsLua + `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return false
+end
+local now = tonumber(ARGV[4])
+if ARGV[3] == 'reap' and tonumber(score) > now then
+	return false
+end
+if ARGV[3] == 'consume' and tonumber(score) <= now then
+	return false
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+local items = redis.call('HGET', KEYS[2], ARGV[2]) or ''
+redis.call('HDEL', KEYS[2], ARGV[2])
+redis.call('DEL', KEYS[3])
+if ARGV[3] ~= 'consume' and redis.call('EXISTS', KEYS[4]) == 1 then
+	credit_items(KEYS[4], items)
+end
+return items
+`)
+
+// reserveHoldScript checks the availability of every line item, decrements it
+// and writes the hold in one step, so concurrent reservations can neither
+// oversell nor serialise on a lock. A hold of the same user that is past its
+// deadline but not reaped yet is credited back first, a live one is reported
+// as already holding.
+//
+// KEYS[1] availability of the event, KEYS[2] hold key,
+// KEYS[3] hold items of the event, KEYS[4] hold deadlines
+// ARGV[1] items, ARGV[2] ttl (ms), ARGV[3] now (unix ms),
+// ARGV[4] user id, ARGV[5] deadline member
+//
+// Returns a HoldResult.
+var reserveHoldScript = redis.NewScript(parseItemsLua + `
+local items = parse_items(ARGV[1])
+for _, item in ipairs(items) do
+	if redis.call('HEXISTS', KEYS[1], item[1]) == 0 then
+		return -2
+	end
+end
+local now = tonumber(ARGV[3])
+local score = redis.call('ZSCORE', KEYS[4], ARGV[5])
+if score then
+	if tonumber(score) > now then
+		return -1
+	end
+	local stale = redis.call('HGET', KEYS[3], ARGV[4])
+	redis.call('ZREM', KEYS[4], ARGV[5])
+	redis.call('HDEL', KEYS[3], ARGV[4])
+	credit_items(KEYS[1], stale)
+end
+for _, item in ipairs(items) do
+	if tonumber(redis.call('HGET', KEYS[1], item[1])) < item[2] then
+		return 0
+	end
+end
+for _, item in ipairs(items) do
+	redis.call('HINCRBY', KEYS[1], item[1], -item[2])
+end
+redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+redis.call('HSET', KEYS[3], ARGV[4], ARGV[1])
+redis.call('ZADD', KEYS[4], now + tonumber(ARGV[2]), ARGV[5])
+return 1
+`)
+
+// creditAvailabilityScript adds tickets back to existing counters only.
+//
+// KEYS[1] availability of the event
+// ARGV[1] items
+var creditAvailabilityScript = redis.NewScript(parseItemsLua + `
+credit_items(KEYS[1], ARGV[1])
+return 1
+`)
+
+func holdKey(eventID, userID uuid.UUID) string {
+	return fmt.Sprintf("hold:event:%s:user:%s", eventID.String(), userID.String())
+}
+
+// holdPromoKey is the promo code entered with the hold, it expires with the
+// hold.
+func holdPromoKey(eventID, userID uuid.UUID) string {
+	return fmt.Sprintf("hold:promo:event:%s:user:%s", eventID.String(), userID.String())
+}
+
+func holdItemsKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("hold:items:event:%s", eventID.String())
+}
+
+func holdDeadlineMember(eventID, userID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s", eventID.String(), userID.String())
+}
+
+func parseHoldDeadlineMember(member string) (eventID, userID uuid.UUID, err error) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 2 {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("malformed hold member %q", member)
+	}
+	if eventID, err = uuid.Parse(parts[0]); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if userID, err = uuid.Parse(parts[1]); err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return eventID, userID, nil
+}
+
+// availableKey is a hash of the available tickets of every ticket type of the
+// event, keyed by ticket type id.
+func availableKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("availability:event:%s", eventID.String())
+}
+
+// encodeLineItems is the inverse of decodeLineItems and of parse_items in
+// parseItemsLua.
+func encodeLineItems(items []model.LineItem) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, fmt.Sprintf("%s=%d", item.TicketTypeID.String(), item.Quantity))
+	}
+	return strings.Join(parts, ";")
+}
+
+func decodeLineItems(encoded string) ([]model.LineItem, error) {
+	items := []model.LineItem{}
+	if encoded == "" {
+		return items, nil
+	}
+	for _, part := range strings.Split(encoded, ";") {
+		ticketType, quantity, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed line item %q", part)
+		}
+		id, err := uuid.Parse(ticketType)
+		if err != nil {
+			return nil, fmt.Errorf("malformed line item %q: %w", part, err)
+		}
+		qty, err := strconv.Atoi(quantity)
+		if err != nil {
+			return nil, fmt.Errorf("malformed line item %q: %w", part, err)
+		}
+		items = append(items, model.LineItem{TicketTypeID: id, Quantity: qty})
+	}
+	return items, nil
+}
+
+// writeHold records a hold together with its bookkeeping entries.
+func writeHold(
+	ctx context.Context,
+	pipe redis.Pipeliner,
+	eventID, userID uuid.UUID,
+	items []model.LineItem,
+	ttl time.Duration,
+) {
+	encoded := encodeLineItems(items)
+	pipe.Set(ctx, holdKey(eventID, userID), encoded, ttl)
+	pipe.HSet(ctx, holdItemsKey(eventID), userID.String(), encoded)
+	pipe.ZAdd(ctx, holdDeadlinesKey, redis.Z{
+		Score:  float64(time.Now().Add(ttl).UnixMilli()),
+		Member: holdDeadlineMember(eventID, userID),
+	})
+}
+
+// reserveHold runs reserveHoldScript for the given user and line items.
+func reserveHold(
+	ctx context.Context,
+	client *redis.Client,
+	eventID, userID uuid.UUID,
+	items []model.LineItem,
+	ttl time.Duration,
+) (HoldResult, error) {
+	keys := []string{
+		availableKey(eventID),
+		holdKey(eventID, userID),
+		holdItemsKey(eventID),
+		holdDeadlinesKey,
+	}
+	result, err := reserveHoldScript.Run(ctx, client, keys,
+		encodeLineItems(items),
+		ttl.Milliseconds(),
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+		userID.String(),
+		holdDeadlineMember(eventID, userID),
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve hold: %w", err)
+	}
+	return HoldResult(result), nil
+}
+
+// claimHold runs claimHoldScript and returns the claimed line items,
+// or ErrHoldNotFound when no hold could be claimed in the given mode.
+func claimHold(
+	ctx context.Context,
+	client *redis.Client,
+	eventID, userID uuid.UUID,
+	mode string,
+) ([]model.LineItem, error) {
+	keys := []string{
+		holdDeadlinesKey,
+		holdItemsKey(eventID),
+		holdKey(eventID, userID),
+		availableKey(eventID),
+	}
+	encoded, err := claimHoldScript.Run(ctx, client, keys,
+		holdDeadlineMember(eventID, userID),
+		userID.String(),
+		mode,
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+	).Text()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to claim hold: %w", err)
+	}
+	return decodeLineItems(encoded)
+}
+
+// creditAvailability returns line items to the availability of an event.
+func creditAvailability(
+	ctx context.Context,
+	client *redis.Client,
+	eventID uuid.UUID,
+	items []model.LineItem,
+) error {
+	err := creditAvailabilityScript.Run(ctx, client, []string{availableKey(eventID)}, encodeLineItems(items)).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to credit availability: %w", err)
+	}
+	return nil
+}
+
+// dropHolds deletes every hold of an event together with its bookkeeping
+// entries, without crediting anything back. Only for events that are gone.
+func dropHolds(ctx context.Context, client *redis.Client, eventID uuid.UUID) error {
+	users, err := client.HKeys(ctx, holdItemsKey(eventID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list holds: %w", err)
+	}
+	pipe := client.TxPipeline()
+	for _, user := range users {
+		userID, err := uuid.Parse(user)
+		if err != nil {
+			return fmt.Errorf("malformed hold user %q: %w", user, err)
+		}
+		pipe.ZRem(ctx, holdDeadlinesKey, holdDeadlineMember(eventID, userID))
+		pipe.Del(ctx, holdKey(eventID, userID), holdPromoKey(eventID, userID))
+	}
+	pipe.Del(ctx, holdItemsKey(eventID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to drop holds: %w", err)
+	}
+	return nil
+}
+
+// dropAvailability deletes the availability of an event so that no further
+// holds can be reserved against it. Remaining holds are left to the reaper,
+// which does not recreate the counters.
+func dropAvailability(ctx context.Context, client *redis.Client, eventID uuid.UUID) error {
+	if err := client.Del(ctx, availableKey(eventID)).Err(); err != nil {
+		return fmt.Errorf("failed to drop availability: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	inventoryModeInspect = "inspect"
+	inventoryModeSeed    = "seed"
+	inventoryModeRepair  = "repair"
+)
+
+// inventoryScript compares the availability counter of every ticket type of
+// an event with the value derived from Postgres, i.e. the available tickets of
+// the ticket type minus every ticket of it still sitting in a hold. seed writes
+// the derived value of missing counters, repair always overwrites them,
+// inspect only reports.
+//
+// KEYS[1] availability of the event, KEYS[2] hold items of the event
+// ARGV[1] mode, followed by a ticket type id and its available tickets in
+// Postgres for every ticket type
+//
+// Returns {active holds, then held tickets, expected, seeded (0/1), current
+// for every ticket type in argument order}.
+var inventoryScript = redis.NewScript(parseItemsLua + `
+local held = {}
+local holds = redis.call('HVALS', KEYS[2])
+for _, encoded in ipairs(holds) do
+	for _, item in ipairs(parse_items(encoded)) do
+		held[item[1]] = (held[item[1]] or 0) + item[2]
+	end
+end
+local reply = {#holds}
+for i = 2, #ARGV, 2 do
+	local ticket_type = ARGV[i]
+	local type_held = held[ticket_type] or 0
+	local expected = tonumber(ARGV[i + 1]) - type_held
+	if expected < 0 then
+		expected = 0
+	end
+	local current = redis.call('HGET', KEYS[1], ticket_type)
+	local seeded = 0
+	local value = 0
+	if current then
+		seeded = 1
+		value = tonumber(current)
+	end
+	if ARGV[1] == 'repair' or (ARGV[1] == 'seed' and seeded == 0) then
+		redis.call('HSET', KEYS[1], ticket_type, expected)
+		if seeded == 0 then
+			value = expected
+		end
+	end
+	reply[#reply + 1] = type_held
+	reply[#reply + 1] = expected
+	reply[#reply + 1] = seeded
+	reply[#reply + 1] = value
+end
+return reply
+`)
+
+// adjustAvailabilityScript moves the availability counter of a ticket type by
+// a capacity delta unless that would leave fewer tickets than are sold or
+// held. When the counter is missing it only validates the delta against
+// Postgres minus the holds.
+//
+// KEYS[1] availability of the event, KEYS[2] hold items of the event
+// ARGV[1] ticket type id, ARGV[2] delta, ARGV[3] available tickets in Postgres
+//
+// Returns 1 when the counter was adjusted, 0 when it is missing and the delta
+// is acceptable and -1 when the delta is rejected.
+var adjustAvailabilityScript = redis.NewScript(parseItemsLua + `
+local delta = tonumber(ARGV[2])
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if current then
+	if tonumber(current) + delta < 0 then
+		return -1
+	end
+	redis.call('HINCRBY', KEYS[1], ARGV[1], delta)
+	return 1
+end
+local held = 0
+for _, encoded in ipairs(redis.call('HVALS', KEYS[2])) do
+	for _, item in ipairs(parse_items(encoded)) do
+		if item[1] == ARGV[1] then
+			held = held + item[2]
+		end
+	end
+end
+if tonumber(ARGV[3]) + delta - held < 0 then
+	return -1
+end
+return 0
+`)
+
+// adjustAvailability applies a capacity delta to the availability counter of
+// the ticket type and reports whether the counter was changed, so callers can
+// undo it.
+func adjustAvailability(
+	ctx context.Context,
+	client *redis.Client,
+	ticketType *model.TicketType,
+	delta int,
+) (bool, error) {
+	keys := []string{availableKey(ticketType.EventID), holdItemsKey(ticketType.EventID)}
+	result, err := adjustAvailabilityScript.Run(ctx, client, keys,
+		ticketType.ID.String(),
+		delta,
+		ticketType.AvailableTickets,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to adjust availability: %w", err)
+	}
+	if result < 0 {
+		return false, model.ErrCapacityBelowCommitted
+	}
+	return result == 1, nil
+}
+
+// syncAvailability runs inventoryScript for the loaded ticket types of the
+// event and returns the snapshot as seen before any write.
+func syncAvailability(
+	ctx context.Context,
+	client *redis.Client,
+	event *model.Event,
+	mode string,
+) (*dto.InventoryDTO, error) {
+	keys := []string{availableKey(event.ID), holdItemsKey(event.ID)}
+	args := []interface{}{mode}
+	for _, ticketType := range event.TicketTypes {
+		args = append(args, ticketType.ID.String(), ticketType.AvailableTickets)
+	}
+	values, err := inventoryScript.Run(ctx, client, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync availability: %w", err)
+	}
+	if len(values) != 1+4*len(event.TicketTypes) {
+		return nil, fmt.Errorf("failed to sync availability: unexpected reply %v", values)
+	}
+
+	inventory := &dto.InventoryDTO{
+		EventID:     event.ID,
+		Capacity:    event.Capacity,
+		DBAvailable: event.AvailableTickets,
+		ActiveHolds: int(values[0]),
+		TicketTypes: make([]*dto.TicketTypeInventoryDTO, 0, len(event.TicketTypes)),
+	}
+	redisAvailable := 0
+	for i, ticketType := range event.TicketTypes {
+		row := values[1+4*i:]
+		tier := &dto.TicketTypeInventoryDTO{
+			TicketTypeID:      ticketType.ID,
+			Name:              ticketType.Name,
+			Capacity:          ticketType.Capacity,
+			DBAvailable:       ticketType.AvailableTickets,
+			HeldTickets:       int(row[0]),
+			ExpectedAvailable: int(row[1]),
+			Seeded:            row[2] == 1,
+		}
+		inventory.HeldTickets += tier.HeldTickets
+		inventory.ExpectedAvailable += tier.ExpectedAvailable
+		if tier.Seeded {
+			current := int(row[3])
+			tier.RedisAvailable = &current
+			tier.Drift = current - tier.ExpectedAvailable
+			inventory.Seeded = true
+			redisAvailable += current
+			inventory.Drift += abs(tier.Drift)
+		}
+		inventory.TicketTypes = append(inventory.TicketTypes, tier)
+	}
+	if inventory.Seeded {
+		inventory.RedisAvailable = &redisAvailable
+	}
+	return inventory, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+type InventoryService struct {
+	eventRepo repository.EventRepositoryInterface
+	logger    logger.Logger
+	redis     *redis.Client
+}
+
+func NewInventoryService(
+	eventRepo repository.EventRepositoryInterface,
+	logger logger.Logger,
+	redis *redis.Client,
+) InventoryServiceInterface {
+	return &InventoryService{
+		eventRepo: eventRepo,
+		logger:    logger,
+		redis:     redis,
+	}
+}
+
+func (s *InventoryService) GetInventory(ctx context.Context, eventID uuid.UUID) (*dto.InventoryDTO, error) {
+	event, err := s.eventRepo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return syncAvailability(ctx, s.redis, event, inventoryModeInspect)
+}
+
+func (s *InventoryService) ListReconcilableEvents(ctx context.Context) ([]*model.Event, error) {
+	events, err := s.eventRepo.ListOnSaleEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *InventoryService) CheckInventory(ctx context.Context, event *model.Event) (*dto.InventoryDTO, error) {
+	return syncAvailability(ctx, s.redis, event, inventoryModeInspect)
+}
+
+func (s *InventoryService) RepairInventory(ctx context.Context, eventID uuid.UUID) (*dto.InventoryDTO, error) {
+	// Re-read the event so the repair uses the freshest Postgres value.
+	event, err := s.eventRepo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return syncAvailability(ctx, s.redis, event, inventoryModeRepair)
+}
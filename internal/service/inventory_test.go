@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+)
+
+func TestSyncAvailability(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	event, err := f.repo.GetEventByID(ctx, f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspect := func(mode string) (seeded bool, redisAvailable, expected, drift int) {
+		t.Helper()
+		inventory, err := syncAvailability(ctx, f.redis, event, mode)
+		if err != nil {
+			t.Fatalf("syncAvailability(%s) = %v", mode, err)
+		}
+		if inventory.RedisAvailable != nil {
+			redisAvailable = *inventory.RedisAvailable
+		}
+		return inventory.Seeded, redisAvailable, inventory.ExpectedAvailable, inventory.Drift
+	}
+
+	if seeded, _, expected, _ := inspect(inventoryModeInspect); seeded || expected != 10 {
+		t.Fatalf("inspect before seeding = seeded %v, expected %d, want unseeded and 10", seeded, expected)
+	}
+	if exists := f.redis.Exists(ctx, availableKey(f.event.ID)).Val(); exists != 0 {
+		t.Fatal("inspect wrote the counters")
+	}
+	if seeded, current, _, _ := inspect(inventoryModeSeed); seeded || current != 0 {
+		t.Fatalf("first seed reported seeded %v, redis %d, want the state before the write", seeded, current)
+	}
+	if got := f.available(t); got != 10 {
+		t.Fatalf("available after seeding = %d, want 10", got)
+	}
+
+	// Held tickets are not in Postgres yet, the expected counter leaves them out.
+	items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 3}}
+	if result, err := reserveHold(ctx, f.redis, f.event.ID, uuid.New(), items, time.Minute); err != nil || result != HoldResultOK {
+		t.Fatalf("reserveHold() = %v, %v, want %v", result, err, HoldResultOK)
+	}
+	if seeded, current, expected, drift := inspect(inventoryModeInspect); !seeded || current != 7 || expected != 7 || drift != 0 {
+		t.Fatalf("inspect with a hold = seeded %v, redis %d, expected %d, drift %d, want 7, 7 and no drift",
+			seeded, current, expected, drift)
+	}
+
+	// A lost update leaves the counter off, seed keeps it and repair fixes it.
+	f.redis.HSet(ctx, availableKey(f.event.ID), f.ticketType.ID.String(), 9)
+	if _, current, _, drift := inspect(inventoryModeSeed); current != 9 || drift != 2 {
+		t.Fatalf("seed of a drifted counter = redis %d, drift %d, want 9 and 2", current, drift)
+	}
+	if got := f.available(t); got != 9 {
+		t.Fatalf("available after seed = %d, want the seeded counter kept at 9", got)
+	}
+	if _, current, _, drift := inspect(inventoryModeRepair); current != 9 || drift != 2 {
+		t.Fatalf("repair = redis %d, drift %d, want the state before the write", current, drift)
+	}
+	if got := f.available(t); got != 7 {
+		t.Fatalf("available after repair = %d, want 7", got)
+	}
+}
+
+func TestAdjustAvailability(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	ticketType := *f.ticketType
+	items := []model.LineItem{{TicketTypeID: ticketType.ID, Quantity: 4}}
+
+	// Without counters the delta is checked against Postgres minus the holds.
+	f.redis.HSet(ctx, holdItemsKey(f.event.ID), uuid.NewString(), encodeLineItems(items))
+	if adjusted, err := adjustAvailability(ctx, f.redis, &ticketType, -6); err != nil || adjusted {
+		t.Fatalf("adjustAvailability(-6) = %v, %v, want accepted without a counter", adjusted, err)
+	}
+	if _, err := adjustAvailability(ctx, f.redis, &ticketType, -7); !errors.Is(err, model.ErrCapacityBelowCommitted) {
+		t.Fatalf("adjustAvailability(-7) = %v, want %v", err, model.ErrCapacityBelowCommitted)
+	}
+
+	f.redis.HSet(ctx, availableKey(f.event.ID), ticketType.ID.String(), 6)
+	if adjusted, err := adjustAvailability(ctx, f.redis, &ticketType, 5); err != nil || !adjusted {
+		t.Fatalf("adjustAvailability(5) = %v, %v, want the counter adjusted", adjusted, err)
+	}
+	if got := f.available(t); got != 11 {
+		t.Fatalf("available = %d, want 11", got)
+	}
+	if _, err := adjustAvailability(ctx, f.redis, &ticketType, -12); !errors.Is(err, model.ErrCapacityBelowCommitted) {
+		t.Fatalf("adjustAvailability(-12) = %v, want %v", err, model.ErrCapacityBelowCommitted)
+	}
+	if got := f.available(t); got != 11 {
+		t.Fatalf("available after a refused delta = %d, want 11", got)
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+)
+
+// memoryRepository keeps events, bookings, payments and webhook events in
+// memory and applies the same transition tables as the Postgres
+// repositories. Methods the tests do not need are left to the embedded nil
+// interfaces and panic when called.
+type memoryRepository struct {
+	repository.BookingRepositoryInterface
+	repository.EventRepositoryInterface
+	repository.WebhookRepositoryInterface
+
+	mu       sync.Mutex
+	events   map[uuid.UUID]*model.Event
+	bookings map[uuid.UUID]*model.Booking
+	payments map[uuid.UUID]*model.Payment
+	webhooks map[string]*model.WebhookEvent
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		events:   map[uuid.UUID]*model.Event{},
+		bookings: map[uuid.UUID]*model.Booking{},
+		payments: map[uuid.UUID]*model.Payment{},
+		webhooks: map[string]*model.WebhookEvent{},
+	}
+}
+
+func (r *memoryRepository) addEvent(event *model.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[event.ID] = event
+}
+
+func (r *memoryRepository) GetEventByID(_ context.Context, id uuid.UUID) (*model.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[id]
+	if !ok {
+		return nil, fmt.Errorf("event not found: %w", sql.ErrNoRows)
+	}
+	copied := *event
+	copied.TicketTypes = make([]*model.TicketType, 0, len(event.TicketTypes))
+	for _, ticketType := range event.TicketTypes {
+		tier := *ticketType
+		copied.TicketTypes = append(copied.TicketTypes, &tier)
+	}
+	return &copied, nil
+}
+
+func (r *memoryRepository) ListWaitingRoomEvents(_ context.Context) ([]*model.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []*model.Event
+	for _, event := range r.events {
+		if event.WaitingRoom && (event.Status == model.EventStatusDraft || event.Status == model.EventStatusPublished) {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	return events, nil
+}
+
+// CancelEvent cancels the event and cascades the cancellation to its bookings
+// the way the Postgres repository does.
+func (r *memoryRepository) CancelEvent(
+	_ context.Context,
+	id uuid.UUID,
+	reason string,
+	cancelledAt time.Time,
+) (*model.EventCancellation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[id]
+	if !ok {
+		return nil, fmt.Errorf("event not found: %w", sql.ErrNoRows)
+	}
+	if err := event.Status.TransitionTo(model.EventStatusCancelled); err != nil {
+		return nil, err
+	}
+	event.Status = model.EventStatusCancelled
+	event.UpdatedAt = cancelledAt
+
+	cancellation := &model.EventCancellation{}
+	for _, booking := range r.bookings {
+		next, ok := booking.Status.OnEventCancelled()
+		if booking.EventID != id || !ok {
+			continue
+		}
+		booking.CancellationReason = &reason
+		booking.CancelledAt = &cancelledAt
+		cancellation.Bookings = append(cancellation.Bookings, r.applyTransition(booking, next, reason, cancelledAt))
+	}
+	copied := *event
+	cancellation.Event = &copied
+	return cancellation, nil
+}
+
+func (r *memoryRepository) CreateBooking(
+	_ context.Context,
+	booking *model.Booking,
+	feeRate model.FeeRate,
+	_ string,
+) (*model.Booking, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[booking.EventID]
+	if !ok {
+		return nil, fmt.Errorf("event not found: %w", sql.ErrNoRows)
+	}
+	if err := model.BookingStatus("").TransitionTo(booking.Status); err != nil {
+		return nil, err
+	}
+	for _, item := range booking.Items {
+		ticketType := event.TicketType(item.TicketTypeID)
+		if ticketType == nil {
+			return nil, model.ErrUnknownTicketType
+		}
+		if ticketType.AvailableTickets < item.Quantity {
+			return nil, model.ErrNotEnoughTickets
+		}
+		item.UnitPrice = ticketType.Price
+	}
+	if err := booking.CalculateTotals(feeRate, nil); err != nil {
+		return nil, err
+	}
+	for _, item := range booking.Items {
+		event.TicketType(item.TicketTypeID).AvailableTickets -= item.Quantity
+		event.AvailableTickets -= item.Quantity
+	}
+	r.bookings[booking.ID] = copyBooking(booking)
+	return copyBooking(booking), nil
+}
+
+func (r *memoryRepository) GetBookingByID(_ context.Context, id uuid.UUID) (*model.Booking, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	booking, ok := r.bookings[id]
+	if !ok {
+		return nil, fmt.Errorf("booking not found: %w", sql.ErrNoRows)
+	}
+	return copyBooking(booking), nil
+}
+
+func (r *memoryRepository) TransitionBooking(
+	_ context.Context,
+	id uuid.UUID,
+	to model.BookingStatus,
+	reason string,
+	at time.Time,
+) (*model.BookingTransition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	booking, ok := r.bookings[id]
+	if !ok {
+		return nil, fmt.Errorf("booking not found: %w", sql.ErrNoRows)
+	}
+	if err := booking.Status.TransitionTo(to); err != nil {
+		return nil, err
+	}
+	return r.applyTransition(booking, to, reason, at), nil
+}
+
+func (r *memoryRepository) CancelBooking(
+	_ context.Context,
+	id uuid.UUID,
+	reason string,
+	cancelledAt time.Time,
+) (*model.BookingTransition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	booking, ok := r.bookings[id]
+	if !ok {
+		return nil, fmt.Errorf("booking not found: %w", sql.ErrNoRows)
+	}
+	paid := false
+	for _, p := range r.payments {
+		if p.BookingID == id && p.Status == model.PaymentStatusSucceeded {
+			paid = true
+		}
+	}
+	to := booking.Status.OnCancelled(paid)
+	if err := booking.Status.TransitionTo(to); err != nil {
+		return nil, err
+	}
+	booking.CancellationReason = &reason
+	booking.CancelledAt = &cancelledAt
+	return r.applyTransition(booking, to, reason, cancelledAt), nil
+}
+
+func (r *memoryRepository) ListStaleBookings(
+	_ context.Context,
+	status model.BookingStatus,
+	updatedBefore time.Time,
+	limit int,
+) ([]*model.Booking, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var bookings []*model.Booking
+	for _, booking := range r.bookings {
+		if booking.Status == status && booking.UpdatedAt.Before(updatedBefore) && len(bookings) < limit {
+			bookings = append(bookings, copyBooking(booking))
+		}
+	}
+	return bookings, nil
+}
+
+func (r *memoryRepository) CountActiveTickets(_ context.Context, eventID, userID uuid.UUID) (map[uuid.UUID]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owned := map[uuid.UUID]int{}
+	for _, booking := range r.bookings {
+		if booking.EventID != eventID || booking.UserID != userID {
+			continue
+		}
+		switch booking.Status {
+		case model.BookingStatusPending, model.BookingStatusAwaitingPayment,
+			model.BookingStatusConfirmed, model.BookingStatusCheckedIn:
+			for _, item := range booking.Items {
+				owned[item.TicketTypeID] += item.Quantity
+			}
+		}
+	}
+	return owned, nil
+}
+
+// applyTransition moves a booking whose transition was checked and gives the
+// tickets back to the event when it leaves an inventory holding status.
+func (r *memoryRepository) applyTransition(
+	booking *model.Booking,
+	to model.BookingStatus,
+	reason string,
+	at time.Time,
+) *model.BookingTransition {
+	transition := &model.BookingTransition{From: booking.Status}
+	if booking.Status.HoldsInventory() && !to.HoldsInventory() {
+		event := r.events[booking.EventID]
+		for _, item := range booking.Items {
+			event.TicketType(item.TicketTypeID).AvailableTickets += item.Quantity
+			event.AvailableTickets += item.Quantity
+		}
+		transition.ReleasedTickets = booking.Quantity
+	}
+	booking.Status = to
+	booking.UpdatedAt = at
+	if to == model.BookingStatusCancelled {
+		booking.CancellationReason = &reason
+		booking.CancelledAt = &at
+	}
+	transition.Booking = copyBooking(booking)
+	return transition
+}
+
+func (r *memoryRepository) CreatePayment(_ context.Context, p *model.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *p
+	r.payments[p.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepository) GetPaymentByBookingID(_ context.Context, bookingID uuid.UUID) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.payments {
+		if p.BookingID == bookingID {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("payment not found: %w", sql.ErrNoRows)
+}
+
+func (r *memoryRepository) GetPaymentByIntentID(_ context.Context, provider, intentID string) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.payments {
+		if p.Provider == provider && p.ProviderIntentID == intentID {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("payment not found: %w", sql.ErrNoRows)
+}
+
+// SettlePayment changes the payment and its booking together, neither
+// changes when one of the transitions is not allowed.
+func (r *memoryRepository) SettlePayment(
+	_ context.Context,
+	id uuid.UUID,
+	status model.PaymentStatus,
+	bookingStatus model.BookingStatus,
+	reason string,
+	at time.Time,
+) (*model.PaymentSettlement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("payment not found: %w", sql.ErrNoRows)
+	}
+	if err := p.Status.TransitionTo(status); err != nil {
+		return nil, err
+	}
+	booking := r.bookings[p.BookingID]
+	if err := booking.Status.TransitionTo(bookingStatus); err != nil {
+		return nil, err
+	}
+	p.Status = status
+	p.UpdatedAt = at
+	copied := *p
+	return &model.PaymentSettlement{
+		Payment:    &copied,
+		Transition: r.applyTransition(booking, bookingStatus, reason, at),
+	}, nil
+}
+
+func (r *memoryRepository) RecordWebhookEvent(_ context.Context, event *model.WebhookEvent) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := event.Provider + "/" + event.EventID
+	if existing, ok := r.webhooks[key]; ok {
+		*event = *existing
+		return false, nil
+	}
+	copied := *event
+	r.webhooks[key] = &copied
+	return true, nil
+}
+
+func (r *memoryRepository) FinishWebhookEvent(
+	_ context.Context,
+	id uuid.UUID,
+	status model.WebhookEventStatus,
+	processingErr *string,
+	at time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.webhooks {
+		if event.ID == id {
+			event.Status = status
+			event.Error = processingErr
+			event.ProcessedAt = &at
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook event not found: %w", sql.ErrNoRows)
+}
+
+func (r *memoryRepository) GetWebhookEvent(_ context.Context, id uuid.UUID) (*model.WebhookEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.webhooks {
+		if event.ID == id {
+			copied := *event
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("webhook event not found: %w", sql.ErrNoRows)
+}
+
+func copyBooking(booking *model.Booking) *model.Booking {
+	copied := *booking
+	copied.Items = make([]*model.BookingItem, 0, len(booking.Items))
+	for _, item := range booking.Items {
+		copiedItem := *item
+		copied.Items = append(copied.Items, &copiedItem)
+	}
+	return &copied
+}
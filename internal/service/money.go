@@ -0,0 +1,14 @@
+package service
+
+import (
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+)
+
+func toMoneyDTO(money model.Money) dto.MoneyDTO {
+	return dto.MoneyDTO{Amount: money.Amount, Currency: money.Currency}
+}
+
+func toMoney(moneyDTO dto.MoneyDTO) model.Money {
+	return model.NewMoney(moneyDTO.Amount, moneyDTO.Currency)
+}
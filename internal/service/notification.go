@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/notification"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+const (
+	notificationBatchSize          = 100
+	defaultNotificationMaxAttempts = 3
+	// notificationRetryDelay is how long a failed notification waits before
+	// it is tried again
+	notificationRetryDelay = 5 * time.Minute
+	// notificationClaimLease is how long a sender may take. A notification
+	// still sending after that was left behind by a crashed sender and is
+	// claimed again.
+	notificationClaimLease = 10 * time.Minute
+)
+
+type NotificationService struct {
+	notificationRepo repository.NotificationRepositoryInterface
+	sender           notification.Sender
+	templates        *notification.Templates
+	logger           logger.Logger
+	reminderOffsets  []time.Duration
+	maxAttempts      int
+}
+
+func NewNotificationService(
+	cfg *config.Config,
+	notificationRepo repository.NotificationRepositoryInterface,
+	sender notification.Sender,
+	templates *notification.Templates,
+	logger logger.Logger,
+) NotificationServiceInterface {
+	maxAttempts := cfg.Notification.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultNotificationMaxAttempts
+	}
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		sender:           sender,
+		templates:        templates,
+		logger:           logger,
+		reminderOffsets:  cfg.Notification.ReminderOffsets,
+		maxAttempts:      maxAttempts,
+	}
+}
+
+// SendConfirmations notifies confirmed bookings that have not been told yet
+// and returns how many messages were sent.
+func (s *NotificationService) SendConfirmations(ctx context.Context) (int, error) {
+	kind := string(notification.KindBookingConfirmed)
+	return s.sendAll(ctx, kind, notification.KindBookingConfirmed, "",
+		func(filter *repository.NotificationTargetFilter) ([]*model.NotificationTarget, error) {
+			return s.notificationRepo.ListConfirmationTargets(ctx, filter, notificationBatchSize)
+		})
+}
+
+// SendReminders sends the reminders that are due for every configured offset
+// and returns how many messages were sent.
+func (s *NotificationService) SendReminders(ctx context.Context) (int, error) {
+	sent := 0
+	for _, offset := range s.reminderOffsets {
+		kind := fmt.Sprintf("%s_%s", notification.KindEventReminder, formatOffset(offset))
+		n, err := s.sendAll(ctx, kind, notification.KindEventReminder, describeOffset(offset),
+			func(filter *repository.NotificationTargetFilter) ([]*model.NotificationTarget, error) {
+				return s.notificationRepo.ListReminderTargets(ctx, filter, offset, notificationBatchSize)
+			})
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// sendAll sends the notification kind to every target list returns, batch
+// by batch. Each target leaves the list once it is claimed, so the loop ends.
+func (s *NotificationService) sendAll(
+	ctx context.Context,
+	kind string,
+	template notification.Kind,
+	startsIn string,
+	list func(filter *repository.NotificationTargetFilter) ([]*model.NotificationTarget, error),
+) (int, error) {
+	sent := 0
+	for {
+		now := time.Now()
+		targets, err := list(&repository.NotificationTargetFilter{
+			Kind:          kind,
+			MaxAttempts:   s.maxAttempts,
+			RetryBefore:   now.Add(-notificationRetryDelay),
+			ClaimedBefore: now.Add(-notificationClaimLease),
+			Now:           now,
+		})
+		if err != nil {
+			return sent, err
+		}
+		for _, target := range targets {
+			ok, err := s.send(ctx, kind, template, target, startsIn)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+		if len(targets) < notificationBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// send claims the notification of the target and sends it. Failing to render
+// or deliver the message is recorded on the notification, only storage errors
+// are returned.
+func (s *NotificationService) send(
+	ctx context.Context,
+	kind string,
+	template notification.Kind,
+	target *model.NotificationTarget,
+	startsIn string,
+) (bool, error) {
+	if target.ContactEmail == nil {
+		return false, nil
+	}
+	now := time.Now()
+	n := &model.Notification{
+		ID:        uuid.New(),
+		BookingID: target.ID,
+		Kind:      kind,
+		Channel:   notification.ChannelEmail,
+		Recipient: *target.ContactEmail,
+		CreatedAt: now,
+	}
+	claimed, err := s.notificationRepo.ClaimNotification(ctx, n, s.maxAttempts, now.Add(-notificationClaimLease))
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	message, err := s.templates.Render(template, n.Recipient, &notification.TemplateData{
+		BookingID:      target.ID,
+		Quantity:       target.Quantity,
+		EventTitle:     target.EventTitle,
+		EventStartTime: target.EventStartTime,
+		EventLocation:  target.EventLocation,
+		StartsIn:       startsIn,
+	})
+	if err == nil {
+		err = s.sender.Send(ctx, message)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("NOTIFICATION_SERVICE.SEND: %s for booking %s, attempt %d: %v",
+			kind, target.ID, n.Attempts, err)
+		text := err.Error()
+		return false, s.notificationRepo.FinishNotification(ctx, n.ID, model.NotificationStatusFailed, &text, time.Now())
+	}
+	return true, s.notificationRepo.FinishNotification(ctx, n.ID, model.NotificationStatusSent, nil, time.Now())
+}
+
+// formatOffset names an offset for a notification kind, e.g. "24h" or "90m".
+func formatOffset(offset time.Duration) string {
+	switch {
+	case offset%time.Hour == 0:
+		return fmt.Sprintf("%dh", offset/time.Hour)
+	case offset%time.Minute == 0:
+		return fmt.Sprintf("%dm", offset/time.Minute)
+	default:
+		return offset.String()
+	}
+}
+
+// describeOffset spells out an offset for a message, e.g. "24 hours".
+func describeOffset(offset time.Duration) string {
+	plural := func(n time.Duration, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case offset%(24*time.Hour) == 0:
+		return plural(offset/(24*time.Hour), "day")
+	case offset%time.Hour == 0:
+		return plural(offset/time.Hour, "hour")
+	case offset%time.Minute == 0:
+		return plural(offset/time.Minute, "minute")
+	default:
+		return offset.String()
+	}
+}
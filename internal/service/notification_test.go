@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/notification"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+// memoryNotifications lists targets and claims notifications with the same
+// rules as the Postgres repository.
+type memoryNotifications struct {
+	mu            sync.Mutex
+	confirmations []*model.NotificationTarget
+	reminders     map[time.Duration][]*model.NotificationTarget
+	notifications map[string]*model.Notification
+}
+
+func newMemoryNotifications() *memoryNotifications {
+	return &memoryNotifications{
+		reminders:     map[time.Duration][]*model.NotificationTarget{},
+		notifications: map[string]*model.Notification{},
+	}
+}
+
+func notificationKey(bookingID uuid.UUID, kind string) string {
+	return bookingID.String() + ":" + kind
+}
+
+func (r *memoryNotifications) get(bookingID uuid.UUID, kind string) *model.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.notifications[notificationKey(bookingID, kind)]
+}
+
+// due mirrors the notificationDue condition of the repository.
+func (r *memoryNotifications) due(filter *repository.NotificationTargetFilter, target *model.NotificationTarget) bool {
+	n, ok := r.notifications[notificationKey(target.ID, filter.Kind)]
+	if !ok {
+		return true
+	}
+	if n.Attempts >= filter.MaxAttempts {
+		return false
+	}
+	return (n.Status == model.NotificationStatusFailed && !n.UpdatedAt.After(filter.RetryBefore)) ||
+		(n.Status == model.NotificationStatusSending && !n.ClaimedAt.After(filter.ClaimedBefore))
+}
+
+func (r *memoryNotifications) list(
+	filter *repository.NotificationTargetFilter,
+	targets []*model.NotificationTarget,
+	limit int,
+) []*model.NotificationTarget {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*model.NotificationTarget
+	for _, target := range targets {
+		if len(due) < limit && r.due(filter, target) {
+			due = append(due, target)
+		}
+	}
+	return due
+}
+
+func (r *memoryNotifications) ListConfirmationTargets(
+	_ context.Context,
+	filter *repository.NotificationTargetFilter,
+	limit int,
+) ([]*model.NotificationTarget, error) {
+	return r.list(filter, r.confirmations, limit), nil
+}
+
+func (r *memoryNotifications) ListReminderTargets(
+	_ context.Context,
+	filter *repository.NotificationTargetFilter,
+	offset time.Duration,
+	limit int,
+) ([]*model.NotificationTarget, error) {
+	return r.list(filter, r.reminders[offset], limit), nil
+}
+
+func (r *memoryNotifications) ClaimNotification(
+	_ context.Context,
+	n *model.Notification,
+	maxAttempts int,
+	claimedBefore time.Time,
+) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := notificationKey(n.BookingID, n.Kind)
+	stored, ok := r.notifications[key]
+	if !ok {
+		stored = &model.Notification{ID: n.ID, BookingID: n.BookingID, Kind: n.Kind, CreatedAt: n.CreatedAt}
+		r.notifications[key] = stored
+	} else if stored.Attempts >= maxAttempts || !(stored.Status == model.NotificationStatusFailed ||
+		(stored.Status == model.NotificationStatusSending && !stored.ClaimedAt.After(claimedBefore))) {
+		return false, nil
+	}
+	claimedAt := n.CreatedAt
+	stored.Status = model.NotificationStatusSending
+	stored.Channel, stored.Recipient = n.Channel, n.Recipient
+	stored.Attempts++
+	stored.Error = nil
+	stored.UpdatedAt, stored.ClaimedAt = claimedAt, &claimedAt
+	n.ID, n.Attempts, n.Status, n.ClaimedAt = stored.ID, stored.Attempts, stored.Status, stored.ClaimedAt
+	return true, nil
+}
+
+func (r *memoryNotifications) FinishNotification(
+	_ context.Context,
+	id uuid.UUID,
+	status model.NotificationStatus,
+	sendErr *string,
+	at time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range r.notifications {
+		if n.ID != id {
+			continue
+		}
+		n.Status, n.Error, n.UpdatedAt = status, sendErr, at
+		if status == model.NotificationStatusSent {
+			n.SentAt = &at
+		}
+		return nil
+	}
+	return errors.New("notification not found")
+}
+
+// recordingSender keeps the messages it sent and fails those to the
+// recipients listed.
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []*notification.Message
+	fail map[string]bool
+}
+
+func (s *recordingSender) Send(_ context.Context, message *notification.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail[message.To] {
+		return errors.New("mailbox unavailable")
+	}
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+func newNotificationService(t *testing.T, repo *memoryNotifications, sender *recordingSender, offsets ...time.Duration) NotificationServiceInterface {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Notification.MaxAttempts = 2
+	cfg.Notification.ReminderOffsets = offsets
+	appLogger := logger.NewApiLogger(cfg)
+	appLogger.InitLogger()
+	templates, err := notification.NewTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewNotificationService(cfg, repo, sender, templates, appLogger)
+}
+
+func notificationTarget(email string) *model.NotificationTarget {
+	target := &model.NotificationTarget{
+		Booking:        model.Booking{ID: uuid.New(), Quantity: 2},
+		EventTitle:     "Concert",
+		EventStartTime: time.Now().Add(24 * time.Hour),
+		EventLocation:  "Hanoi",
+	}
+	if email != "" {
+		target.ContactEmail = &email
+	}
+	return target
+}
+
+func TestSendConfirmations(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryNotifications()
+	ok, failing, anonymous := notificationTarget("ok@example.com"), notificationTarget("down@example.com"), notificationTarget("")
+	repo.confirmations = []*model.NotificationTarget{ok, failing, anonymous}
+	sender := &recordingSender{fail: map[string]bool{"down@example.com": true}}
+	s := newNotificationService(t, repo, sender)
+	kind := string(notification.KindBookingConfirmed)
+
+	if sent, err := s.SendConfirmations(ctx); err != nil || sent != 1 {
+		t.Fatalf("SendConfirmations() = %d, %v, want 1", sent, err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "ok@example.com" || sender.sent[0].Subject == "" {
+		t.Fatalf("sent %+v, want one confirmation to ok@example.com", sender.sent)
+	}
+	if n := repo.get(ok.ID, kind); n.Status != model.NotificationStatusSent || n.SentAt == nil {
+		t.Fatalf("notification = %+v, want sent", n)
+	}
+	failed := repo.get(failing.ID, kind)
+	if failed.Status != model.NotificationStatusFailed || failed.Attempts != 1 || failed.Error == nil {
+		t.Fatalf("notification = %+v, want one failed attempt", failed)
+	}
+	if repo.get(anonymous.ID, kind) != nil {
+		t.Fatal("booking without a contact email was notified")
+	}
+
+	// Sent notifications are not sent again, failed ones wait for the retry
+	// delay and stop once the attempts run out.
+	if sent, err := s.SendConfirmations(ctx); err != nil || sent != 0 {
+		t.Fatalf("SendConfirmations() within the retry delay = %d, %v, want 0", sent, err)
+	}
+	failed.UpdatedAt = failed.UpdatedAt.Add(-notificationRetryDelay)
+	if sent, err := s.SendConfirmations(ctx); err != nil || sent != 0 {
+		t.Fatalf("SendConfirmations() after the retry delay = %d, %v, want 0", sent, err)
+	}
+	if failed.Attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", failed.Attempts)
+	}
+	failed.UpdatedAt = failed.UpdatedAt.Add(-notificationRetryDelay)
+	delete(sender.fail, "down@example.com")
+	if sent, err := s.SendConfirmations(ctx); err != nil || sent != 0 {
+		t.Fatalf("SendConfirmations() without attempts left = %d, %v, want 0", sent, err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sender.sent))
+	}
+}
+
+func TestSendConfirmationsReclaimsAbandonedClaim(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryNotifications()
+	abandoned, running := notificationTarget("a@example.com"), notificationTarget("b@example.com")
+	repo.confirmations = []*model.NotificationTarget{abandoned, running}
+	kind := string(notification.KindBookingConfirmed)
+	for target, claimedAt := range map[*model.NotificationTarget]time.Time{
+		abandoned: time.Now().Add(-notificationClaimLease - time.Minute),
+		running:   time.Now().Add(-time.Minute),
+	} {
+		repo.notifications[notificationKey(target.ID, kind)] = &model.Notification{
+			ID:        uuid.New(),
+			BookingID: target.ID,
+			Kind:      kind,
+			Status:    model.NotificationStatusSending,
+			Attempts:  1,
+			UpdatedAt: claimedAt,
+			ClaimedAt: &claimedAt,
+		}
+	}
+	sender := &recordingSender{}
+	s := newNotificationService(t, repo, sender)
+
+	if sent, err := s.SendConfirmations(ctx); err != nil || sent != 1 {
+		t.Fatalf("SendConfirmations() = %d, %v, want 1", sent, err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "a@example.com" {
+		t.Fatalf("sent %+v, want the abandoned notification only", sender.sent)
+	}
+	if n := repo.get(abandoned.ID, kind); n.Status != model.NotificationStatusSent || n.Attempts != 2 {
+		t.Fatalf("notification = %+v, want sent on the second attempt", n)
+	}
+	if n := repo.get(running.ID, kind); n.Status != model.NotificationStatusSending {
+		t.Fatalf("notification = %+v, want left to its sender", n)
+	}
+}
+
+func TestSendReminders(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryNotifications()
+	day, soon := notificationTarget("day@example.com"), notificationTarget("soon@example.com")
+	repo.reminders[24*time.Hour] = []*model.NotificationTarget{day}
+	repo.reminders[90*time.Minute] = []*model.NotificationTarget{day, soon}
+	sender := &recordingSender{}
+	s := newNotificationService(t, repo, sender, 24*time.Hour, 90*time.Minute)
+
+	if sent, err := s.SendReminders(ctx); err != nil || sent != 3 {
+		t.Fatalf("SendReminders() = %d, %v, want 3", sent, err)
+	}
+	subjects := map[string]bool{}
+	for _, message := range sender.sent {
+		subjects[message.To+" "+message.Subject] = true
+	}
+	for _, want := range []string{
+		"day@example.com Reminder: Concert starts in 1 day",
+		"day@example.com Reminder: Concert starts in 90 minutes",
+		"soon@example.com Reminder: Concert starts in 90 minutes",
+	} {
+		if !subjects[want] {
+			t.Errorf("no message %q in %v", want, subjects)
+		}
+	}
+	if repo.get(day.ID, "event_reminder_24h") == nil || repo.get(day.ID, "event_reminder_90m") == nil {
+		t.Fatal("reminders are not recorded per offset")
+	}
+	if sent, err := s.SendReminders(ctx); err != nil || sent != 0 {
+		t.Fatalf("second SendReminders() = %d, %v, want 0", sent, err)
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := []struct {
+		offset          time.Duration
+		name, described string
+	}{
+		{24 * time.Hour, "24h", "1 day"},
+		{48 * time.Hour, "48h", "2 days"},
+		{time.Hour, "1h", "1 hour"},
+		{3 * time.Hour, "3h", "3 hours"},
+		{90 * time.Minute, "90m", "90 minutes"},
+		{time.Minute, "1m", "1 minute"},
+		{90 * time.Second, "1m30s", "1m30s"},
+	}
+	for _, tt := range tests {
+		if got := formatOffset(tt.offset); got != tt.name {
+			t.Errorf("formatOffset(%v) = %q, want %q", tt.offset, got, tt.name)
+		}
+		if got := describeOffset(tt.offset); got != tt.described {
+			t.Errorf("describeOffset(%v) = %q, want %q", tt.offset, got, tt.described)
+		}
+	}
+}
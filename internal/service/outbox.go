@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/outbox"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+const (
+	defaultOutboxBatchSize  = 100
+	defaultOutboxMaxBackoff = 5 * time.Minute
+	// outboxLease is how long a leased message is hidden from other relays,
+	// it must be longer than publishing a batch takes
+	outboxLease = 30 * time.Second
+	// outboxBaseBackoff is the delay after the first failed delivery, it
+	// doubles with every further attempt up to the configured maximum
+	outboxBaseBackoff = time.Second
+)
+
+type OutboxService struct {
+	outboxRepo repository.OutboxRepositoryInterface
+	publisher  outbox.Publisher
+	logger     logger.Logger
+	batchSize  int
+	maxBackoff time.Duration
+	retention  time.Duration
+}
+
+func NewOutboxService(
+	cfg *config.Config,
+	outboxRepo repository.OutboxRepositoryInterface,
+	publisher outbox.Publisher,
+	logger logger.Logger,
+) OutboxServiceInterface {
+	batchSize := cfg.Outbox.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	maxBackoff := cfg.Outbox.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultOutboxMaxBackoff
+	}
+	return &OutboxService{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		logger:     logger,
+		batchSize:  batchSize,
+		maxBackoff: maxBackoff,
+		retention:  cfg.Outbox.Retention,
+	}
+}
+
+// RelayPending publishes every due outbox message and returns how many were
+// published. A message that fails is retried with exponential backoff, the
+// others of the batch are still attempted, so messages of one aggregate may
+// be delivered out of order after a failure.
+func (s *OutboxService) RelayPending(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		messages, err := s.outboxRepo.LeaseOutboxMessages(ctx, s.batchSize, outboxLease, time.Now())
+		if err != nil {
+			return published, err
+		}
+		for _, message := range messages {
+			if err := s.publisher.Publish(ctx, message); err != nil {
+				s.logger.WithContext(ctx).Errorf("OUTBOX_SERVICE.RELAY_PENDING: message %d (%s) attempt %d: %v",
+					message.ID, message.EventType, message.Attempts+1, err)
+				next := time.Now().Add(s.backoff(message.Attempts + 1))
+				if err := s.outboxRepo.MarkOutboxFailed(ctx, message.ID, err.Error(), next); err != nil {
+					return published, err
+				}
+				continue
+			}
+			// A crash before this point publishes the message again once the
+			// lease ends.
+			if err := s.outboxRepo.MarkOutboxPublished(ctx, message.ID, time.Now()); err != nil {
+				return published, err
+			}
+			published++
+		}
+		if len(messages) < s.batchSize {
+			return published, nil
+		}
+	}
+}
+
+// PurgePublished deletes messages published longer ago than the retention.
+func (s *OutboxService) PurgePublished(ctx context.Context) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.outboxRepo.DeletePublishedOutbox(ctx, time.Now().Add(-s.retention))
+}
+
+// backoff returns the delay before the next delivery of a message that has
+// failed attempts times.
+func (s *OutboxService) backoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.maxBackoff {
+			return s.maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+// memoryOutbox leases, publishes and fails outbox messages the way the
+// Postgres repository does.
+type memoryOutbox struct {
+	mu       sync.Mutex
+	messages []*model.OutboxMessage
+	errors   map[int64]string
+}
+
+func (o *memoryOutbox) add(n int) {
+	for i := 0; i < n; i++ {
+		o.messages = append(o.messages, &model.OutboxMessage{
+			ID:            int64(len(o.messages) + 1),
+			AggregateType: model.AggregateBooking,
+			AggregateID:   uuid.New(),
+			EventType:     model.BookingCreated,
+			Payload:       []byte(`{}`),
+		})
+	}
+}
+
+func (o *memoryOutbox) LeaseOutboxMessages(_ context.Context, limit int, lease time.Duration, now time.Time) ([]*model.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var leased []*model.OutboxMessage
+	for _, message := range o.messages {
+		if len(leased) == limit {
+			break
+		}
+		if message.PublishedAt == nil && !message.NextAttemptAt.After(now) {
+			message.NextAttemptAt = now.Add(lease)
+			copied := *message
+			leased = append(leased, &copied)
+		}
+	}
+	return leased, nil
+}
+
+func (o *memoryOutbox) MarkOutboxPublished(_ context.Context, id int64, at time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages[id-1].PublishedAt = &at
+	return nil
+}
+
+func (o *memoryOutbox) MarkOutboxFailed(_ context.Context, id int64, publishErr string, nextAttemptAt time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	message := o.messages[id-1]
+	message.Attempts++
+	message.LastError = &publishErr
+	message.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (o *memoryOutbox) DeletePublishedOutbox(_ context.Context, before time.Time) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	kept, deleted := o.messages[:0], 0
+	for _, message := range o.messages {
+		if message.PublishedAt != nil && message.PublishedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, message)
+	}
+	o.messages = kept
+	return deleted, nil
+}
+
+// failingPublisher records the published messages and fails those listed.
+type failingPublisher struct {
+	published []int64
+	fail      map[int64]bool
+}
+
+func (p *failingPublisher) Publish(_ context.Context, message *model.OutboxMessage) error {
+	if p.fail[message.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, message.ID)
+	return nil
+}
+
+func newOutboxService(repo *memoryOutbox, publisher *failingPublisher) *OutboxService {
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Outbox.BatchSize = 2
+	cfg.Outbox.MaxBackoff = 5 * time.Second
+	cfg.Outbox.Retention = time.Hour
+	appLogger := logger.NewApiLogger(cfg)
+	appLogger.InitLogger()
+	return NewOutboxService(cfg, repo, publisher, appLogger).(*OutboxService)
+}
+
+func TestRelayPending(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryOutbox{}
+	repo.add(5)
+	publisher := &failingPublisher{fail: map[int64]bool{3: true}}
+	s := newOutboxService(repo, publisher)
+
+	// Every batch is relayed, a failed message does not hold back the others.
+	published, err := s.RelayPending(ctx)
+	if err != nil || published != 4 {
+		t.Fatalf("RelayPending() = %d, %v, want 4", published, err)
+	}
+	if want := []int64{1, 2, 4, 5}; !slices.Equal(publisher.published, want) {
+		t.Fatalf("published %v, want %v", publisher.published, want)
+	}
+	failed := repo.messages[2]
+	if failed.PublishedAt != nil || failed.Attempts != 1 || failed.LastError == nil || *failed.LastError != "broker unavailable" {
+		t.Fatalf("failed message = %+v, want one failed attempt", failed)
+	}
+	if delay := time.Until(failed.NextAttemptAt); delay <= 0 || delay > outboxBaseBackoff {
+		t.Fatalf("failed message retried in %v, want within %v", delay, outboxBaseBackoff)
+	}
+
+	// The failed message waits for its backoff, then is published.
+	if published, err := s.RelayPending(ctx); err != nil || published != 0 {
+		t.Fatalf("RelayPending() during the backoff = %d, %v, want 0", published, err)
+	}
+	delete(publisher.fail, 3)
+	failed.NextAttemptAt = time.Now()
+	if published, err := s.RelayPending(ctx); err != nil || published != 1 {
+		t.Fatalf("RelayPending() after the backoff = %d, %v, want 1", published, err)
+	}
+
+	// Only messages published before the retention are purged.
+	publishedAt := time.Now().Add(-2 * time.Hour)
+	repo.messages[0].PublishedAt = &publishedAt
+	if deleted, err := s.PurgePublished(ctx); err != nil || deleted != 1 {
+		t.Fatalf("PurgePublished() = %d, %v, want 1", deleted, err)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	s := newOutboxService(&memoryOutbox{}, &failingPublisher{})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, delay := range want {
+		if got := s.backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/payment"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultPaymentTimeout   = 15 * time.Minute
+	unpaidBookingsBatchSize = 100
+	// webhookProcessingTimeout is after how long a redelivered webhook whose
+	// first delivery never finished is processed again
+	webhookProcessingTimeout = time.Minute
+)
+
+type PaymentService struct {
+	gateway        payment.Gateway
+	paymentRepo    repository.PaymentRepositoryInterface
+	bookingRepo    repository.BookingRepositoryInterface
+	webhookRepo    repository.WebhookRepositoryInterface
+	logger         logger.Logger
+	redis          *redis.Client
+	paymentTimeout time.Duration
+}
+
+func NewPaymentService(
+	cfg *config.Config,
+	gateway payment.Gateway,
+	paymentRepo repository.PaymentRepositoryInterface,
+	bookingRepo repository.BookingRepositoryInterface,
+	webhookRepo repository.WebhookRepositoryInterface,
+	logger logger.Logger,
+	redis *redis.Client,
+) PaymentServiceInterface {
+	paymentTimeout := cfg.Payment.Timeout
+	if paymentTimeout <= 0 {
+		paymentTimeout = defaultPaymentTimeout
+	}
+	return &PaymentService{
+		gateway:        gateway,
+		paymentRepo:    paymentRepo,
+		bookingRepo:    bookingRepo,
+		webhookRepo:    webhookRepo,
+		logger:         logger,
+		redis:          redis,
+		paymentTimeout: paymentTimeout,
+	}
+}
+
+// CreatePayment authorises the total of the booking, as priced when it was
+// created, with the gateway and records the pending payment.
+func (s *PaymentService) CreatePayment(
+	ctx context.Context,
+	booking *model.Booking,
+	event *model.Event,
+) (_ *dto.PaymentDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.CreatePayment")
+	defer func() { tracing.End(span, err) }()
+	intent, err := s.gateway.CreateIntent(ctx, &payment.IntentRequest{
+		BookingID: booking.ID,
+		Amount:    booking.Total.Amount,
+		Currency:  booking.Total.Currency,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	now := time.Now()
+	p := &model.Payment{
+		ID:               uuid.New(),
+		BookingID:        booking.ID,
+		Provider:         s.gateway.Provider(),
+		ProviderIntentID: intent.ID,
+		Amount:           model.NewMoney(intent.Amount, intent.Currency),
+		Status:           model.PaymentStatusPending,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.paymentRepo.CreatePayment(ctx, p); err != nil {
+		return nil, err
+	}
+
+	paymentDTO := toPaymentDTO(p)
+	paymentDTO.ClientSecret = intent.ClientSecret
+	return paymentDTO, nil
+}
+
+func (s *PaymentService) GetPayment(ctx context.Context, bookingID uuid.UUID) (_ *dto.PaymentDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.GetPayment")
+	defer func() { tracing.End(span, err) }()
+	booking, err := s.bookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckOwner(ctx, booking.UserID); err != nil {
+		return nil, err
+	}
+	p, err := s.paymentRepo.GetPaymentByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentDTO(p), nil
+}
+
+// CapturePayment charges the payment of a booking awaiting payment and
+// confirms the booking. A declined payment cancels the booking.
+func (s *PaymentService) CapturePayment(ctx context.Context, bookingID uuid.UUID) (_ *dto.BookingDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.CapturePayment")
+	defer func() { tracing.End(span, err) }()
+	booking, err := s.bookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckOwner(ctx, booking.UserID); err != nil {
+		return nil, err
+	}
+	if err := booking.Status.TransitionTo(model.BookingStatusConfirmed); err != nil {
+		return nil, err
+	}
+	p, err := s.paymentRepo.GetPaymentByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Status.TransitionTo(model.PaymentStatusSucceeded); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.gateway.Capture(ctx, p.ProviderIntentID); err != nil {
+		if !errors.Is(err, payment.ErrPaymentDeclined) {
+			return nil, fmt.Errorf("failed to capture payment: %w", err)
+		}
+		if _, settleErr := s.settle(ctx, p, model.PaymentStatusFailed, model.BookingStatusCancelled, "payment declined"); settleErr != nil {
+			return nil, settleErr
+		}
+		return nil, err
+	}
+
+	settlement, err := s.settle(ctx, p, model.PaymentStatusSucceeded, model.BookingStatusConfirmed, "payment captured")
+	if err != nil {
+		// The booking expired or was cancelled while the charge went through.
+		s.refundOrphanedCapture(ctx, p)
+		return nil, err
+	}
+	return s.toBookingDTO(settlement), nil
+}
+
+// RefundPayment refunds the captured payment of a cancelled or refund_pending
+// booking.
+func (s *PaymentService) RefundPayment(ctx context.Context, bookingID uuid.UUID) (_ *dto.BookingDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.RefundPayment")
+	defer func() { tracing.End(span, err) }()
+	booking, err := s.bookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := booking.Status.TransitionTo(model.BookingStatusRefunded); err != nil {
+		return nil, err
+	}
+	p, err := s.paymentRepo.GetPaymentByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Status.TransitionTo(model.PaymentStatusRefunded); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.gateway.Refund(ctx, p.ProviderIntentID); err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+	settlement, err := s.settle(ctx, p, model.PaymentStatusRefunded, model.BookingStatusRefunded, "payment refunded")
+	if err != nil {
+		return nil, err
+	}
+	return s.toBookingDTO(settlement), nil
+}
+
+// ExpireUnpaidBookings expires bookings that have been awaiting payment for
+// longer than the payment timeout and returns their tickets to the event.
+func (s *PaymentService) ExpireUnpaidBookings(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.ExpireUnpaidBookings")
+	defer func() { tracing.End(span, err) }()
+	expired := 0
+	for {
+		bookings, err := s.bookingRepo.ListStaleBookings(ctx,
+			model.BookingStatusAwaitingPayment,
+			time.Now().Add(-s.paymentTimeout),
+			unpaidBookingsBatchSize,
+		)
+		if err != nil {
+			return expired, err
+		}
+		for _, booking := range bookings {
+			if err := s.expireBooking(ctx, booking); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+		if len(bookings) < unpaidBookingsBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+func (s *PaymentService) expireBooking(ctx context.Context, booking *model.Booking) error {
+	const reason = "payment timed out"
+	p, err := s.paymentRepo.GetPaymentByBookingID(ctx, booking.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if p != nil && p.Status == model.PaymentStatusPending {
+		_, err := s.settle(ctx, p, model.PaymentStatusFailed, model.BookingStatusExpired, reason)
+		return err
+	}
+	transition, err := s.bookingRepo.TransitionBooking(ctx, booking.ID, model.BookingStatusExpired, reason, time.Now())
+	if err != nil {
+		return err
+	}
+	afterBookingTransition(ctx, s.redis, s.logger, transition)
+	return nil
+}
+
+// HandleWebhook authenticates a provider notification, records it once per
+// provider event id and applies it to the payment and its booking. Events are
+// checked against the payment state machine, so redeliveries and events
+// arriving out of order are recorded as ignored instead of being applied.
+func (s *PaymentService) HandleWebhook(
+	ctx context.Context,
+	payload []byte,
+	header http.Header,
+) (_ *dto.WebhookEventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.HandleWebhook")
+	defer func() { tracing.End(span, err) }()
+	parsed, err := s.gateway.ParseWebhook(payload, header)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &model.WebhookEvent{
+		ID:         uuid.New(),
+		Provider:   s.gateway.Provider(),
+		EventID:    parsed.ID,
+		EventType:  string(parsed.Type),
+		IntentID:   parsed.IntentID,
+		Payload:    payload,
+		Status:     model.WebhookEventStatusReceived,
+		ReceivedAt: time.Now(),
+	}
+	inserted, err := s.webhookRepo.RecordWebhookEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if !inserted {
+		if event.Status.Done() {
+			return toWebhookEventDTO(event), nil
+		}
+		if event.Status == model.WebhookEventStatusReceived &&
+			time.Since(event.ReceivedAt) < webhookProcessingTimeout {
+			return nil, ErrWebhookInProgress
+		}
+	}
+
+	status, processingErr := s.applyWebhook(ctx, parsed)
+	var message *string
+	if processingErr != nil {
+		text := processingErr.Error()
+		message = &text
+	}
+	now := time.Now()
+	if err := s.webhookRepo.FinishWebhookEvent(ctx, event.ID, status, message, now); err != nil {
+		return nil, err
+	}
+	if status == model.WebhookEventStatusFailed {
+		return nil, processingErr
+	}
+	event.Status = status
+	event.Error = message
+	event.ProcessedAt = &now
+	return toWebhookEventDTO(event), nil
+}
+
+// applyWebhook returns the status to record for the event. Events that no
+// longer apply are ignored, the error then explains why.
+func (s *PaymentService) applyWebhook(
+	ctx context.Context,
+	event *payment.WebhookEvent,
+) (model.WebhookEventStatus, error) {
+	p, err := s.paymentRepo.GetPaymentByIntentID(ctx, s.gateway.Provider(), event.IntentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.WebhookEventStatusIgnored, err
+	}
+	if err != nil {
+		return model.WebhookEventStatusFailed, err
+	}
+
+	switch event.Type {
+	case payment.WebhookPaymentSucceeded:
+		if p.Status == model.PaymentStatusFailed {
+			// The booking expired before the money arrived.
+			s.refundOrphanedCapture(ctx, p)
+			return model.WebhookEventStatusIgnored, &model.ErrInvalidPaymentTransition{From: p.Status, To: model.PaymentStatusSucceeded}
+		}
+		_, err = s.settle(ctx, p, model.PaymentStatusSucceeded, model.BookingStatusConfirmed, "payment succeeded")
+		var invalidTransition *model.ErrInvalidTransition
+		if errors.As(err, &invalidTransition) {
+			// The booking was cancelled before the money arrived.
+			s.refundOrphanedCapture(ctx, p)
+		}
+	case payment.WebhookPaymentFailed:
+		_, err = s.settle(ctx, p, model.PaymentStatusFailed, model.BookingStatusCancelled, "payment failed")
+	case payment.WebhookRefundSucceeded:
+		_, err = s.settle(ctx, p, model.PaymentStatusRefunded, model.BookingStatusRefunded, "payment refunded")
+	default:
+		return model.WebhookEventStatusIgnored, fmt.Errorf("unsupported webhook event type %q", event.Type)
+	}
+
+	var invalidTransition *model.ErrInvalidTransition
+	var invalidPaymentTransition *model.ErrInvalidPaymentTransition
+	switch {
+	case err == nil:
+		return model.WebhookEventStatusProcessed, nil
+	case errors.As(err, &invalidTransition), errors.As(err, &invalidPaymentTransition):
+		return model.WebhookEventStatusIgnored, err
+	default:
+		return model.WebhookEventStatusFailed, err
+	}
+}
+
+func (s *PaymentService) GetWebhookEvent(ctx context.Context, id uuid.UUID) (_ *dto.WebhookEventDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.GetWebhookEvent")
+	defer func() { tracing.End(span, err) }()
+	event, err := s.webhookRepo.GetWebhookEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookEventDTO(event), nil
+}
+
+func (s *PaymentService) ListWebhookEvents(
+	ctx context.Context,
+	filter *dto.WebhookEventFilterDTO,
+	pagination *utils.Pagination,
+) (_ *dto.WebhookEventListDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.ListWebhookEvents")
+	defer func() { tracing.End(span, err) }()
+	events, total, err := s.webhookRepo.ListWebhookEvents(ctx, &repository.WebhookEventFilter{
+		Provider:  filter.Provider,
+		EventType: filter.EventType,
+		IntentID:  filter.IntentID,
+		Status:    model.WebhookEventStatus(filter.Status),
+		From:      filter.From,
+		To:        filter.To,
+	}, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &dto.WebhookEventListDTO{
+		Events:     make([]*dto.WebhookEventDTO, 0, len(events)),
+		TotalCount: total,
+		TotalPages: pagination.GetTotalPages(total),
+		Page:       pagination.GetPage(),
+		Size:       pagination.GetSize(),
+		HasMore:    pagination.GetHasMore(total),
+	}
+	for _, event := range events {
+		list.Events = append(list.Events, toWebhookEventDTO(event))
+	}
+	return list, nil
+}
+
+func (s *PaymentService) settle(
+	ctx context.Context,
+	p *model.Payment,
+	status model.PaymentStatus,
+	bookingStatus model.BookingStatus,
+	reason string,
+) (*model.PaymentSettlement, error) {
+	settlement, err := s.paymentRepo.SettlePayment(ctx, p.ID, status, bookingStatus, reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	afterBookingTransition(ctx, s.redis, s.logger, settlement.Transition)
+	return settlement, nil
+}
+
+// refundOrphanedCapture gives the money back for a charge whose booking can
+// no longer be confirmed.
+func (s *PaymentService) refundOrphanedCapture(ctx context.Context, p *model.Payment) {
+	if _, err := s.gateway.Refund(ctx, p.ProviderIntentID); err != nil {
+		s.logger.WithContext(ctx).Errorf("PAYMENT_SERVICE.REFUND_ORPHANED_CAPTURE: intent %s of booking %s: %v",
+			p.ProviderIntentID, p.BookingID, err)
+	}
+}
+
+func (s *PaymentService) toBookingDTO(settlement *model.PaymentSettlement) *dto.BookingDTO {
+	bookingDTO := toBookingDTO(settlement.Transition.Booking)
+	bookingDTO.Payment = toPaymentDTO(settlement.Payment)
+	return bookingDTO
+}
+
+func toPaymentDTO(p *model.Payment) *dto.PaymentDTO {
+	return &dto.PaymentDTO{
+		ID:        p.ID,
+		BookingID: p.BookingID,
+		Provider:  p.Provider,
+		IntentID:  p.ProviderIntentID,
+		Amount:    toMoneyDTO(p.Amount),
+		Status:    string(p.Status),
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func toWebhookEventDTO(event *model.WebhookEvent) *dto.WebhookEventDTO {
+	return &dto.WebhookEventDTO{
+		ID:          event.ID,
+		Provider:    event.Provider,
+		EventID:     event.EventID,
+		EventType:   event.EventType,
+		IntentID:    event.IntentID,
+		Payload:     event.Payload,
+		Status:      string(event.Status),
+		Error:       event.Error,
+		ReceivedAt:  event.ReceivedAt,
+		ProcessedAt: event.ProcessedAt,
+	}
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/payment"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// paymentFlow wires the booking and payment services to the fake gateway,
+// an in-memory repository and miniredis, with one published event of a
+// single ticket type.
+type paymentFlow struct {
+	cfg        *config.Config
+	redis      *redis.Client
+	repo       *memoryRepository
+	gateway    *payment.FakeGateway
+	payments   PaymentServiceInterface
+	bookings   BookingServiceInterface
+	events     EventServiceInterface
+	event      *model.Event
+	ticketType *model.TicketType
+}
+
+func newPaymentFlow(t *testing.T) *paymentFlow {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	cfg.Payment.Timeout = time.Minute
+	appLogger := logger.NewApiLogger(cfg)
+	appLogger.InitLogger()
+
+	ticketType := &model.TicketType{
+		ID:               uuid.New(),
+		Name:             model.DefaultTicketTypeName,
+		Price:            model.NewMoney(2500, "USD"),
+		Capacity:         10,
+		AvailableTickets: 10,
+	}
+	event := &model.Event{
+		ID:               uuid.New(),
+		Title:            "Concert",
+		Capacity:         10,
+		AvailableTickets: 10,
+		Price:            ticketType.Price,
+		StartTime:        time.Now().Add(24 * time.Hour),
+		Status:           model.EventStatusPublished,
+		TicketTypes:      []*model.TicketType{ticketType},
+	}
+	ticketType.EventID = event.ID
+	repo := newMemoryRepository()
+	repo.addEvent(event)
+
+	gateway := payment.NewFakeGateway(testWebhookSecret, 5*time.Minute)
+	payments := NewPaymentService(cfg, gateway, repo, repo, repo, appLogger, client)
+	return &paymentFlow{
+		cfg:        cfg,
+		redis:      client,
+		repo:       repo,
+		gateway:    gateway,
+		payments:   payments,
+		bookings:   NewBookingService(cfg, repo, repo, nil, payments, appLogger, client),
+		events:     NewEventService(repo, appLogger, client),
+		event:      event,
+		ticketType: ticketType,
+	}
+}
+
+// book holds quantity tickets for a new user and checks the hold out, which
+// leaves a booking awaiting payment.
+func (f *paymentFlow) book(t *testing.T, quantity int) (context.Context, *dto.BookingDTO) {
+	t.Helper()
+	userID := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{
+		UserID: userID,
+		Roles:  []string{auth.RoleCustomer},
+	})
+	event, err := f.repo.GetEventByID(ctx, f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncAvailability(ctx, f.redis, event, inventoryModeSeed); err != nil {
+		t.Fatalf("syncAvailability() = %v", err)
+	}
+	items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: quantity}}
+	result, err := reserveHold(ctx, f.redis, f.event.ID, userID, items, time.Minute)
+	if err != nil || result != HoldResultOK {
+		t.Fatalf("reserveHold() = %v, %v, want %v", result, err, HoldResultOK)
+	}
+
+	booking, err := f.bookings.CreateBooking(ctx, &dto.CreateBookingDTO{
+		EventID:  f.event.ID,
+		UserID:   userID,
+		Quantity: quantity,
+	})
+	if err != nil {
+		t.Fatalf("CreateBooking() = %v", err)
+	}
+	if booking.Status != string(model.BookingStatusAwaitingPayment) {
+		t.Fatalf("booking status = %s, want %s", booking.Status, model.BookingStatusAwaitingPayment)
+	}
+	if booking.Payment == nil || booking.Payment.Status != string(model.PaymentStatusPending) {
+		t.Fatalf("booking payment = %+v, want a pending payment", booking.Payment)
+	}
+	if exists := f.redis.Exists(ctx, holdKey(f.event.ID, userID)).Val(); exists != 0 {
+		t.Fatalf("hold still exists after checkout")
+	}
+	return ctx, booking
+}
+
+// webhook sends a signed notification about the intent.
+func (f *paymentFlow) webhook(
+	ctx context.Context,
+	eventType payment.WebhookEventType,
+	intentID string,
+) (*dto.WebhookEventDTO, error) {
+	return f.webhookWithID(ctx, "evt_"+uuid.NewString(), eventType, intentID)
+}
+
+func (f *paymentFlow) webhookWithID(
+	ctx context.Context,
+	id string,
+	eventType payment.WebhookEventType,
+	intentID string,
+) (*dto.WebhookEventDTO, error) {
+	payload := []byte(fmt.Sprintf(`{"id":%q,"type":%q,"intent_id":%q}`, id, eventType, intentID))
+	header := http.Header{}
+	header.Set(payment.SignatureHeader, f.gateway.SignWebhook(payload))
+	return f.payments.HandleWebhook(ctx, payload, header)
+}
+
+func (f *paymentFlow) available(t *testing.T) int {
+	t.Helper()
+	count, err := f.redis.HGet(context.Background(), availableKey(f.event.ID), f.ticketType.ID.String()).Int()
+	if err != nil {
+		t.Fatalf("failed to read availability: %v", err)
+	}
+	return count
+}
+
+func (f *paymentFlow) assertStatus(
+	t *testing.T,
+	bookingID uuid.UUID,
+	bookingStatus model.BookingStatus,
+	paymentStatus model.PaymentStatus,
+) {
+	t.Helper()
+	booking, err := f.repo.GetBookingByID(context.Background(), bookingID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := f.repo.GetPaymentByBookingID(context.Background(), bookingID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.Status != bookingStatus || p.Status != paymentStatus {
+		t.Fatalf("booking %s with payment %s, want booking %s with payment %s",
+			booking.Status, p.Status, bookingStatus, paymentStatus)
+	}
+}
+
+func (f *paymentFlow) assertIntent(t *testing.T, intentID string, status payment.IntentStatus) {
+	t.Helper()
+	intent, ok := f.gateway.Intent(intentID)
+	if !ok {
+		t.Fatalf("intent %s not found", intentID)
+	}
+	if intent.Status != status {
+		t.Fatalf("intent status = %s, want %s", intent.Status, status)
+	}
+}
+
+func TestCapturePaymentConfirmsBooking(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx, booking := f.book(t, 2)
+	if got := f.available(t); got != 8 {
+		t.Fatalf("available after checkout = %d, want 8", got)
+	}
+	if booking.Payment.Amount.Amount != 5000 || booking.Payment.Amount.Currency != "USD" {
+		t.Fatalf("payment amount = %+v, want 5000 USD", booking.Payment.Amount)
+	}
+
+	confirmed, err := f.payments.CapturePayment(ctx, booking.ID)
+	if err != nil {
+		t.Fatalf("CapturePayment() = %v", err)
+	}
+	if confirmed.Status != string(model.BookingStatusConfirmed) {
+		t.Fatalf("booking status = %s, want %s", confirmed.Status, model.BookingStatusConfirmed)
+	}
+	if confirmed.Payment.Status != string(model.PaymentStatusSucceeded) {
+		t.Fatalf("payment status = %s, want %s", confirmed.Payment.Status, model.PaymentStatusSucceeded)
+	}
+	f.assertStatus(t, booking.ID, model.BookingStatusConfirmed, model.PaymentStatusSucceeded)
+	f.assertIntent(t, booking.Payment.IntentID, payment.IntentStatusSucceeded)
+	if got := f.available(t); got != 8 {
+		t.Fatalf("available after capture = %d, want 8", got)
+	}
+
+	if _, err := f.payments.CapturePayment(ctx, booking.ID); !errors.As(err, new(*model.ErrInvalidTransition)) {
+		t.Fatalf("second CapturePayment() = %v, want *model.ErrInvalidTransition", err)
+	}
+}
+
+func TestCapturePaymentDeclinedCancelsBooking(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx, booking := f.book(t, 3)
+	f.gateway.Decline(booking.Payment.IntentID)
+
+	if _, err := f.payments.CapturePayment(ctx, booking.ID); !errors.Is(err, payment.ErrPaymentDeclined) {
+		t.Fatalf("CapturePayment() = %v, want %v", err, payment.ErrPaymentDeclined)
+	}
+	f.assertStatus(t, booking.ID, model.BookingStatusCancelled, model.PaymentStatusFailed)
+	if got := f.available(t); got != 10 {
+		t.Fatalf("available after decline = %d, want 10", got)
+	}
+}
+
+func TestCapturePaymentOfAnotherUser(t *testing.T) {
+	f := newPaymentFlow(t)
+	_, booking := f.book(t, 1)
+	otherCtx, _ := f.book(t, 1)
+
+	if _, err := f.payments.CapturePayment(otherCtx, booking.ID); !errors.Is(err, auth.ErrNotOwner) {
+		t.Fatalf("CapturePayment() = %v, want %v", err, auth.ErrNotOwner)
+	}
+	f.assertStatus(t, booking.ID, model.BookingStatusAwaitingPayment, model.PaymentStatusPending)
+}
+
+func TestCancelBookingRefundsPaidBooking(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx, booking := f.book(t, 2)
+	if _, err := f.payments.CapturePayment(ctx, booking.ID); err != nil {
+		t.Fatalf("CapturePayment() = %v", err)
+	}
+
+	cancelled, err := f.bookings.CancelBooking(ctx, booking.ID, &dto.CancelBookingDTO{Reason: "cannot attend"})
+	if err != nil {
+		t.Fatalf("CancelBooking() = %v", err)
+	}
+	if cancelled.Status != string(model.BookingStatusRefunded) {
+		t.Fatalf("booking status = %s, want %s", cancelled.Status, model.BookingStatusRefunded)
+	}
+	f.assertStatus(t, booking.ID, model.BookingStatusRefunded, model.PaymentStatusRefunded)
+	f.assertIntent(t, booking.Payment.IntentID, payment.IntentStatusRefunded)
+	if got := f.available(t); got != 10 {
+		t.Fatalf("available after cancel = %d, want 10", got)
+	}
+}
+
+func TestCancelBookingOfUnpaidBooking(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx, booking := f.book(t, 2)
+
+	cancelled, err := f.bookings.CancelBooking(ctx, booking.ID, &dto.CancelBookingDTO{Reason: "changed plans"})
+	if err != nil {
+		t.Fatalf("CancelBooking() = %v", err)
+	}
+	if cancelled.Status != string(model.BookingStatusCancelled) {
+		t.Fatalf("booking status = %s, want %s", cancelled.Status, model.BookingStatusCancelled)
+	}
+	f.assertStatus(t, booking.ID, model.BookingStatusCancelled, model.PaymentStatusPending)
+	if got := f.available(t); got != 10 {
+		t.Fatalf("available after cancel = %d, want 10", got)
+	}
+}
+
+func TestHandleWebhook(t *testing.T) {
+	t.Run("payment succeeded confirms the booking once", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		ctx, booking := f.book(t, 2)
+
+		event, err := f.webhookWithID(ctx, "evt_1", payment.WebhookPaymentSucceeded, booking.Payment.IntentID)
+		if err != nil {
+			t.Fatalf("HandleWebhook() = %v", err)
+		}
+		if event.Status != string(model.WebhookEventStatusProcessed) {
+			t.Fatalf("webhook status = %s, want %s", event.Status, model.WebhookEventStatusProcessed)
+		}
+		f.assertStatus(t, booking.ID, model.BookingStatusConfirmed, model.PaymentStatusSucceeded)
+
+		redelivered, err := f.webhookWithID(ctx, "evt_1", payment.WebhookPaymentSucceeded, booking.Payment.IntentID)
+		if err != nil {
+			t.Fatalf("redelivered HandleWebhook() = %v", err)
+		}
+		if redelivered.ID != event.ID || redelivered.Status != event.Status {
+			t.Fatalf("redelivery = %+v, want the recorded event %+v", redelivered, event)
+		}
+	})
+
+	t.Run("payment failed cancels the booking", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		ctx, booking := f.book(t, 4)
+
+		event, err := f.webhook(ctx, payment.WebhookPaymentFailed, booking.Payment.IntentID)
+		if err != nil {
+			t.Fatalf("HandleWebhook() = %v", err)
+		}
+		if event.Status != string(model.WebhookEventStatusProcessed) {
+			t.Fatalf("webhook status = %s, want %s", event.Status, model.WebhookEventStatusProcessed)
+		}
+		f.assertStatus(t, booking.ID, model.BookingStatusCancelled, model.PaymentStatusFailed)
+		if got := f.available(t); got != 10 {
+			t.Fatalf("available after failed payment = %d, want 10", got)
+		}
+	})
+
+	t.Run("out of order event is ignored", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		ctx, booking := f.book(t, 1)
+
+		event, err := f.webhook(ctx, payment.WebhookRefundSucceeded, booking.Payment.IntentID)
+		if err != nil {
+			t.Fatalf("HandleWebhook() = %v", err)
+		}
+		if event.Status != string(model.WebhookEventStatusIgnored) || event.Error == nil {
+			t.Fatalf("webhook = %+v, want ignored with a reason", event)
+		}
+		f.assertStatus(t, booking.ID, model.BookingStatusAwaitingPayment, model.PaymentStatusPending)
+	})
+
+	t.Run("unknown intent is ignored", func(t *testing.T) {
+		f := newPaymentFlow(t)
+
+		event, err := f.webhook(context.Background(), payment.WebhookPaymentSucceeded, "pi_unknown")
+		if err != nil {
+			t.Fatalf("HandleWebhook() = %v", err)
+		}
+		if event.Status != string(model.WebhookEventStatusIgnored) {
+			t.Fatalf("webhook status = %s, want %s", event.Status, model.WebhookEventStatusIgnored)
+		}
+	})
+}
+
+func TestHandleWebhookRejectsUntrustedPayloads(t *testing.T) {
+	f := newPaymentFlow(t)
+	valid := []byte(`{"id":"evt_1","type":"payment.succeeded","intent_id":"pi_1"}`)
+	tests := []struct {
+		name    string
+		payload []byte
+		// header is the signature, sign signs the payload with the secret
+		// of the gateway instead
+		header string
+		sign   bool
+		want   error
+	}{
+		{
+			name:    "missing signature",
+			payload: valid,
+			want:    payment.ErrInvalidSignature,
+		},
+		{
+			name:    "signed with another secret",
+			payload: valid,
+			header:  payment.SignPayload(valid, "whsec_other", time.Now()),
+			want:    payment.ErrInvalidSignature,
+		},
+		{
+			name:    "payload changed after signing",
+			payload: []byte(`{"id":"evt_1","type":"payment.succeeded","intent_id":"pi_2"}`),
+			header:  payment.SignPayload(valid, testWebhookSecret, time.Now()),
+			want:    payment.ErrInvalidSignature,
+		},
+		{
+			name:    "stale timestamp",
+			payload: valid,
+			header:  payment.SignPayload(valid, testWebhookSecret, time.Now().Add(-time.Hour)),
+			want:    payment.ErrStaleWebhook,
+		},
+		{
+			name:    "malformed json",
+			payload: []byte(`{"id":`),
+			sign:    true,
+			want:    payment.ErrInvalidWebhook,
+		},
+		{
+			name:    "missing intent",
+			payload: []byte(`{"id":"evt_1","type":"payment.succeeded"}`),
+			sign:    true,
+			want:    payment.ErrInvalidWebhook,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			signature := tt.header
+			if tt.sign {
+				signature = f.gateway.SignWebhook(tt.payload)
+			}
+			if signature != "" {
+				header.Set(payment.SignatureHeader, signature)
+			}
+			if _, err := f.payments.HandleWebhook(context.Background(), tt.payload, header); !errors.Is(err, tt.want) {
+				t.Fatalf("HandleWebhook() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookRefundsOrphanedCapture(t *testing.T) {
+	t.Run("booking expired before the payment arrived", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		ctx, booking := f.book(t, 2)
+		// The customer pays at the provider, the notification is late.
+		if _, err := f.gateway.Capture(ctx, booking.Payment.IntentID); err != nil {
+			t.Fatal(err)
+		}
+		f.repo.mu.Lock()
+		f.repo.bookings[booking.ID].UpdatedAt = time.Now().Add(-time.Hour)
+		f.repo.mu.Unlock()
+		expired, err := f.payments.ExpireUnpaidBookings(ctx)
+		if err != nil || expired != 1 {
+			t.Fatalf("ExpireUnpaidBookings() = %d, %v, want 1", expired, err)
+		}
+		f.assertStatus(t, booking.ID, model.BookingStatusExpired, model.PaymentStatusFailed)
+
+		event, err := f.webhook(ctx, payment.WebhookPaymentSucceeded, booking.Payment.IntentID)
+		if err != nil {
+			t.Fatalf("HandleWebhook() = %v", err)
+		}
+		if event.Status != string(model.WebhookEventStatusIgnored) {
+			t.Fatalf("webhook status = %s, want %s", event.Status, model.WebhookEventStatusIgnored)
+		}
+		f.assertIntent(t, booking.Payment.IntentID, payment.IntentStatusRefunded)
+		f.assertStatus(t, booking.ID, model.BookingStatusExpired, model.PaymentStatusFailed)
+		if got := f.available(t); got != 10 {
+			t.Fatalf("available after expiry = %d, want 10", got)
+		}
+	})
+
+	t.Run("booking cancelled before the payment arrived", func(t *testing.T) {
+		f := newPaymentFlow(t)
+		ctx, booking := f.book(t, 2)
+		if _, err := f.gateway.Capture(ctx, booking.Payment.IntentID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.repo.TransitionBooking(ctx, booking.ID, model.BookingStatusCancelled, "changed my mind", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		event, err := f.webhook(ctx, payment.WebhookPaymentSucceeded, booking.Payment.IntentID)
+		if err != nil {
+			t.Fatalf("HandleWebhook() = %v", err)
+		}
+		if event.Status != string(model.WebhookEventStatusIgnored) {
+			t.Fatalf("webhook status = %s, want %s", event.Status, model.WebhookEventStatusIgnored)
+		}
+		f.assertIntent(t, booking.Payment.IntentID, payment.IntentStatusRefunded)
+		f.assertStatus(t, booking.ID, model.BookingStatusCancelled, model.PaymentStatusPending)
+	})
+}
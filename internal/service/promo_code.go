@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+type PromoCodeService struct {
+	promoRepo repository.PromoCodeRepositoryInterface
+	eventRepo repository.EventRepositoryInterface
+}
+
+func NewPromoCodeService(
+	promoRepo repository.PromoCodeRepositoryInterface,
+	eventRepo repository.EventRepositoryInterface,
+) PromoCodeServiceInterface {
+	return &PromoCodeService{promoRepo: promoRepo, eventRepo: eventRepo}
+}
+
+// CreatePromoCode creates a code, active unless stated otherwise. A fixed code
+// scoped to an event must be in the currency of the event. Organizers create
+// codes for their own events, global codes take an admin.
+func (s *PromoCodeService) CreatePromoCode(ctx context.Context, promoDTO *dto.CreatePromoCodeDTO) (_ *dto.PromoCodeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PromoCodeService.CreatePromoCode")
+	defer func() { tracing.End(span, err) }()
+	now := time.Now()
+	promo := &model.PromoCode{
+		ID:                    uuid.New(),
+		Code:                  model.NormalizePromoCode(promoDTO.Code),
+		EventID:               promoDTO.EventID,
+		DiscountType:          model.DiscountType(promoDTO.DiscountType),
+		MaxRedemptions:        promoDTO.MaxRedemptions,
+		MaxRedemptionsPerUser: promoDTO.MaxRedemptionsPerUser,
+		MinQuantity:           promoDTO.MinQuantity,
+		ValidFrom:             promoDTO.ValidFrom,
+		ValidUntil:            promoDTO.ValidUntil,
+		Active:                promoDTO.Active == nil || *promoDTO.Active,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	switch promo.DiscountType {
+	case model.DiscountTypePercentage:
+		promo.PercentOff = promoDTO.PercentOff
+	case model.DiscountTypeFixed:
+		if promoDTO.AmountOff != nil {
+			promo.AmountOff = toMoney(*promoDTO.AmountOff)
+		}
+	}
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+	if promo.EventID == nil {
+		if err := auth.CheckRole(ctx, auth.RoleAdmin); err != nil {
+			return nil, err
+		}
+	} else {
+		event, err := s.eventRepo.GetEventByID(ctx, *promo.EventID)
+		if err != nil {
+			return nil, err
+		}
+		if err := auth.CheckOrganizer(ctx, event.OrganizerId); err != nil {
+			return nil, err
+		}
+		if promo.DiscountType == model.DiscountTypeFixed && promo.AmountOff.Currency != event.Price.Currency {
+			return nil, model.ErrCurrencyMismatch
+		}
+	}
+
+	if err := s.promoRepo.CreatePromoCode(ctx, promo); err != nil {
+		return nil, err
+	}
+	return toPromoCodeDTO(promo), nil
+}
+
+func (s *PromoCodeService) GetPromoCode(ctx context.Context, id uuid.UUID) (_ *dto.PromoCodeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PromoCodeService.GetPromoCode")
+	defer func() { tracing.End(span, err) }()
+	promo, err := s.promoRepo.GetPromoCodeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeEventScope(ctx, promo.EventID); err != nil {
+		return nil, err
+	}
+	return toPromoCodeDTO(promo), nil
+}
+
+// ListPromoCodes lists the codes of an event to its organizer. Listing across
+// events takes an admin.
+func (s *PromoCodeService) ListPromoCodes(
+	ctx context.Context,
+	filter *dto.PromoCodeFilterDTO,
+	pagination *utils.Pagination,
+) (_ *dto.PromoCodeListDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PromoCodeService.ListPromoCodes")
+	defer func() { tracing.End(span, err) }()
+	var eventID *uuid.UUID
+	if filter.EventID != uuid.Nil {
+		eventID = &filter.EventID
+	}
+	if err := s.authorizeEventScope(ctx, eventID); err != nil {
+		return nil, err
+	}
+	promos, total, err := s.promoRepo.ListPromoCodes(ctx, &repository.PromoCodeFilter{
+		EventID: filter.EventID,
+		Active:  filter.Active,
+	}, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &dto.PromoCodeListDTO{
+		PromoCodes: make([]*dto.PromoCodeDTO, 0, len(promos)),
+		TotalCount: total,
+		TotalPages: pagination.GetTotalPages(total),
+		Page:       pagination.GetPage(),
+		Size:       pagination.GetSize(),
+		HasMore:    pagination.GetHasMore(total),
+	}
+	for _, promo := range promos {
+		list.PromoCodes = append(list.PromoCodes, toPromoCodeDTO(promo))
+	}
+	return list, nil
+}
+
+func (s *PromoCodeService) UpdatePromoCode(
+	ctx context.Context,
+	id uuid.UUID,
+	promoDTO *dto.UpdatePromoCodeDTO,
+) (_ *dto.PromoCodeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "PromoCodeService.UpdatePromoCode")
+	defer func() { tracing.End(span, err) }()
+	promo, err := s.promoRepo.GetPromoCodeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeEventScope(ctx, promo.EventID); err != nil {
+		return nil, err
+	}
+	if promoDTO.MaxRedemptions != nil {
+		promo.MaxRedemptions = *promoDTO.MaxRedemptions
+	}
+	if promoDTO.MaxRedemptionsPerUser != nil {
+		promo.MaxRedemptionsPerUser = *promoDTO.MaxRedemptionsPerUser
+	}
+	if promoDTO.MinQuantity != nil {
+		promo.MinQuantity = *promoDTO.MinQuantity
+	}
+	if promoDTO.ValidFrom != nil {
+		promo.ValidFrom = promoDTO.ValidFrom
+	}
+	if promoDTO.ValidUntil != nil {
+		promo.ValidUntil = promoDTO.ValidUntil
+	}
+	if promoDTO.Active != nil {
+		promo.Active = *promoDTO.Active
+	}
+	promo.UpdatedAt = time.Now()
+	if err := promo.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.promoRepo.UpdatePromoCode(ctx, promo); err != nil {
+		return nil, err
+	}
+	return toPromoCodeDTO(promo), nil
+}
+
+// authorizeEventScope allows the organizer of the event to manage its codes.
+// Codes of every event, a nil eventID, take an admin.
+func (s *PromoCodeService) authorizeEventScope(ctx context.Context, eventID *uuid.UUID) error {
+	if eventID == nil {
+		return auth.CheckRole(ctx, auth.RoleAdmin)
+	}
+	event, err := s.eventRepo.GetEventByID(ctx, *eventID)
+	if err != nil {
+		return err
+	}
+	return auth.CheckOrganizer(ctx, event.OrganizerId)
+}
+
+func toPromoCodeDTO(promo *model.PromoCode) *dto.PromoCodeDTO {
+	promoDTO := &dto.PromoCodeDTO{
+		ID:                    promo.ID,
+		Code:                  promo.Code,
+		EventID:               promo.EventID,
+		DiscountType:          string(promo.DiscountType),
+		PercentOff:            promo.PercentOff,
+		MaxRedemptions:        promo.MaxRedemptions,
+		MaxRedemptionsPerUser: promo.MaxRedemptionsPerUser,
+		RedemptionCount:       promo.RedemptionCount,
+		MinQuantity:           promo.MinQuantity,
+		ValidFrom:             promo.ValidFrom,
+		ValidUntil:            promo.ValidUntil,
+		Active:                promo.Active,
+		CreatedAt:             promo.CreatedAt,
+		UpdatedAt:             promo.UpdatedAt,
+	}
+	if promo.DiscountType == model.DiscountTypeFixed {
+		amountOff := toMoneyDTO(promo.AmountOff)
+		promoDTO.AmountOff = &amountOff
+	}
+	return promoDTO
+}
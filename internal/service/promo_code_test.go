@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+)
+
+// memoryPromoCodes looks codes up by their normalized form and counts the
+// redemptions per user.
+type memoryPromoCodes struct {
+	repository.PromoCodeRepositoryInterface
+	codes       map[string]*model.PromoCode
+	redemptions map[uuid.UUID]int
+}
+
+func (r *memoryPromoCodes) GetPromoCodeByCode(_ context.Context, code string) (*model.PromoCode, error) {
+	promo, ok := r.codes[model.NormalizePromoCode(code)]
+	if !ok {
+		return nil, model.ErrInvalidPromoCode
+	}
+	copied := *promo
+	return &copied, nil
+}
+
+func (r *memoryPromoCodes) CountUserRedemptions(_ context.Context, _ uuid.UUID, userID uuid.UUID) (int, error) {
+	return r.redemptions[userID], nil
+}
+
+func TestCheckPromoCode(t *testing.T) {
+	ctx := context.Background()
+	eventID, regular, loyal := uuid.New(), uuid.New(), uuid.New()
+	promo := &model.PromoCode{
+		ID:                    uuid.New(),
+		Code:                  "SPRING10",
+		DiscountType:          model.DiscountTypePercentage,
+		PercentOff:            1000,
+		MaxRedemptions:        100,
+		MaxRedemptionsPerUser: 1,
+		RedemptionCount:       99,
+		MinQuantity:           2,
+		Active:                true,
+	}
+	repo := &memoryPromoCodes{
+		codes:       map[string]*model.PromoCode{promo.Code: promo},
+		redemptions: map[uuid.UUID]int{loyal: 1},
+	}
+
+	got, err := checkPromoCode(ctx, repo, eventID, regular, 2, " spring10 ")
+	if err != nil || got.ID != promo.ID {
+		t.Fatalf("checkPromoCode() = %v, %v, want %s", got, err, promo.Code)
+	}
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		quantity int
+		code     string
+		want     error
+	}{
+		{"unknown code", regular, 2, "WINTER10", model.ErrInvalidPromoCode},
+		{"below minimum quantity", regular, 1, "SPRING10", model.ErrPromoCodeNotApplicable},
+		{"already redeemed by the user", loyal, 2, "SPRING10", model.ErrPromoCodeExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := checkPromoCode(ctx, repo, eventID, tt.userID, tt.quantity, tt.code); !errors.Is(err, tt.want) {
+				t.Errorf("checkPromoCode() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	promo.RedemptionCount = 100
+	if _, err := checkPromoCode(ctx, repo, eventID, regular, 2, "SPRING10"); !errors.Is(err, model.ErrPromoCodeExhausted) {
+		t.Fatalf("checkPromoCode() of a used up code = %v, want %v", err, model.ErrPromoCodeExhausted)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lockPurchases serialises the holds and bookings of one user for an event
+// with per-user limits, so the tickets the user owns cannot change between
+// checking the limits and reserving. Events without any are not locked.
+func lockPurchases(
+	ctx context.Context,
+	rs *redsync.Redsync,
+	event *model.Event,
+	userID uuid.UUID,
+) (unlock func(), err error) {
+	if !event.HasUserLimits() {
+		return func() {}, nil
+	}
+	mutex := rs.NewMutex(
+		fmt.Sprintf("lock:purchase:event:%s:user:%s", event.ID.String(), userID.String()),
+		redsync.WithExpiry(10*time.Second),
+		redsync.WithTries(5),
+	)
+	if err := lockMutex(ctx, mutex); err != nil {
+		return nil, fmt.Errorf("failed to acquire purchase lock: %w", err)
+	}
+	return func() { mutex.UnlockContext(ctx) }, nil
+}
+
+// lockMutex acquires the mutex in a span of its own, so the time spent
+// waiting for the lock shows in the trace.
+func lockMutex(ctx context.Context, mutex *redsync.Mutex) error {
+	ctx, span := tracing.Start(ctx, "redsync.Lock",
+		trace.WithAttributes(attribute.String("lock.name", mutex.Name())))
+	err := mutex.LockContext(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// checkPurchaseLimits verifies the purchase limits of the event and its
+// ticket types for the line items of the user. Call it with the purchase lock
+// held.
+func checkPurchaseLimits(
+	ctx context.Context,
+	bookingRepo repository.BookingRepositoryInterface,
+	event *model.Event,
+	userID uuid.UUID,
+	items []model.LineItem,
+) error {
+	owned := map[uuid.UUID]int{}
+	if event.HasUserLimits() {
+		var err error
+		if owned, err = bookingRepo.CountActiveTickets(ctx, event.ID, userID); err != nil {
+			return err
+		}
+	}
+	return event.CheckPurchaseLimits(owned, items)
+}
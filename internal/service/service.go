@@ -2,19 +2,92 @@ package service
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
 )
 
 type BookingServiceInterface interface {
 	CreateBooking(ctx context.Context, booking *dto.CreateBookingDTO) (*dto.BookingDTO, error)
 	GetBooking(ctx context.Context, id uuid.UUID) (*dto.BookingDTO, error)
+	CancelBooking(ctx context.Context, id uuid.UUID, cancelDTO *dto.CancelBookingDTO) (*dto.BookingDTO, error)
+	GetBookingHistory(ctx context.Context, id uuid.UUID) ([]*model.BookingStatusChange, error)
+	ListUserBookings(ctx context.Context, filter *dto.BookingFilterDTO, pagination *utils.Pagination) (*dto.BookingListDTO, error)
 	DeleteBooking(ctx context.Context, id uuid.UUID) error
 }
 
+type TicketServiceInterface interface {
+	HoldTickets(
+		ctx context.Context,
+		eventID, userID uuid.UUID,
+		items []model.LineItem,
+		admissionToken string,
+		promoCode string,
+	) (*dto.HoldDTO, error)
+	GetHold(ctx context.Context, eventID, userID uuid.UUID) (*dto.HoldDTO, error)
+	ReleaseHold(ctx context.Context, eventID, userID uuid.UUID) error
+	ReapExpiredHolds(ctx context.Context) (*HoldReapResult, error)
+}
+
 type EventServiceInterface interface {
 	CreateEvent(ctx context.Context, eventDTO *dto.CreateEventDTO) (*dto.EventDTO, error)
 	GetEventByID(ctx context.Context, id uuid.UUID) (*dto.EventDTO, error)
+	ListEvents(ctx context.Context, filter *dto.EventFilterDTO, pagination *utils.Pagination) (*dto.EventListDTO, error)
+	UpdateEvent(ctx context.Context, id uuid.UUID, eventDTO *dto.UpdateEventDTO) (*dto.EventDTO, error)
+	PublishEvent(ctx context.Context, id uuid.UUID) (*dto.EventDTO, error)
+	CancelEvent(ctx context.Context, id uuid.UUID, cancelDTO *dto.CancelEventDTO) (*dto.EventCancellationDTO, error)
+	CompleteEvent(ctx context.Context, id uuid.UUID) (*dto.EventDTO, error)
+	CompleteEndedEvents(ctx context.Context) (int, error)
 	DeleteEvent(ctx context.Context, id uuid.UUID) error
+	CreateTicketType(ctx context.Context, eventID uuid.UUID, ticketTypeDTO *dto.CreateTicketTypeDTO) (*dto.TicketTypeDTO, error)
+	ListTicketTypes(ctx context.Context, eventID uuid.UUID) ([]*dto.TicketTypeDTO, error)
+	UpdateTicketType(
+		ctx context.Context,
+		eventID, id uuid.UUID,
+		ticketTypeDTO *dto.UpdateTicketTypeDTO,
+	) (*dto.TicketTypeDTO, error)
+}
+
+type PromoCodeServiceInterface interface {
+	CreatePromoCode(ctx context.Context, promoDTO *dto.CreatePromoCodeDTO) (*dto.PromoCodeDTO, error)
+	GetPromoCode(ctx context.Context, id uuid.UUID) (*dto.PromoCodeDTO, error)
+	ListPromoCodes(ctx context.Context, filter *dto.PromoCodeFilterDTO, pagination *utils.Pagination) (*dto.PromoCodeListDTO, error)
+	UpdatePromoCode(ctx context.Context, id uuid.UUID, promoDTO *dto.UpdatePromoCodeDTO) (*dto.PromoCodeDTO, error)
+}
+
+type InventoryServiceInterface interface {
+	GetInventory(ctx context.Context, eventID uuid.UUID) (*dto.InventoryDTO, error)
+	ListReconcilableEvents(ctx context.Context) ([]*model.Event, error)
+	CheckInventory(ctx context.Context, event *model.Event) (*dto.InventoryDTO, error)
+	RepairInventory(ctx context.Context, eventID uuid.UUID) (*dto.InventoryDTO, error)
+}
+
+type PaymentServiceInterface interface {
+	CreatePayment(ctx context.Context, booking *model.Booking, event *model.Event) (*dto.PaymentDTO, error)
+	GetPayment(ctx context.Context, bookingID uuid.UUID) (*dto.PaymentDTO, error)
+	CapturePayment(ctx context.Context, bookingID uuid.UUID) (*dto.BookingDTO, error)
+	RefundPayment(ctx context.Context, bookingID uuid.UUID) (*dto.BookingDTO, error)
+	ExpireUnpaidBookings(ctx context.Context) (int, error)
+	HandleWebhook(ctx context.Context, payload []byte, header http.Header) (*dto.WebhookEventDTO, error)
+	GetWebhookEvent(ctx context.Context, id uuid.UUID) (*dto.WebhookEventDTO, error)
+	ListWebhookEvents(ctx context.Context, filter *dto.WebhookEventFilterDTO, pagination *utils.Pagination) (*dto.WebhookEventListDTO, error)
+}
+
+type OutboxServiceInterface interface {
+	RelayPending(ctx context.Context) (int, error)
+	PurgePublished(ctx context.Context) (int, error)
+}
+
+type NotificationServiceInterface interface {
+	SendConfirmations(ctx context.Context) (int, error)
+	SendReminders(ctx context.Context) (int, error)
+}
+
+type WaitingRoomServiceInterface interface {
+	JoinQueue(ctx context.Context, eventID, userID uuid.UUID) (*dto.QueueTicketDTO, error)
+	GetQueueTicket(ctx context.Context, eventID uuid.UUID, token string) (*dto.QueueTicketDTO, error)
+	AdmitQueued(ctx context.Context) (int, error)
 }
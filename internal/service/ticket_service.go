@@ -4,105 +4,471 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/go-redsync/redsync/v4"
 	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
 	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
+	"github.com/phamdinhha/event-booking-service/internal/model"
 	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	bookingHoldTime = 5 * time.Minute
+	HoldStrategyLua     = "lua"
+	HoldStrategyRedsync = "redsync"
 )
 
+const (
+	defaultHoldTime   = 5 * time.Minute
+	holdReapBatchSize = 100
+)
+
+// HoldReapResult reports what a single ReapExpiredHolds sweep reclaimed.
+type HoldReapResult struct {
+	Holds   int
+	Tickets int
+}
+
 type TicketService struct {
-	bookingRepo repository.BookingRepositoryInterface
-	eventRepo   repository.EventRepositoryInterface
-	redis       *redis.Client
-	redsync     *redsync.Redsync
+	bookingRepo  repository.BookingRepositoryInterface
+	eventRepo    repository.EventRepositoryInterface
+	promoRepo    repository.PromoCodeRepositoryInterface
+	redis        *redis.Client
+	redsync      *redsync.Redsync
+	holdStrategy string
+	holdTime     time.Duration
 }
 
 func NewTicketService(
+	cfg *config.Config,
 	bookingRepo repository.BookingRepositoryInterface,
 	eventRepo repository.EventRepositoryInterface,
+	promoRepo repository.PromoCodeRepositoryInterface,
 	redis *redis.Client,
-) *TicketService {
+) TicketServiceInterface {
 	pool := goredis.NewPool(redis)
+	holdTime := cfg.Ticket.HoldTTL
+	if holdTime <= 0 {
+		holdTime = defaultHoldTime
+	}
 	return &TicketService{
-		bookingRepo: bookingRepo,
-		eventRepo:   eventRepo,
-		redis:       redis,
-		redsync:     redsync.New(pool),
+		bookingRepo:  bookingRepo,
+		eventRepo:    eventRepo,
+		promoRepo:    promoRepo,
+		redis:        redis,
+		redsync:      redsync.New(pool),
+		holdStrategy: cfg.Ticket.HoldStrategy,
+		holdTime:     holdTime,
+	}
+}
+
+// HoldTickets reserves the line items for the user. Events with a waiting
+// room only accept users holding a live admission token, and the purchase
+// limits of the event and its ticket types count the tickets the user already
+// booked. A promo code is checked against the held items and applied when
+// the hold is booked.
+func (s *TicketService) HoldTickets(
+	ctx context.Context,
+	eventID, userID uuid.UUID,
+	items []model.LineItem,
+	admissionToken string,
+	promoCode string,
+) (_ *dto.HoldDTO, err error) {
+	ctx, span := tracing.Start(ctx, "TicketService.HoldTickets",
+		trace.WithAttributes(attribute.String("event.id", eventID.String())))
+	defer func() { tracing.End(span, err) }()
+	if err := checkAdmission(ctx, s.redis, eventID, userID, admissionToken); err != nil {
+		return nil, err
+	}
+
+	event, err := s.eventRepo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	// Counters of a closed event may linger until closing it dropped them,
+	// so the status is checked before every hold and not only on a reseed.
+	if !event.Status.OnSale() {
+		return nil, model.ErrEventNotOnSale
+	}
+	if items, err = resolveLineItems(event, items, time.Now()); err != nil {
+		return nil, err
+	}
+	var discount *model.Money
+	if promoCode != "" {
+		if discount, err = s.estimateDiscount(ctx, event, userID, items, promoCode); err != nil {
+			return nil, err
+		}
+	}
+	unlock, err := lockPurchases(ctx, s.redsync, event, userID)
+	if err != nil {
+		metrics.HoldLockFailures.WithLabelValues(metrics.LockPurchase).Inc()
+		return nil, err
+	}
+	defer unlock()
+	if err := checkPurchaseLimits(ctx, s.bookingRepo, event, userID, items); err != nil {
+		return nil, err
+	}
+
+	var result HoldResult
+	if s.holdStrategy == HoldStrategyRedsync {
+		result, err = s.holdWithLock(ctx, eventID, userID, items)
+	} else {
+		result, err = s.holdWithScript(ctx, event, userID, items)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch result {
+	case HoldResultOK:
+		if err := s.setHoldPromoCode(ctx, eventID, userID, promoCode); err != nil {
+			return nil, err
+		}
+		metrics.HoldsCreated.Inc()
+		hold := toHoldDTO(eventID, userID, items, s.holdTime)
+		hold.PromoCode = model.NormalizePromoCode(promoCode)
+		if discount != nil {
+			estimate := toMoneyDTO(*discount)
+			hold.Discount = &estimate
+		}
+		return hold, nil
+	case HoldResultSoldOut:
+		metrics.SoldOutRejections.Inc()
+		return nil, model.ErrNotEnoughTickets
+	case HoldResultAlreadyHolding:
+		return nil, ErrAlreadyHolding
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownHoldResult, result)
 	}
 }
 
-func (s *TicketService) HoldTickets(ctx context.Context, eventID, userID uuid.UUID, quantity int) error {
-	mutexName := fmt.Sprintf("lock:event:%d", eventID)
+func (s *TicketService) holdWithScript(
+	ctx context.Context,
+	event *model.Event,
+	userID uuid.UUID,
+	items []model.LineItem,
+) (HoldResult, error) {
+	result, err := reserveHold(ctx, s.redis, event.ID, userID, items, s.holdTime)
+	if err != nil || result != HoldResultNotSeeded {
+		return result, err
+	}
+	// A counter is missing because the event is not on sale, was never
+	// seeded, got evicted or the ticket type is new. Rebuild the counters of
+	// published events and retry once. The event is read again, closing it
+	// drops the counters and they must not be seeded from a stale status.
+	event, err = s.eventRepo.GetEventByID(ctx, event.ID)
+	if err != nil {
+		return 0, err
+	}
+	if !event.Status.OnSale() {
+		return 0, model.ErrEventNotOnSale
+	}
+	if _, err := syncAvailability(ctx, s.redis, event, inventoryModeSeed); err != nil {
+		return 0, err
+	}
+	result, err = reserveHold(ctx, s.redis, event.ID, userID, items, s.holdTime)
+	if err != nil {
+		return 0, err
+	}
+	if result == HoldResultNotSeeded {
+		return 0, fmt.Errorf("availability of event %s could not be seeded", event.ID)
+	}
+	return result, nil
+}
+
+// holdWithLock is the lock based reservation kept around for benchmarking
+// against reserveHoldScript. It serialises all holds of an event on one mutex
+// and checks and decrements the same availability counters as the script, so
+// both strategies hold the same invariants.
+func (s *TicketService) holdWithLock(
+	ctx context.Context,
+	eventID, userID uuid.UUID,
+	items []model.LineItem,
+) (HoldResult, error) {
+	mutexName := fmt.Sprintf("lock:event:%s", eventID.String())
 	mutex := s.redsync.NewMutex(
 		mutexName,
 		redsync.WithExpiry(10*time.Second),
 		redsync.WithTries(5),
 	)
-	if err := mutex.Lock(); err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
+	if err := lockMutex(ctx, mutex); err != nil {
+		metrics.HoldLockFailures.WithLabelValues(metrics.LockEvent).Inc()
+		return 0, fmt.Errorf("failed to acquire lock: %w", err)
 	}
-	defer mutex.Unlock()
+	defer mutex.UnlockContext(ctx)
 
-	availableTickets, err := s.eventRepo.GetEventByID(ctx, eventID)
+	deadline, err := s.redis.ZScore(ctx, holdDeadlinesKey, holdDeadlineMember(eventID, userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("failed to check existing hold: %w", err)
+	}
+	if err == nil {
+		if int64(deadline) > time.Now().UnixMilli() {
+			return HoldResultAlreadyHolding, nil
+		}
+		// The previous hold expired but was not reaped yet, give its tickets
+		// back before it is overwritten.
+		if _, err := claimHold(ctx, s.redis, eventID, userID, claimModeReap); err != nil && !errors.Is(err, ErrHoldNotFound) {
+			return 0, err
+		}
+	}
+
+	event, err := s.eventRepo.GetEventByID(ctx, eventID)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	if !event.Status.OnSale() {
+		return 0, model.ErrEventNotOnSale
+	}
+	if _, err := syncAvailability(ctx, s.redis, event, inventoryModeSeed); err != nil {
+		return 0, err
 	}
-	if availableTickets.AvailableTickets < quantity {
-		return errors.New("not enough tickets available")
+	fields := make([]string, 0, len(items))
+	for _, item := range items {
+		if event.TicketType(item.TicketTypeID) == nil {
+			return 0, model.ErrUnknownTicketType
+		}
+		fields = append(fields, item.TicketTypeID.String())
+	}
+	available, err := s.redis.HMGet(ctx, availableKey(eventID), fields...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read availability: %w", err)
+	}
+	for i, item := range items {
+		value, ok := available[i].(string)
+		if !ok {
+			return 0, fmt.Errorf("availability of event %s could not be seeded", eventID)
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("malformed availability %q: %w", value, err)
+		}
+		if item.Quantity > count {
+			return HoldResultSoldOut, nil
+		}
 	}
 
-	holdKey := fmt.Sprintf("hold:event:%s:user:%s", eventID.String(), userID.String())
-	availableKey := fmt.Sprintf("available:event:%s", eventID.String())
+	pipe := s.redis.TxPipeline()
+	writeHold(ctx, pipe, eventID, userID, items, s.holdTime)
+	for _, item := range items {
+		pipe.HIncrBy(ctx, availableKey(eventID), item.TicketTypeID.String(), -int64(item.Quantity))
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hold tickets: %w", err)
+	}
+	return HoldResultOK, nil
+}
 
+func (s *TicketService) GetHold(ctx context.Context, eventID, userID uuid.UUID) (_ *dto.HoldDTO, err error) {
+	ctx, span := tracing.Start(ctx, "TicketService.GetHold")
+	defer func() { tracing.End(span, err) }()
+	if err := auth.CheckOwner(ctx, userID); err != nil {
+		return nil, err
+	}
+	key := holdKey(eventID, userID)
 	pipe := s.redis.Pipeline()
-	pipe.Set(ctx, holdKey, quantity, bookingHoldTime)
-	pipe.DecrBy(ctx, availableKey, int64(quantity))
+	itemsCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.TTL(ctx, key)
+	promoCmd := pipe.Get(ctx, holdPromoKey(eventID, userID))
 	_, err = pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	encoded, err := itemsCmd.Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to get held tickets: %w", err)
+	}
+	items, err := decodeLineItems(encoded)
 	if err != nil {
-		return fmt.Errorf("failed to hold tickets: %w", err)
+		return nil, fmt.Errorf("failed to get held tickets: %w", err)
 	}
+	hold := toHoldDTO(eventID, userID, items, ttlCmd.Val())
+	hold.PromoCode = promoCmd.Val()
+	return hold, nil
+}
+
+func (s *TicketService) ReleaseHold(ctx context.Context, eventID, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "TicketService.ReleaseHold")
+	defer func() { tracing.End(span, err) }()
+	if err := auth.CheckOwner(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := claimHold(ctx, s.redis, eventID, userID, claimModeRelease); err != nil {
+		return err
+	}
+	metrics.HoldsReleased.Inc()
+	s.redis.Del(ctx, holdPromoKey(eventID, userID))
 	return nil
 }
 
-func (s *TicketService) ReleaseHold(ctx context.Context, eventID, userID uuid.UUID) error {
-	holdKey := fmt.Sprintf("hold:event:%s:user:%s", eventID.String(), userID.String())
-	heldTickets, err := s.redis.Get(ctx, holdKey).Int()
-	if err != nil && err != redis.Nil {
-		return fmt.Errorf("failed to get held tickets: %w", err)
-	}
-	if heldTickets > 0 {
-		pipe := s.redis.Pipeline()
-		pipe.Del(ctx, holdKey)
-		pipe.IncrBy(ctx, fmt.Sprintf("available:event:%d", eventID), int64(heldTickets))
-		_, err = pipe.Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to release hold: %w", err)
+// estimateDiscount checks the promo code for the held items and returns the
+// discount at the current prices. The code is checked again, under lock,
+// when the hold is booked.
+func (s *TicketService) estimateDiscount(
+	ctx context.Context,
+	event *model.Event,
+	userID uuid.UUID,
+	items []model.LineItem,
+	promoCode string,
+) (*model.Money, error) {
+	promo, err := checkPromoCode(ctx, s.promoRepo, event.ID, userID, model.TotalQuantity(items), promoCode)
+	if err != nil {
+		return nil, err
+	}
+	subtotal := model.Money{}
+	for _, item := range items {
+		if subtotal, err = subtotal.Add(event.TicketType(item.TicketTypeID).Price.Mul(item.Quantity)); err != nil {
+			return nil, err
 		}
 	}
+	discount, err := promo.Discount(subtotal)
+	if err != nil {
+		return nil, err
+	}
+	return &discount, nil
+}
+
+// setHoldPromoCode remembers the promo code of a new hold for as long as the
+// hold lives. A hold without a code drops the code of an earlier hold.
+func (s *TicketService) setHoldPromoCode(ctx context.Context, eventID, userID uuid.UUID, promoCode string) error {
+	key := holdPromoKey(eventID, userID)
+	var err error
+	if promoCode == "" {
+		err = s.redis.Del(ctx, key).Err()
+	} else {
+		err = s.redis.Set(ctx, key, model.NormalizePromoCode(promoCode), s.holdTime).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store hold promo code: %w", err)
+	}
 	return nil
 }
 
-func (s *TicketService) CleanupExpiredHolds(ctx context.Context) error {
-	iter := s.redis.Scan(ctx, 0, "hold:event:*", 0).Iterator()
-	for iter.Next(ctx) {
-		holdKey := iter.Val()
-		ttl := s.redis.TTL(ctx, holdKey).Val()
-		if ttl < 0 {
-			parts := strings.Split(holdKey, ":")
-			if len(parts) == 4 {
-				eventID, _ := uuid.Parse(parts[2])
-				userID, _ := uuid.Parse(parts[3])
-				s.ReleaseHold(ctx, uuid.UUID(eventID), uuid.UUID(userID))
+// ReapExpiredHolds returns the tickets of every hold whose deadline has passed
+// to the availability counter. Each hold is credited back exactly once, even
+// when several instances reap concurrently or the hold key already expired.
+func (s *TicketService) ReapExpiredHolds(ctx context.Context) (_ *HoldReapResult, err error) {
+	ctx, span := tracing.Start(ctx, "TicketService.ReapExpiredHolds")
+	defer func() { tracing.End(span, err) }()
+	result := &HoldReapResult{}
+	for {
+		members, err := s.redis.ZRangeByScore(ctx, holdDeadlinesKey, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Count: holdReapBatchSize,
+		}).Result()
+		if err != nil {
+			return result, fmt.Errorf("failed to list expired holds: %w", err)
+		}
+		for _, member := range members {
+			eventID, userID, err := parseHoldDeadlineMember(member)
+			if err != nil {
+				// Unparseable members can never be claimed, drop them.
+				s.redis.ZRem(ctx, holdDeadlinesKey, member)
+				continue
 			}
+			items, err := claimHold(ctx, s.redis, eventID, userID, claimModeReap)
+			if errors.Is(err, ErrHoldNotFound) {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+			metrics.HoldsExpired.Inc()
+			result.Holds++
+			result.Tickets += model.TotalQuantity(items)
+		}
+		if len(members) < holdReapBatchSize {
+			return result, nil
 		}
 	}
-	return nil
+}
+
+// resolveLineItems checks the requested line items against the ticket types
+// of the event and merges the ones of the same ticket type. A line item
+// without a ticket type stands for the only ticket type of the event.
+func resolveLineItems(event *model.Event, items []model.LineItem, at time.Time) ([]model.LineItem, error) {
+	resolved := make([]model.LineItem, 0, len(items))
+	positions := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		if item.TicketTypeID == uuid.Nil {
+			if len(event.TicketTypes) != 1 {
+				return nil, model.ErrTicketTypeRequired
+			}
+			item.TicketTypeID = event.TicketTypes[0].ID
+		}
+		ticketType := event.TicketType(item.TicketTypeID)
+		if ticketType == nil {
+			return nil, model.ErrUnknownTicketType
+		}
+		if !ticketType.OnSaleAt(at) {
+			return nil, model.ErrTicketTypeNotOnSale
+		}
+		if i, ok := positions[item.TicketTypeID]; ok {
+			resolved[i].Quantity += item.Quantity
+			continue
+		}
+		positions[item.TicketTypeID] = len(resolved)
+		resolved = append(resolved, item)
+	}
+	if len(resolved) == 0 {
+		return nil, model.ErrEmptyOrder
+	}
+	return resolved, nil
+}
+
+func toHoldDTO(eventID, userID uuid.UUID, items []model.LineItem, ttl time.Duration) *dto.HoldDTO {
+	hold := &dto.HoldDTO{
+		EventID:   eventID,
+		UserID:    userID,
+		Quantity:  model.TotalQuantity(items),
+		Items:     make([]dto.HoldItemDTO, 0, len(items)),
+		TTL:       int64(ttl.Seconds()),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	for _, item := range items {
+		hold.Items = append(hold.Items, dto.HoldItemDTO{TicketTypeID: item.TicketTypeID, Quantity: item.Quantity})
+	}
+	return hold
+}
+
+// checkPromoCode looks up the promo code and checks that the user can redeem
+// it on an order of quantity tickets of the event.
+func checkPromoCode(
+	ctx context.Context,
+	promoRepo repository.PromoCodeRepositoryInterface,
+	eventID, userID uuid.UUID,
+	quantity int,
+	promoCode string,
+) (*model.PromoCode, error) {
+	promo, err := promoRepo.GetPromoCodeByCode(ctx, promoCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := promo.CheckApplicable(eventID, quantity, time.Now()); err != nil {
+		return nil, err
+	}
+	redeemed, err := promoRepo.CountUserRedemptions(ctx, promo.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := promo.CheckRedemptions(redeemed); err != nil {
+		return nil, err
+	}
+	return promo, nil
 }
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/auth"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/metrics"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var holdStrategies = []string{HoldStrategyLua, HoldStrategyRedsync}
+
+// ticketService returns a TicketService on the repository and Redis of the
+// flow that holds tickets with strategy.
+func (f *paymentFlow) ticketService(strategy string) TicketServiceInterface {
+	cfg := &config.Config{}
+	cfg.Ticket.HoldStrategy = strategy
+	return NewTicketService(cfg, f.repo, f.repo, nil, f.redis)
+}
+
+func customerContext(userID uuid.UUID) context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{
+		UserID: userID,
+		Roles:  []string{auth.RoleCustomer},
+	})
+}
+
+func TestHoldGetAndReleaseHold(t *testing.T) {
+	for _, strategy := range holdStrategies {
+		t.Run(strategy, func(t *testing.T) {
+			f := newPaymentFlow(t)
+			tickets := f.ticketService(strategy)
+			userID := uuid.New()
+			ctx := customerContext(userID)
+			items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 3}}
+
+			hold, err := tickets.HoldTickets(ctx, f.event.ID, userID, items, "", "")
+			if err != nil {
+				t.Fatalf("HoldTickets() = %v", err)
+			}
+			if hold.Quantity != 3 || hold.TTL <= 0 {
+				t.Fatalf("hold = %+v, want 3 tickets with a ttl", hold)
+			}
+			if got := f.available(t); got != 7 {
+				t.Fatalf("available after hold = %d, want 7", got)
+			}
+			if _, err := tickets.HoldTickets(ctx, f.event.ID, userID, items, "", ""); !errors.Is(err, ErrAlreadyHolding) {
+				t.Fatalf("second HoldTickets() = %v, want %v", err, ErrAlreadyHolding)
+			}
+
+			other := customerContext(uuid.New())
+			if _, err := tickets.GetHold(other, f.event.ID, userID); !errors.Is(err, auth.ErrNotOwner) {
+				t.Fatalf("GetHold() of another user = %v, want %v", err, auth.ErrNotOwner)
+			}
+			if err := tickets.ReleaseHold(other, f.event.ID, userID); !errors.Is(err, auth.ErrNotOwner) {
+				t.Fatalf("ReleaseHold() of another user = %v, want %v", err, auth.ErrNotOwner)
+			}
+			got, err := tickets.GetHold(ctx, f.event.ID, userID)
+			if err != nil {
+				t.Fatalf("GetHold() = %v", err)
+			}
+			if got.Quantity != 3 || len(got.Items) != 1 || got.Items[0].TicketTypeID != f.ticketType.ID {
+				t.Fatalf("GetHold() = %+v, want 3 tickets of %s", got, f.ticketType.ID)
+			}
+
+			if err := tickets.ReleaseHold(ctx, f.event.ID, userID); err != nil {
+				t.Fatalf("ReleaseHold() = %v", err)
+			}
+			if got := f.available(t); got != 10 {
+				t.Fatalf("available after release = %d, want 10", got)
+			}
+			if _, err := tickets.GetHold(ctx, f.event.ID, userID); !errors.Is(err, ErrHoldNotFound) {
+				t.Fatalf("GetHold() after release = %v, want %v", err, ErrHoldNotFound)
+			}
+			if err := tickets.ReleaseHold(ctx, f.event.ID, userID); !errors.Is(err, ErrHoldNotFound) {
+				t.Fatalf("second ReleaseHold() = %v, want %v", err, ErrHoldNotFound)
+			}
+			if got := f.available(t); got != 10 {
+				t.Fatalf("available after second release = %d, want 10", got)
+			}
+		})
+	}
+}
+
+func TestHoldMetrics(t *testing.T) {
+	f := newPaymentFlow(t)
+	tickets := f.ticketService(HoldStrategyLua)
+	userID := uuid.New()
+	ctx := customerContext(userID)
+	created, released := testutil.ToFloat64(metrics.HoldsCreated), testutil.ToFloat64(metrics.HoldsReleased)
+	soldOut := testutil.ToFloat64(metrics.SoldOutRejections)
+
+	items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 3}}
+	if _, err := tickets.HoldTickets(ctx, f.event.ID, userID, items, "", ""); err != nil {
+		t.Fatalf("HoldTickets() = %v", err)
+	}
+	other := uuid.New()
+	items = []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 8}}
+	if _, err := tickets.HoldTickets(customerContext(other), f.event.ID, other, items, "", ""); !errors.Is(err, model.ErrNotEnoughTickets) {
+		t.Fatalf("HoldTickets() past the capacity = %v, want %v", err, model.ErrNotEnoughTickets)
+	}
+	if err := tickets.ReleaseHold(ctx, f.event.ID, userID); err != nil {
+		t.Fatalf("ReleaseHold() = %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.HoldsCreated) - created; got != 1 {
+		t.Errorf("holds created metric grew by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.SoldOutRejections) - soldOut; got != 1 {
+		t.Errorf("sold out rejections metric grew by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.HoldsReleased) - released; got != 1 {
+		t.Errorf("holds released metric grew by %v, want 1", got)
+	}
+}
+
+func TestHoldTicketsChecksEventStatus(t *testing.T) {
+	for _, strategy := range holdStrategies {
+		t.Run(strategy, func(t *testing.T) {
+			f := newPaymentFlow(t)
+			tickets := f.ticketService(strategy)
+			items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 2}}
+
+			ctx := context.Background()
+			if _, err := tickets.HoldTickets(ctx, f.event.ID, uuid.New(), items, "", ""); err != nil {
+				t.Fatalf("HoldTickets() = %v", err)
+			}
+			if got := f.available(t); got != 8 {
+				t.Fatalf("available after hold = %d, want 8", got)
+			}
+
+			// The event closed but its counters were not dropped yet.
+			f.event.Status = model.EventStatusCancelled
+			_, err := tickets.HoldTickets(ctx, f.event.ID, uuid.New(), items, "", "")
+			if !errors.Is(err, model.ErrEventNotOnSale) {
+				t.Fatalf("HoldTickets() = %v, want %v", err, model.ErrEventNotOnSale)
+			}
+			if got := f.available(t); got != 8 {
+				t.Fatalf("available after refused hold = %d, want 8", got)
+			}
+		})
+	}
+}
+
+func TestHoldTicketsEnforcesPurchaseLimits(t *testing.T) {
+	for _, strategy := range holdStrategies {
+		t.Run(strategy, func(t *testing.T) {
+			f := newPaymentFlow(t)
+			tickets := f.ticketService(strategy)
+			f.event.MaxTicketsPerOrder = 3
+			f.event.MaxTicketsPerUser = 4
+			_, booking := f.book(t, 3)
+			ctx := customerContext(booking.UserID)
+			hold := func(quantity int) error {
+				items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: quantity}}
+				_, err := tickets.HoldTickets(ctx, f.event.ID, booking.UserID, items, "", "")
+				return err
+			}
+
+			if err := hold(2); !errors.Is(err, model.ErrUserLimitExceeded) {
+				t.Fatalf("HoldTickets() over the user limit = %v, want %v", err, model.ErrUserLimitExceeded)
+			}
+			if got := f.available(t); got != 7 {
+				t.Fatalf("available after a refused hold = %d, want 7", got)
+			}
+			if err := hold(1); err != nil {
+				t.Fatalf("HoldTickets() up to the user limit = %v", err)
+			}
+
+			// Cancelled bookings no longer count against the limit.
+			if _, err := f.bookings.CancelBooking(ctx, booking.ID, &dto.CancelBookingDTO{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tickets.ReleaseHold(ctx, f.event.ID, booking.UserID); err != nil {
+				t.Fatal(err)
+			}
+			if err := hold(4); !errors.Is(err, model.ErrOrderLimitExceeded) {
+				t.Fatalf("HoldTickets() over the order limit = %v, want %v", err, model.ErrOrderLimitExceeded)
+			}
+			if err := hold(3); err != nil {
+				t.Fatalf("HoldTickets() after cancelling = %v", err)
+			}
+		})
+	}
+}
+
+func TestReapExpiredHolds(t *testing.T) {
+	f := newPaymentFlow(t)
+	tickets := f.ticketService(HoldStrategyLua)
+	ctx := context.Background()
+	event, err := f.repo.GetEventByID(ctx, f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncAvailability(ctx, f.redis, event, inventoryModeSeed); err != nil {
+		t.Fatalf("syncAvailability() = %v", err)
+	}
+	expired, live := uuid.New(), uuid.New()
+	hold := func(userID uuid.UUID, quantity int, ttl time.Duration) {
+		items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: quantity}}
+		result, err := reserveHold(ctx, f.redis, f.event.ID, userID, items, ttl)
+		if err != nil || result != HoldResultOK {
+			t.Fatalf("reserveHold() = %v, %v, want %v", result, err, HoldResultOK)
+		}
+	}
+	hold(expired, 3, time.Millisecond)
+	hold(live, 2, time.Minute)
+	time.Sleep(5 * time.Millisecond)
+	before := testutil.ToFloat64(metrics.HoldsExpired)
+
+	result, err := tickets.ReapExpiredHolds(ctx)
+	if err != nil {
+		t.Fatalf("ReapExpiredHolds() = %v", err)
+	}
+	if result.Holds != 1 || result.Tickets != 3 {
+		t.Fatalf("ReapExpiredHolds() = %+v, want 1 hold of 3 tickets", result)
+	}
+	if got := testutil.ToFloat64(metrics.HoldsExpired) - before; got != 1 {
+		t.Fatalf("holds expired metric grew by %v, want 1", got)
+	}
+	if got := f.available(t); got != 8 {
+		t.Fatalf("available after reaping = %d, want 8", got)
+	}
+	if _, err := tickets.GetHold(customerContext(live), f.event.ID, live); err != nil {
+		t.Fatalf("GetHold() of the live hold = %v", err)
+	}
+
+	// Each hold is credited back once, a second sweep finds nothing.
+	result, err = tickets.ReapExpiredHolds(ctx)
+	if err != nil {
+		t.Fatalf("second ReapExpiredHolds() = %v", err)
+	}
+	if result.Holds != 0 || result.Tickets != 0 {
+		t.Fatalf("second ReapExpiredHolds() = %+v, want nothing", result)
+	}
+	if got := f.available(t); got != 8 {
+		t.Fatalf("available after second sweep = %d, want 8", got)
+	}
+	if err := tickets.ReleaseHold(customerContext(expired), f.event.ID, expired); !errors.Is(err, ErrHoldNotFound) {
+		t.Fatalf("ReleaseHold() of a reaped hold = %v, want %v", err, ErrHoldNotFound)
+	}
+}
+
+func TestHoldTicketsDoesNotOversell(t *testing.T) {
+	for _, strategy := range holdStrategies {
+		t.Run(strategy, func(t *testing.T) {
+			f := newPaymentFlow(t)
+			tickets := f.ticketService(strategy)
+			items := []model.LineItem{{TicketTypeID: f.ticketType.ID, Quantity: 1}}
+
+			const buyers = 30
+			var wg sync.WaitGroup
+			results := make(chan error, buyers)
+			for i := 0; i < buyers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := tickets.HoldTickets(context.Background(), f.event.ID, uuid.New(), items, "", "")
+					results <- err
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			held := 0
+			for err := range results {
+				switch {
+				case err == nil:
+					held++
+				case errors.Is(err, model.ErrNotEnoughTickets):
+				case strategy == HoldStrategyRedsync && errors.Is(err, redsync.ErrFailed):
+					// Losing the lock race is allowed, overselling is not.
+				default:
+					t.Fatalf("HoldTickets() = %v", err)
+				}
+			}
+			if held > 10 {
+				t.Fatalf("%d holds of a single ticket each on 10 tickets", held)
+			}
+			if strategy == HoldStrategyLua && held != 10 {
+				t.Fatalf("%d holds, want all 10 tickets held", held)
+			}
+			if got := f.available(t); got != 10-held {
+				t.Fatalf("available = %d after %d holds, want %d", got, held, 10-held)
+			}
+		})
+	}
+}
+
+func TestReserveHoldIsAllOrNothing(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	plenty, scarce := uuid.New(), uuid.New()
+	f.redis.HSet(ctx, availableKey(f.event.ID), plenty.String(), 10, scarce.String(), 1)
+	userID := uuid.New()
+	items := []model.LineItem{{TicketTypeID: plenty, Quantity: 4}, {TicketTypeID: scarce, Quantity: 2}}
+
+	result, err := reserveHold(ctx, f.redis, f.event.ID, userID, items, time.Minute)
+	if err != nil || result != HoldResultSoldOut {
+		t.Fatalf("reserveHold() = %v, %v, want %v", result, err, HoldResultSoldOut)
+	}
+	counters := f.redis.HGetAll(ctx, availableKey(f.event.ID)).Val()
+	if counters[plenty.String()] != "10" || counters[scarce.String()] != "1" {
+		t.Fatalf("availability = %v after a refused hold, want it untouched", counters)
+	}
+	if exists := f.redis.Exists(ctx, holdKey(f.event.ID, userID)).Val(); exists != 0 {
+		t.Fatal("refused hold was written")
+	}
+
+	missing := []model.LineItem{{TicketTypeID: uuid.New(), Quantity: 1}}
+	if result, err := reserveHold(ctx, f.redis, f.event.ID, userID, missing, time.Minute); err != nil || result != HoldResultNotSeeded {
+		t.Fatalf("reserveHold() of an unseeded ticket type = %v, %v, want %v", result, err, HoldResultNotSeeded)
+	}
+}
+
+func TestReserveHoldReplacesExpiredHold(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	ticketType := uuid.New()
+	f.redis.HSet(ctx, availableKey(f.event.ID), ticketType.String(), 5)
+	userID := uuid.New()
+
+	first := []model.LineItem{{TicketTypeID: ticketType, Quantity: 4}}
+	if result, err := reserveHold(ctx, f.redis, f.event.ID, userID, first, 50*time.Millisecond); err != nil || result != HoldResultOK {
+		t.Fatalf("reserveHold() = %v, %v, want %v", result, err, HoldResultOK)
+	}
+	second := []model.LineItem{{TicketTypeID: ticketType, Quantity: 3}}
+	if result, err := reserveHold(ctx, f.redis, f.event.ID, userID, second, time.Minute); err != nil || result != HoldResultAlreadyHolding {
+		t.Fatalf("reserveHold() over a live hold = %v, %v, want %v", result, err, HoldResultAlreadyHolding)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// The 4 tickets of the expired hold are credited back before 3 are taken.
+	if result, err := reserveHold(ctx, f.redis, f.event.ID, userID, second, time.Minute); err != nil || result != HoldResultOK {
+		t.Fatalf("reserveHold() over an expired hold = %v, %v, want %v", result, err, HoldResultOK)
+	}
+	if got := f.redis.HGet(ctx, availableKey(f.event.ID), ticketType.String()).Val(); got != "2" {
+		t.Fatalf("available = %s, want 2", got)
+	}
+	items, err := claimHold(ctx, f.redis, f.event.ID, userID, claimModeRelease)
+	if err != nil || model.TotalQuantity(items) != 3 {
+		t.Fatalf("claimHold() = %v, %v, want the 3 tickets of the new hold", items, err)
+	}
+}
+
+func TestResolveLineItems(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	general := &model.TicketType{ID: uuid.New()}
+	early := &model.TicketType{ID: uuid.New(), SaleEndsAt: &now}
+	vip := &model.TicketType{ID: uuid.New(), SaleStartsAt: &later}
+	single := &model.Event{TicketTypes: []*model.TicketType{general}}
+	tiered := &model.Event{TicketTypes: []*model.TicketType{general, early, vip}}
+
+	resolved, err := resolveLineItems(tiered, []model.LineItem{
+		{TicketTypeID: general.ID, Quantity: 2},
+		{TicketTypeID: general.ID, Quantity: 1},
+		{TicketTypeID: vip.ID, Quantity: 0},
+	}, now)
+	if err != nil || len(resolved) != 1 || resolved[0] != (model.LineItem{TicketTypeID: general.ID, Quantity: 3}) {
+		t.Fatalf("resolveLineItems() = %v, %v, want 3 tickets of %s", resolved, err, general.ID)
+	}
+	// The only tier of an event is picked when none is named.
+	resolved, err = resolveLineItems(single, []model.LineItem{{Quantity: 2}}, now)
+	if err != nil || len(resolved) != 1 || resolved[0].TicketTypeID != general.ID {
+		t.Fatalf("resolveLineItems() = %v, %v, want the only tier", resolved, err)
+	}
+
+	tests := []struct {
+		name  string
+		event *model.Event
+		items []model.LineItem
+		want  error
+	}{
+		{"no tier among several", tiered, []model.LineItem{{Quantity: 1}}, model.ErrTicketTypeRequired},
+		{"tier of another event", tiered, []model.LineItem{{TicketTypeID: uuid.New(), Quantity: 1}}, model.ErrUnknownTicketType},
+		{"sale ended", tiered, []model.LineItem{{TicketTypeID: early.ID, Quantity: 1}}, model.ErrTicketTypeNotOnSale},
+		{"sale not started", tiered, []model.LineItem{{TicketTypeID: vip.ID, Quantity: 1}}, model.ErrTicketTypeNotOnSale},
+		{"no tickets", tiered, []model.LineItem{{TicketTypeID: general.ID, Quantity: 0}}, model.ErrEmptyOrder},
+		{"no items", single, nil, model.ErrEmptyOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveLineItems(tt.event, tt.items, now); !errors.Is(err, tt.want) {
+				t.Errorf("resolveLineItems() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
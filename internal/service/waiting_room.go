@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/repository"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// waitingRoomEventsKey is the set of events whose holds require an
+	// admission token. It mirrors events.waiting_room of draft and published
+	// events and is resynced by every admitter sweep.
+	waitingRoomEventsKey = "waitingroom:events"
+
+	defaultAdmitBatchSize = 100
+	defaultAdmissionTTL   = 10 * time.Minute
+
+	queueStatusWaiting  = "waiting"
+	queueStatusAdmitted = "admitted"
+)
+
+// joinQueueScript hands out one token per user and event. A user who is
+// still waiting or admitted gets the token again, an expired admission is
+// forgotten and the user queues up behind everyone else.
+//
+// KEYS[1] waiting room events, KEYS[2] queue, KEYS[3] queue sequence,
+// KEYS[4] admitted tokens, KEYS[5] token owners, KEYS[6] user tokens
+// ARGV[1] event id, ARGV[2] user id, ARGV[3] new token, ARGV[4] now (unix ms)
+//
+// Returns {-1} when the event has no waiting room, {0, token, position} for a
+// waiting token and {1, token, admission deadline} for an admitted one.
+var joinQueueScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[1], ARGV[1]) == 0 then
+	return {-1}
+end
+local token = redis.call('HGET', KEYS[6], ARGV[2])
+if token then
+	local rank = redis.call('ZRANK', KEYS[2], token)
+	if rank then
+		return {0, token, rank + 1}
+	end
+	local deadline = redis.call('ZSCORE', KEYS[4], token)
+	if deadline and tonumber(deadline) > tonumber(ARGV[4]) then
+		return {1, token, deadline}
+	end
+	redis.call('ZREM', KEYS[4], token)
+	redis.call('HDEL', KEYS[5], token)
+end
+token = ARGV[3]
+local seq = redis.call('INCR', KEYS[3])
+redis.call('ZADD', KEYS[2], seq, token)
+redis.call('HSET', KEYS[5], token, ARGV[2])
+redis.call('HSET', KEYS[6], ARGV[2], token)
+return {0, token, redis.call('ZRANK', KEYS[2], token) + 1}
+`)
+
+// queueTicketScript looks a token up.
+//
+// KEYS[1] queue, KEYS[2] admitted tokens
+// ARGV[1] token, ARGV[2] now (unix ms)
+//
+// Returns {-1} for an unknown or expired token, {0, position} while waiting
+// and {1, admission deadline} once admitted.
+var queueTicketScript = redis.NewScript(`
+local rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+if rank then
+	return {0, rank + 1}
+end
+local deadline = redis.call('ZSCORE', KEYS[2], ARGV[1])
+if deadline and tonumber(deadline) > tonumber(ARGV[2]) then
+	return {1, deadline}
+end
+return {-1}
+`)
+
+// admitScript forgets expired admissions and moves the head of the queue to
+// the admitted set.
+//
+// KEYS[1] queue, KEYS[2] admitted tokens, KEYS[3] token owners, KEYS[4] user tokens
+// ARGV[1] batch size, ARGV[2] now (unix ms), ARGV[3] admission deadline (unix ms)
+//
+// Returns the number of admitted tokens.
+var admitScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[2])
+for _, token in ipairs(expired) do
+	local user = redis.call('HGET', KEYS[3], token)
+	if user and redis.call('HGET', KEYS[4], user) == token then
+		redis.call('HDEL', KEYS[4], user)
+	end
+	redis.call('HDEL', KEYS[3], token)
+end
+if #expired > 0 then
+	redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[2])
+end
+local head = redis.call('ZPOPMIN', KEYS[1], ARGV[1])
+local admitted = 0
+for i = 1, #head, 2 do
+	redis.call('ZADD', KEYS[2], ARGV[3], head[i])
+	admitted = admitted + 1
+end
+return admitted
+`)
+
+// checkAdmissionScript decides whether a hold may go ahead.
+//
+// KEYS[1] waiting room events, KEYS[2] admitted tokens, KEYS[3] token owners
+// ARGV[1] event id, ARGV[2] token, ARGV[3] user id, ARGV[4] now (unix ms)
+//
+// Returns 0 when the hold may go ahead, 1 when a token is required and 2 when
+// the token is unknown, expired or belongs to another user.
+var checkAdmissionScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if ARGV[2] == '' then
+	return 1
+end
+if redis.call('HGET', KEYS[3], ARGV[2]) ~= ARGV[3] then
+	return 2
+end
+local deadline = redis.call('ZSCORE', KEYS[2], ARGV[2])
+if not deadline or tonumber(deadline) <= tonumber(ARGV[4]) then
+	return 2
+end
+return 0
+`)
+
+type WaitingRoomService struct {
+	eventRepo     repository.EventRepositoryInterface
+	logger        logger.Logger
+	redis         *redis.Client
+	batchSize     int
+	admissionTTL  time.Duration
+	admitInterval time.Duration
+}
+
+func NewWaitingRoomService(
+	cfg *config.Config,
+	eventRepo repository.EventRepositoryInterface,
+	logger logger.Logger,
+	redis *redis.Client,
+) WaitingRoomServiceInterface {
+	batchSize := cfg.WaitingRoom.AdmitBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultAdmitBatchSize
+	}
+	admissionTTL := cfg.WaitingRoom.AdmissionTTL
+	if admissionTTL <= 0 {
+		admissionTTL = defaultAdmissionTTL
+	}
+	return &WaitingRoomService{
+		eventRepo:     eventRepo,
+		logger:        logger,
+		redis:         redis,
+		batchSize:     batchSize,
+		admissionTTL:  admissionTTL,
+		admitInterval: cfg.Workers.WaitingRoomAdmitterInterval,
+	}
+}
+
+// JoinQueue puts the user in the waiting room of the event, or returns the
+// token the user already has.
+func (s *WaitingRoomService) JoinQueue(ctx context.Context, eventID, userID uuid.UUID) (*dto.QueueTicketDTO, error) {
+	keys := []string{
+		waitingRoomEventsKey,
+		queueKey(eventID),
+		queueSequenceKey(eventID),
+		admittedKey(eventID),
+		queueTokensKey(eventID),
+		queueUsersKey(eventID),
+	}
+	res, err := joinQueueScript.Run(ctx, s.redis, keys,
+		eventID.String(),
+		userID.String(),
+		uuid.NewString(),
+		time.Now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to join queue: %w", err)
+	}
+	if res[0].(int64) < 0 {
+		return nil, ErrWaitingRoomDisabled
+	}
+	return s.toQueueTicket(eventID, res[1].(string), res[0].(int64), res[2])
+}
+
+// GetQueueTicket reports the position of a waiting token and the estimated
+// time of its admission, or the admission deadline of an admitted one.
+func (s *WaitingRoomService) GetQueueTicket(ctx context.Context, eventID uuid.UUID, token string) (*dto.QueueTicketDTO, error) {
+	res, err := queueTicketScript.Run(ctx, s.redis,
+		[]string{queueKey(eventID), admittedKey(eventID)},
+		token,
+		time.Now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue ticket: %w", err)
+	}
+	if res[0].(int64) < 0 {
+		return nil, ErrQueueTokenNotFound
+	}
+	return s.toQueueTicket(eventID, token, res[0].(int64), res[1])
+}
+
+// AdmitQueued syncs the waiting room flags from Postgres and admits the next
+// batch of every published event with a waiting room. It returns how many
+// users were admitted.
+func (s *WaitingRoomService) AdmitQueued(ctx context.Context) (int, error) {
+	events, err := s.eventRepo.ListWaitingRoomEvents(ctx)
+	if err != nil {
+		return 0, err
+	}
+	enabled := make(map[string]bool, len(events))
+	for _, event := range events {
+		enabled[event.ID.String()] = true
+		if err := syncWaitingRoom(ctx, s.redis, event); err != nil {
+			return 0, err
+		}
+	}
+	members, err := s.redis.SMembers(ctx, waitingRoomEventsKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list waiting rooms: %w", err)
+	}
+	for _, member := range members {
+		if enabled[member] {
+			continue
+		}
+		eventID, err := uuid.Parse(member)
+		if err != nil {
+			s.redis.SRem(ctx, waitingRoomEventsKey, member)
+			continue
+		}
+		if err := closeWaitingRoom(ctx, s.redis, eventID); err != nil {
+			return 0, err
+		}
+	}
+
+	admitted := 0
+	now := time.Now()
+	for _, event := range events {
+		if !event.Status.OnSale() {
+			continue
+		}
+		n, err := admitScript.Run(ctx, s.redis,
+			[]string{queueKey(event.ID), admittedKey(event.ID), queueTokensKey(event.ID), queueUsersKey(event.ID)},
+			s.batchSize,
+			now.UnixMilli(),
+			now.Add(s.admissionTTL).UnixMilli(),
+		).Int()
+		if err != nil {
+			return admitted, fmt.Errorf("failed to admit queued users of event %s: %w", event.ID, err)
+		}
+		admitted += n
+	}
+	return admitted, nil
+}
+
+func (s *WaitingRoomService) toQueueTicket(eventID uuid.UUID, token string, state int64, value interface{}) (*dto.QueueTicketDTO, error) {
+	ticket := &dto.QueueTicketDTO{EventID: eventID, Token: token}
+	if state == 1 {
+		deadline, err := strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed admission deadline %v: %w", value, err)
+		}
+		expiresAt := time.UnixMilli(deadline)
+		ticket.Status = queueStatusAdmitted
+		ticket.AdmissionExpiresAt = &expiresAt
+		return ticket, nil
+	}
+
+	position, ok := value.(int64)
+	if !ok {
+		return nil, fmt.Errorf("malformed queue position %v", value)
+	}
+	// The position is admitted after this many admitter runs at most.
+	batches := (position + int64(s.batchSize) - 1) / int64(s.batchSize)
+	wait := time.Duration(batches) * s.admitInterval
+	ticket.Status = queueStatusWaiting
+	ticket.Position = position
+	ticket.EstimatedWaitSeconds = int64(wait.Seconds())
+	return ticket, nil
+}
+
+// syncWaitingRoom makes Redis enforce the waiting room of the event if it has
+// one and can still go on sale, and closes the waiting room otherwise.
+func syncWaitingRoom(ctx context.Context, client *redis.Client, event *model.Event) error {
+	if !event.WaitingRoom || !(event.Status == model.EventStatusDraft || event.Status.OnSale()) {
+		return closeWaitingRoom(ctx, client, event.ID)
+	}
+	if err := client.SAdd(ctx, waitingRoomEventsKey, event.ID.String()).Err(); err != nil {
+		return fmt.Errorf("failed to open waiting room: %w", err)
+	}
+	return nil
+}
+
+// closeWaitingRoom stops enforcing admission for the event and drops its
+// queue. Tokens still held by clients are reported as not found.
+func closeWaitingRoom(ctx context.Context, client *redis.Client, eventID uuid.UUID) error {
+	pipe := client.TxPipeline()
+	pipe.SRem(ctx, waitingRoomEventsKey, eventID.String())
+	pipe.Del(ctx,
+		queueKey(eventID),
+		queueSequenceKey(eventID),
+		admittedKey(eventID),
+		queueTokensKey(eventID),
+		queueUsersKey(eventID),
+	)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to close waiting room: %w", err)
+	}
+	return nil
+}
+
+// checkAdmission returns nil when the user may hold tickets of the event,
+// i.e. the event has no waiting room or token is a live admission of the user.
+func checkAdmission(ctx context.Context, client *redis.Client, eventID, userID uuid.UUID, token string) error {
+	res, err := checkAdmissionScript.Run(ctx, client,
+		[]string{waitingRoomEventsKey, admittedKey(eventID), queueTokensKey(eventID)},
+		eventID.String(),
+		token,
+		userID.String(),
+		time.Now().UnixMilli(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to check admission: %w", err)
+	}
+	switch res {
+	case 0:
+		return nil
+	case 1:
+		return ErrAdmissionRequired
+	default:
+		return ErrInvalidAdmission
+	}
+}
+
+func queueKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("waitingroom:queue:event:%s", eventID.String())
+}
+
+func queueSequenceKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("waitingroom:seq:event:%s", eventID.String())
+}
+
+func admittedKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("waitingroom:admitted:event:%s", eventID.String())
+}
+
+func queueTokensKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("waitingroom:tokens:event:%s", eventID.String())
+}
+
+func queueUsersKey(eventID uuid.UUID) string {
+	return fmt.Sprintf("waitingroom:users:event:%s", eventID.String())
+}
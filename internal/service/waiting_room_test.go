@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (f *paymentFlow) waitingRoom(admissionTTL time.Duration) *WaitingRoomService {
+	f.cfg.WaitingRoom.AdmitBatchSize = 2
+	f.cfg.WaitingRoom.AdmissionTTL = admissionTTL
+	f.cfg.Workers.WaitingRoomAdmitterInterval = 10 * time.Second
+	return NewWaitingRoomService(f.cfg, f.repo, nil, f.redis).(*WaitingRoomService)
+}
+
+func TestWaitingRoomAdmitsInOrder(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	s := f.waitingRoom(time.Minute)
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	if _, err := s.JoinQueue(ctx, f.event.ID, first); !errors.Is(err, ErrWaitingRoomDisabled) {
+		t.Fatalf("JoinQueue() without a waiting room = %v, want %v", err, ErrWaitingRoomDisabled)
+	}
+	f.event.WaitingRoom = true
+	if admitted, err := s.AdmitQueued(ctx); err != nil || admitted != 0 {
+		t.Fatalf("AdmitQueued() of an empty queue = %d, %v, want 0", admitted, err)
+	}
+
+	tickets := map[uuid.UUID]string{}
+	for i, userID := range []uuid.UUID{first, second, third} {
+		ticket, err := s.JoinQueue(ctx, f.event.ID, userID)
+		if err != nil {
+			t.Fatalf("JoinQueue() = %v", err)
+		}
+		if ticket.Status != queueStatusWaiting || ticket.Position != int64(i+1) {
+			t.Fatalf("ticket = %+v, want waiting at position %d", ticket, i+1)
+		}
+		tickets[userID] = ticket.Token
+	}
+	// Joining again keeps the place in the queue.
+	again, err := s.JoinQueue(ctx, f.event.ID, third)
+	if err != nil || again.Token != tickets[third] || again.Position != 3 || again.EstimatedWaitSeconds != 20 {
+		t.Fatalf("JoinQueue() again = %+v, %v, want token %s at position 3 in 20s", again, err, tickets[third])
+	}
+
+	if err := checkAdmission(ctx, f.redis, f.event.ID, first, ""); !errors.Is(err, ErrAdmissionRequired) {
+		t.Fatalf("checkAdmission() without a token = %v, want %v", err, ErrAdmissionRequired)
+	}
+	if err := checkAdmission(ctx, f.redis, f.event.ID, first, tickets[first]); !errors.Is(err, ErrInvalidAdmission) {
+		t.Fatalf("checkAdmission() while waiting = %v, want %v", err, ErrInvalidAdmission)
+	}
+
+	if admitted, err := s.AdmitQueued(ctx); err != nil || admitted != 2 {
+		t.Fatalf("AdmitQueued() = %d, %v, want 2", admitted, err)
+	}
+	ticket, err := s.GetQueueTicket(ctx, f.event.ID, tickets[first])
+	if err != nil || ticket.Status != queueStatusAdmitted || ticket.AdmissionExpiresAt == nil {
+		t.Fatalf("GetQueueTicket() = %+v, %v, want admitted", ticket, err)
+	}
+	if err := checkAdmission(ctx, f.redis, f.event.ID, first, tickets[first]); err != nil {
+		t.Fatalf("checkAdmission() once admitted = %v", err)
+	}
+	if err := checkAdmission(ctx, f.redis, f.event.ID, third, tickets[second]); !errors.Is(err, ErrInvalidAdmission) {
+		t.Fatalf("checkAdmission() with the token of another user = %v, want %v", err, ErrInvalidAdmission)
+	}
+	if ticket, err := s.GetQueueTicket(ctx, f.event.ID, tickets[third]); err != nil || ticket.Position != 1 {
+		t.Fatalf("GetQueueTicket() = %+v, %v, want position 1", ticket, err)
+	}
+	if _, err := s.GetQueueTicket(ctx, f.event.ID, uuid.NewString()); !errors.Is(err, ErrQueueTokenNotFound) {
+		t.Fatalf("GetQueueTicket() of an unknown token = %v, want %v", err, ErrQueueTokenNotFound)
+	}
+}
+
+func TestWaitingRoomAdmissionExpires(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	s := f.waitingRoom(50 * time.Millisecond)
+	f.event.WaitingRoom = true
+	userID, other := uuid.New(), uuid.New()
+
+	if _, err := s.AdmitQueued(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := s.JoinQueue(ctx, f.event.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AdmitQueued(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.JoinQueue(ctx, f.event.ID, other); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	if err := checkAdmission(ctx, f.redis, f.event.ID, userID, ticket.Token); !errors.Is(err, ErrInvalidAdmission) {
+		t.Fatalf("checkAdmission() after the deadline = %v, want %v", err, ErrInvalidAdmission)
+	}
+	// An expired admission queues up again behind everyone else.
+	rejoined, err := s.JoinQueue(ctx, f.event.ID, userID)
+	if err != nil || rejoined.Token == ticket.Token || rejoined.Status != queueStatusWaiting || rejoined.Position != 2 {
+		t.Fatalf("JoinQueue() after the deadline = %+v, %v, want a new token at position 2", rejoined, err)
+	}
+}
+
+func TestWaitingRoomCloses(t *testing.T) {
+	f := newPaymentFlow(t)
+	ctx := context.Background()
+	s := f.waitingRoom(time.Minute)
+	f.event.WaitingRoom = true
+	userID := uuid.New()
+
+	if _, err := s.AdmitQueued(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := s.JoinQueue(ctx, f.event.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.event.WaitingRoom = false
+	if _, err := s.AdmitQueued(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkAdmission(ctx, f.redis, f.event.ID, userID, ""); err != nil {
+		t.Fatalf("checkAdmission() without a waiting room = %v", err)
+	}
+	if _, err := s.GetQueueTicket(ctx, f.event.ID, ticket.Token); !errors.Is(err, ErrQueueTokenNotFound) {
+		t.Fatalf("GetQueueTicket() after closing = %v, want %v", err, ErrQueueTokenNotFound)
+	}
+}
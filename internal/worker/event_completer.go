@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// EventCompleter periodically completes published events that have ended.
+type EventCompleter struct {
+	logger   logger.Logger
+	eventSrv service.EventServiceInterface
+}
+
+func NewEventCompleter(
+	logger logger.Logger,
+	eventSrv service.EventServiceInterface,
+) *EventCompleter {
+	return &EventCompleter{logger: logger, eventSrv: eventSrv}
+}
+
+// Deamon returns a generator that completes ended events every interval.
+func (c *EventCompleter) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(c.logger, "EVENT_COMPLETER", interval, c.sweep)
+}
+
+func (c *EventCompleter) sweep(ctx context.Context) error {
+	completed, err := c.eventSrv.CompleteEndedEvents(ctx)
+	if completed > 0 {
+		c.logger.WithContext(ctx).Infof("EVENT_COMPLETER: completed %d ended events", completed)
+	}
+	return err
+}
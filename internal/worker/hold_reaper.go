@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// HoldReaper periodically returns the tickets of expired holds to inventory.
+type HoldReaper struct {
+	logger    logger.Logger
+	ticketSrv service.TicketServiceInterface
+
+	reclaimedHolds   atomic.Int64
+	reclaimedTickets atomic.Int64
+}
+
+func NewHoldReaper(
+	logger logger.Logger,
+	ticketSrv service.TicketServiceInterface,
+) *HoldReaper {
+	return &HoldReaper{logger: logger, ticketSrv: ticketSrv}
+}
+
+// Deamon returns a generator that sweeps expired holds every interval.
+func (r *HoldReaper) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(r.logger, "HOLD_REAPER", interval, r.sweep)
+}
+
+func (r *HoldReaper) sweep(ctx context.Context) error {
+	result, err := r.ticketSrv.ReapExpiredHolds(ctx)
+	if result != nil && result.Holds > 0 {
+		totalHolds := r.reclaimedHolds.Add(int64(result.Holds))
+		totalTickets := r.reclaimedTickets.Add(int64(result.Tickets))
+		r.logger.WithContext(ctx).Infof(
+			"HOLD_REAPER: reclaimed %d tickets from %d expired holds (total: %d tickets from %d holds)",
+			result.Tickets,
+			result.Holds,
+			totalTickets,
+			totalHolds,
+		)
+	}
+	return err
+}
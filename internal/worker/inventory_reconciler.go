@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// InventoryReconciler compares the Redis availability counters with Postgres.
+//
+// A booking consumes its hold in Redis slightly before Postgres commits the
+// decremented available_tickets, so a single observation of drift can be a
+// booking in flight. Drift is therefore only repaired once the same value has
+// been observed on two consecutive sweeps.
+type InventoryReconciler struct {
+	logger       logger.Logger
+	inventorySrv service.InventoryServiceInterface
+
+	// previous drift per event, only touched by the sweep goroutine
+	drifts map[uuid.UUID]int
+}
+
+func NewInventoryReconciler(
+	logger logger.Logger,
+	inventorySrv service.InventoryServiceInterface,
+) *InventoryReconciler {
+	return &InventoryReconciler{
+		logger:       logger,
+		inventorySrv: inventorySrv,
+		drifts:       map[uuid.UUID]int{},
+	}
+}
+
+// Deamon returns a generator that reconciles inventory every interval.
+func (r *InventoryReconciler) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(r.logger, "INVENTORY_RECONCILER", interval, r.sweep)
+}
+
+func (r *InventoryReconciler) sweep(ctx context.Context) error {
+	events, err := r.inventorySrv.ListReconcilableEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	drifts := make(map[uuid.UUID]int, len(r.drifts))
+	var drifted, repaired int
+	for _, event := range events {
+		inventory, err := r.inventorySrv.CheckInventory(ctx, event)
+		if err != nil {
+			r.logger.WithContext(ctx).Errorf("INVENTORY_RECONCILER: failed to check event %s: %v", event.ID, err)
+			continue
+		}
+		// Unseeded counters are rebuilt lazily on the next hold.
+		if !inventory.Seeded || inventory.Drift == 0 {
+			continue
+		}
+		drifted++
+		r.logger.WithContext(ctx).Warnf(
+			"INVENTORY_RECONCILER: event %s drifted by %d (redis: %d, expected: %d, db: %d, held: %d)",
+			event.ID,
+			inventory.Drift,
+			*inventory.RedisAvailable,
+			inventory.ExpectedAvailable,
+			inventory.DBAvailable,
+			inventory.HeldTickets,
+		)
+		if previous, ok := r.drifts[event.ID]; !ok || previous != inventory.Drift {
+			drifts[event.ID] = inventory.Drift
+			continue
+		}
+		if _, err := r.inventorySrv.RepairInventory(ctx, event.ID); err != nil {
+			r.logger.WithContext(ctx).Errorf("INVENTORY_RECONCILER: failed to repair event %s: %v", event.ID, err)
+			drifts[event.ID] = inventory.Drift
+			continue
+		}
+		repaired++
+	}
+	r.drifts = drifts
+
+	if drifted > 0 {
+		r.logger.WithContext(ctx).Infof(
+			"INVENTORY_RECONCILER: checked %d events, %d drifted, %d repaired",
+			len(events),
+			drifted,
+			repaired,
+		)
+	}
+	return nil
+}
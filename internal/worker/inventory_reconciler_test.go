@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/phamdinhha/event-booking-service/config"
+	"github.com/phamdinhha/event-booking-service/internal/dto"
+	"github.com/phamdinhha/event-booking-service/internal/model"
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+)
+
+// driftingInventory reports the next drift of drifts on every check and
+// counts the repairs.
+type driftingInventory struct {
+	service.InventoryServiceInterface
+
+	event   *model.Event
+	drifts  []int
+	repairs int
+}
+
+func (s *driftingInventory) ListReconcilableEvents(context.Context) ([]*model.Event, error) {
+	return []*model.Event{s.event}, nil
+}
+
+func (s *driftingInventory) CheckInventory(context.Context, *model.Event) (*dto.InventoryDTO, error) {
+	drift := s.drifts[0]
+	s.drifts = s.drifts[1:]
+	available := 10 + drift
+	return &dto.InventoryDTO{
+		EventID:           s.event.ID,
+		Seeded:            true,
+		RedisAvailable:    &available,
+		ExpectedAvailable: 10,
+		Drift:             drift,
+	}, nil
+}
+
+func (s *driftingInventory) RepairInventory(context.Context, uuid.UUID) (*dto.InventoryDTO, error) {
+	s.repairs++
+	return &dto.InventoryDTO{}, nil
+}
+
+func TestInventoryReconcilerRepairsStableDrift(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Logger.Level = "fatal"
+	appLogger := logger.NewApiLogger(cfg)
+	appLogger.InitLogger()
+
+	tests := []struct {
+		name    string
+		drifts  []int
+		repairs int
+	}{
+		{"no drift", []int{0, 0, 0}, 0},
+		{"booking in flight", []int{1, 0, 1, 0}, 0},
+		{"changing drift", []int{1, 2, 3}, 0},
+		{"stable drift", []int{2, 2}, 1},
+		{"stable drift after a repair", []int{2, 2, 2}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := &driftingInventory{event: &model.Event{ID: uuid.New()}, drifts: tt.drifts}
+			reconciler := NewInventoryReconciler(appLogger, inventory)
+			for range tt.drifts {
+				if err := reconciler.sweep(context.Background()); err != nil {
+					t.Fatalf("sweep() = %v", err)
+				}
+			}
+			if inventory.repairs != tt.repairs {
+				t.Fatalf("%d repairs, want %d", inventory.repairs, tt.repairs)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// NotificationScheduler periodically sends booking confirmations and the
+// reminders that are due before an event starts.
+type NotificationScheduler struct {
+	logger          logger.Logger
+	notificationSrv service.NotificationServiceInterface
+}
+
+func NewNotificationScheduler(
+	logger logger.Logger,
+	notificationSrv service.NotificationServiceInterface,
+) *NotificationScheduler {
+	return &NotificationScheduler{logger: logger, notificationSrv: notificationSrv}
+}
+
+// Deamon returns a generator that sends due notifications every interval.
+func (s *NotificationScheduler) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(s.logger, "NOTIFICATION_SCHEDULER", interval, s.sweep)
+}
+
+func (s *NotificationScheduler) sweep(ctx context.Context) error {
+	confirmations, confirmErr := s.notificationSrv.SendConfirmations(ctx)
+	if confirmations > 0 {
+		s.logger.WithContext(ctx).Infof("NOTIFICATION_SCHEDULER: sent %d booking confirmations", confirmations)
+	}
+	reminders, remindErr := s.notificationSrv.SendReminders(ctx)
+	if reminders > 0 {
+		s.logger.WithContext(ctx).Infof("NOTIFICATION_SCHEDULER: sent %d event reminders", reminders)
+	}
+	return errors.Join(confirmErr, remindErr)
+}
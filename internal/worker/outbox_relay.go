@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// outboxPurgeInterval is how often published messages past their retention
+// are deleted.
+const outboxPurgeInterval = time.Hour
+
+// OutboxRelay periodically publishes pending outbox messages.
+type OutboxRelay struct {
+	logger    logger.Logger
+	outboxSrv service.OutboxServiceInterface
+	lastPurge time.Time
+}
+
+func NewOutboxRelay(
+	logger logger.Logger,
+	outboxSrv service.OutboxServiceInterface,
+) *OutboxRelay {
+	return &OutboxRelay{logger: logger, outboxSrv: outboxSrv}
+}
+
+// Deamon returns a generator that relays the outbox every interval.
+func (r *OutboxRelay) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(r.logger, "OUTBOX_RELAY", interval, r.sweep)
+}
+
+func (r *OutboxRelay) sweep(ctx context.Context) error {
+	published, err := r.outboxSrv.RelayPending(ctx)
+	if published > 0 {
+		r.logger.WithContext(ctx).Infof("OUTBOX_RELAY: published %d outbox messages", published)
+	}
+	if err != nil {
+		return err
+	}
+
+	if time.Since(r.lastPurge) < outboxPurgeInterval {
+		return nil
+	}
+	purged, err := r.outboxSrv.PurgePublished(ctx)
+	if purged > 0 {
+		r.logger.WithContext(ctx).Infof("OUTBOX_RELAY: purged %d published outbox messages", purged)
+	}
+	if err == nil {
+		r.lastPurge = time.Now()
+	}
+	return err
+}
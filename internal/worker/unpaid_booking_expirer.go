@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// UnpaidBookingExpirer periodically expires bookings whose payment timed out
+// and returns their tickets to inventory.
+type UnpaidBookingExpirer struct {
+	logger     logger.Logger
+	paymentSrv service.PaymentServiceInterface
+}
+
+func NewUnpaidBookingExpirer(
+	logger logger.Logger,
+	paymentSrv service.PaymentServiceInterface,
+) *UnpaidBookingExpirer {
+	return &UnpaidBookingExpirer{logger: logger, paymentSrv: paymentSrv}
+}
+
+// Deamon returns a generator that expires unpaid bookings every interval.
+func (e *UnpaidBookingExpirer) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(e.logger, "UNPAID_BOOKING_EXPIRER", interval, e.sweep)
+}
+
+func (e *UnpaidBookingExpirer) sweep(ctx context.Context) error {
+	expired, err := e.paymentSrv.ExpireUnpaidBookings(ctx)
+	if expired > 0 {
+		e.logger.WithContext(ctx).Infof("UNPAID_BOOKING_EXPIRER: expired %d unpaid bookings", expired)
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/internal/service"
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/utils"
+)
+
+// WaitingRoomAdmitter periodically admits the next batch of queued users of
+// every event with a waiting room.
+type WaitingRoomAdmitter struct {
+	logger         logger.Logger
+	waitingRoomSrv service.WaitingRoomServiceInterface
+}
+
+func NewWaitingRoomAdmitter(
+	logger logger.Logger,
+	waitingRoomSrv service.WaitingRoomServiceInterface,
+) *WaitingRoomAdmitter {
+	return &WaitingRoomAdmitter{logger: logger, waitingRoomSrv: waitingRoomSrv}
+}
+
+// Deamon returns a generator that admits a batch every interval.
+func (a *WaitingRoomAdmitter) Deamon(interval time.Duration) utils.DeamonGenerator {
+	return utils.NewPeriodicDeamon(a.logger, "WAITING_ROOM_ADMITTER", interval, a.sweep)
+}
+
+func (a *WaitingRoomAdmitter) sweep(ctx context.Context) error {
+	admitted, err := a.waitingRoomSrv.AdmitQueued(ctx)
+	if admitted > 0 {
+		a.logger.WithContext(ctx).Infof("WAITING_ROOM_ADMITTER: admitted %d queued users", admitted)
+	}
+	return err
+}
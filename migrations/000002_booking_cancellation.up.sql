@@ -0,0 +1,3 @@
+ALTER TABLE bookings
+    ADD COLUMN cancellation_reason TEXT,
+    ADD COLUMN cancelled_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,15 @@
+CREATE TABLE booking_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    booking_id UUID NOT NULL,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (booking_id) REFERENCES bookings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_booking_status_history_booking_id ON booking_status_history(booking_id);
+
+-- Existing bookings start their history at their current status
+INSERT INTO booking_status_history (booking_id, from_status, to_status, reason, created_at)
+SELECT id, NULL, status, 'backfill', created_at FROM bookings;
@@ -0,0 +1 @@
+CREATE INDEX idx_events_status_end_time ON events(status, end_time);
@@ -0,0 +1,16 @@
+CREATE TABLE webhook_events (
+    id UUID PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    intent_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    error TEXT,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_webhook_events_provider_event ON webhook_events(provider, event_id);
+CREATE INDEX idx_webhook_events_intent_id ON webhook_events(intent_id);
+CREATE INDEX idx_webhook_events_received_at ON webhook_events(received_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications;
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS contact_email;
@@ -0,0 +1,19 @@
+ALTER TABLE bookings
+    ADD COLUMN contact_email VARCHAR(255);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    kind VARCHAR(100) NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_notifications_booking_kind ON notifications(booking_id, kind);
+CREATE INDEX idx_notifications_status ON notifications(status, updated_at);
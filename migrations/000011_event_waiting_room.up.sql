@@ -0,0 +1,2 @@
+ALTER TABLE events
+    ADD COLUMN waiting_room BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_bookings_event_user;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS max_tickets_per_order,
+    DROP COLUMN IF EXISTS max_tickets_per_user;
@@ -0,0 +1,5 @@
+ALTER TABLE events
+    ADD COLUMN max_tickets_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_tickets_per_user >= 0),
+    ADD COLUMN max_tickets_per_order INTEGER NOT NULL DEFAULT 0 CHECK (max_tickets_per_order >= 0);
+
+CREATE INDEX idx_bookings_event_user ON bookings(event_id, user_id);
@@ -0,0 +1,37 @@
+CREATE TABLE ticket_types (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    capacity INTEGER NOT NULL CHECK (capacity >= 0),
+    available_tickets INTEGER NOT NULL CHECK (available_tickets >= 0),
+    sale_starts_at TIMESTAMP WITH TIME ZONE,
+    sale_ends_at TIMESTAMP WITH TIME ZONE,
+    max_tickets_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_tickets_per_user >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_ticket_types_event_name ON ticket_types(event_id, name);
+
+CREATE TABLE booking_items (
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(10, 2) NOT NULL,
+    PRIMARY KEY (booking_id, ticket_type_id)
+);
+
+CREATE INDEX idx_booking_items_ticket_type_id ON booking_items(ticket_type_id);
+
+-- Every existing event sells a single General tier with its own price and
+-- inventory, and every existing booking is a line item of that tier.
+INSERT INTO ticket_types (id, event_id, name, price, capacity, available_tickets, created_at, updated_at)
+SELECT uuid_generate_v4(), id, 'General', price, capacity, GREATEST(available_tickets, 0), created_at, updated_at
+FROM events;
+
+INSERT INTO booking_items (booking_id, ticket_type_id, quantity, unit_price)
+SELECT b.id, t.id, b.quantity, t.price
+FROM bookings b
+JOIN ticket_types t ON t.event_id = b.event_id
+WHERE b.quantity > 0;
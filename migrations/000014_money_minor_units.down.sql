@@ -0,0 +1,20 @@
+ALTER TABLE payments ALTER COLUMN amount TYPE DECIMAL(10, 2) USING amount / CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG',
+        'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    ELSE 100
+END::DECIMAL;
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS total,
+    DROP COLUMN IF EXISTS fees,
+    DROP COLUMN IF EXISTS subtotal,
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE booking_items ALTER COLUMN unit_price TYPE DECIMAL(10, 2) USING unit_price / 100.0;
+
+ALTER TABLE ticket_types ALTER COLUMN price TYPE DECIMAL(10, 2) USING price / 100.0;
+ALTER TABLE ticket_types DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE events ALTER COLUMN price TYPE DECIMAL(10, 2) USING price / 100.0;
+ALTER TABLE events DROP COLUMN IF EXISTS currency;
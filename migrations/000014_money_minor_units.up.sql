@@ -0,0 +1,36 @@
+-- Amounts are stored as integers in the minor units of their ISO 4217
+-- currency. Events so far were priced in the default payment currency, USD.
+ALTER TABLE events ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE events ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE events ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+
+ALTER TABLE ticket_types ADD COLUMN currency CHAR(3);
+UPDATE ticket_types t SET currency = e.currency FROM events e WHERE e.id = t.event_id;
+ALTER TABLE ticket_types ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE ticket_types ALTER COLUMN price TYPE BIGINT USING ROUND(price * 100);
+
+ALTER TABLE booking_items ALTER COLUMN unit_price TYPE BIGINT USING ROUND(unit_price * 100);
+
+-- Bookings keep what the customer was charged at purchase time.
+ALTER TABLE bookings
+    ADD COLUMN currency CHAR(3),
+    ADD COLUMN subtotal BIGINT NOT NULL DEFAULT 0 CHECK (subtotal >= 0),
+    ADD COLUMN fees BIGINT NOT NULL DEFAULT 0 CHECK (fees >= 0),
+    ADD COLUMN total BIGINT NOT NULL DEFAULT 0 CHECK (total >= 0);
+
+UPDATE bookings b SET currency = e.currency FROM events e WHERE e.id = b.event_id;
+UPDATE bookings b SET subtotal = i.subtotal, total = i.subtotal
+FROM (
+    SELECT booking_id, SUM(quantity * unit_price) AS subtotal
+    FROM booking_items
+    GROUP BY booking_id
+) i
+WHERE i.booking_id = b.id;
+ALTER TABLE bookings ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG',
+        'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    ELSE 100
+END);
@@ -0,0 +1,6 @@
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS promo_code_id;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
@@ -0,0 +1,40 @@
+CREATE TABLE promo_codes (
+    id UUID PRIMARY KEY,
+    code VARCHAR(64) NOT NULL,
+    -- NULL applies the code to every event
+    event_id UUID REFERENCES events(id) ON DELETE CASCADE,
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    -- Basis points of the subtotal for percentage codes
+    percent_off INTEGER NOT NULL DEFAULT 0 CHECK (percent_off BETWEEN 0 AND 10000),
+    -- Minor units of currency for fixed codes
+    amount_off BIGINT NOT NULL DEFAULT 0 CHECK (amount_off >= 0),
+    currency CHAR(3),
+    max_redemptions INTEGER NOT NULL DEFAULT 0 CHECK (max_redemptions >= 0),
+    max_redemptions_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_redemptions_per_user >= 0),
+    redemption_count INTEGER NOT NULL DEFAULT 0 CHECK (redemption_count >= 0),
+    min_quantity INTEGER NOT NULL DEFAULT 0 CHECK (min_quantity >= 0),
+    valid_from TIMESTAMP WITH TIME ZONE,
+    valid_until TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (max_redemptions = 0 OR redemption_count <= max_redemptions),
+    CHECK (discount_type <> 'fixed' OR currency IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX idx_promo_codes_code ON promo_codes(code);
+CREATE INDEX idx_promo_codes_event_id ON promo_codes(event_id);
+
+-- One row per booking that still counts against the caps of its code.
+CREATE TABLE promo_redemptions (
+    booking_id UUID PRIMARY KEY REFERENCES bookings(id) ON DELETE CASCADE,
+    promo_code_id UUID NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_promo_redemptions_code_user ON promo_redemptions(promo_code_id, user_id);
+
+ALTER TABLE bookings
+    ADD COLUMN promo_code_id UUID REFERENCES promo_codes(id) ON DELETE SET NULL,
+    ADD COLUMN discount BIGINT NOT NULL DEFAULT 0 CHECK (discount >= 0);
@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+    ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE;
+
+-- Claims made before the lease existed expire right away.
+UPDATE notifications SET claimed_at = updated_at WHERE status = 'sending';
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx/stdlib"
 	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 
 	"github.com/phamdinhha/event-booking-service/config"
 )
@@ -29,10 +31,15 @@ func NewPostgresDB(c *config.Config) (*sqlx.DB, error) {
 		c.Postgres.Password,
 	)
 
-	db, err := sqlx.Connect(c.Postgres.Driver, dataSourceName)
+	// Every query is traced as a child span of the span in its context.
+	sqlDB, err := otelsql.Open(c.Postgres.Driver, dataSourceName,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
 	if err != nil {
 		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, c.Postgres.Driver)
 	db.SetMaxOpenConns(maxOpenConns)
 	db.SetConnMaxLifetime(connMaxLifetime * time.Second)
 	db.SetMaxIdleConns(maxIdleConns)
@@ -1,18 +1,36 @@
 package http_utils
 
+import (
+	"context"
+
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+)
+
 const (
-	SUCCESS               = "SUCCESS"
-	CREATED               = "CREATED"
-	NOT_FOUND             = "NOT_FOUND"
-	INVALID_REQUEST       = "INVALID_REQUEST"
-	INTERNAL_SERVER_ERROR = "INTERNAL_SERVER_ERROR"
-	TIME_OUT              = "TIME_OUT"
+	SUCCESS                 = "SUCCESS"
+	CREATED                 = "CREATED"
+	NOT_FOUND               = "NOT_FOUND"
+	CONFLICT                = "CONFLICT"
+	PAYMENT_REQUIRED        = "PAYMENT_REQUIRED"
+	UNAUTHORIZED            = "UNAUTHORIZED"
+	FORBIDDEN               = "FORBIDDEN"
+	UNPROCESSABLE_ENTITY    = "UNPROCESSABLE_ENTITY"
+	PURCHASE_LIMIT_EXCEEDED = "PURCHASE_LIMIT_EXCEEDED"
+	INVALID_PROMO_CODE      = "INVALID_PROMO_CODE"
+	INSUFFICIENT_ROLE       = "INSUFFICIENT_ROLE"
+	NOT_RESOURCE_OWNER      = "NOT_RESOURCE_OWNER"
+	TOO_MANY_REQUESTS       = "TOO_MANY_REQUESTS"
+	INVALID_REQUEST         = "INVALID_REQUEST"
+	INTERNAL_SERVER_ERROR   = "INTERNAL_SERVER_ERROR"
+	TIME_OUT                = "TIME_OUT"
 )
 
 type Response struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   interface{} `json:"error,omitempty"`
+	// TraceID of a failed request, to look the request up in the traces
+	TraceID string `json:"trace_id,omitempty"`
 }
 
 func NewResponse(message string, data interface{}, err interface{}) Response {
@@ -27,16 +45,18 @@ func NewOKResponse(message string, data interface{}) Response {
 	return NewResponse(message, data, nil)
 }
 
-func NewErrorResponse(message string, err interface{}) Response {
-	return NewResponse(message, nil, err)
+func NewErrorResponse(ctx context.Context, message string, err interface{}) Response {
+	response := NewResponse(message, nil, err)
+	response.TraceID = tracing.TraceID(ctx)
+	return response
 }
 
-func NewBindingErrorResponse(resourceName, err string) Response {
-	return NewErrorResponse(INVALID_REQUEST, err)
+func NewBindingErrorResponse(ctx context.Context, resourceName, err string) Response {
+	return NewErrorResponse(ctx, INVALID_REQUEST, err)
 }
 
-func NewAttributeErrorResponse(err AttributeError) Response {
-	return NewErrorResponse(INVALID_REQUEST, err)
+func NewAttributeErrorResponse(ctx context.Context, err AttributeError) Response {
+	return NewErrorResponse(ctx, INVALID_REQUEST, err)
 }
 
 type AttributeError struct {
@@ -1,8 +1,10 @@
 package logger
 
 import (
+	"context"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -25,6 +27,7 @@ type Logger interface {
 	Fatal(args ...interface{})
 	Fatalf(template string, args ...interface{})
 	Printf(template string, args ...interface{})
+	WithContext(ctx context.Context) Logger
 }
 
 // Logger
@@ -94,6 +97,22 @@ func (l *apiLogger) InitLogger() {
 	}
 }
 
+// WithContext returns a logger adding the trace and span id of the span in
+// ctx to every line, so log lines can be matched with their trace.
+func (l *apiLogger) WithContext(ctx context.Context) Logger {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return l
+	}
+	return &apiLogger{
+		cfg: l.cfg,
+		sugarLogger: l.sugarLogger.With(
+			"TRACE_ID", spanContext.TraceID().String(),
+			"SPAN_ID", spanContext.SpanID().String(),
+		),
+	}
+}
+
 // Logger methods
 
 func (l *apiLogger) Debug(args ...interface{}) {
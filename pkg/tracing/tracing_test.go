@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartAndEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	if got := TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID() without a span = %q, want empty", got)
+	}
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("sold out"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended %d spans, want 2", len(spans))
+	}
+	failed, succeeded := spans[0], spans[1]
+	if failed.Parent().SpanID() != succeeded.SpanContext().SpanID() {
+		t.Error("child span is not a child of the span in ctx")
+	}
+	if got := TraceID(ctx); got != succeeded.SpanContext().TraceID().String() {
+		t.Errorf("TraceID() = %q, want %s", got, succeeded.SpanContext().TraceID())
+	}
+	if failed.Status().Code != codes.Error || failed.Status().Description != "sold out" || len(failed.Events()) != 1 {
+		t.Errorf("failed span status = %+v with %d events, want error sold out with the recorded error",
+			failed.Status(), len(failed.Events()))
+	}
+	if succeeded.Status().Code != codes.Unset || len(succeeded.Events()) != 0 {
+		t.Errorf("span status = %+v with %d events, want unset without events", succeeded.Status(), len(succeeded.Events()))
+	}
+}
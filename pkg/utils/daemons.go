@@ -1,7 +1,73 @@
 package utils
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phamdinhha/event-booking-service/pkg/logger"
+	"github.com/phamdinhha/event-booking-service/pkg/tracing"
+)
 
 type Deamon func()
 
 type DeamonGenerator func(ctx context.Context) (Deamon, error)
+
+// NewPeriodicDeamon returns a generator for a supervised background loop that
+// runs task once on start and then every interval. A failing or panicking task
+// is logged and the loop carries on with the next tick. Every run is traced as
+// a span named name. The returned Deamon stops the loop and waits for the
+// running task to finish.
+func NewPeriodicDeamon(
+	logger logger.Logger,
+	name string,
+	interval time.Duration,
+	task func(ctx context.Context) error,
+) DeamonGenerator {
+	return func(ctx context.Context) (Deamon, error) {
+		if interval <= 0 {
+			return nil, fmt.Errorf("%s: interval must be positive, got %s", name, interval)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				runSupervised(ctx, logger, name, task)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+		logger.Infof("%s started, interval: %s", name, interval)
+		return func() {
+			cancel()
+			<-done
+			logger.Infof("%s stopped", name)
+		}, nil
+	}
+}
+
+func runSupervised(
+	ctx context.Context,
+	logger logger.Logger,
+	name string,
+	task func(ctx context.Context) error,
+) {
+	ctx, span := tracing.Start(ctx, name)
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WithContext(ctx).Errorf("%s panicked: %v", name, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+		tracing.End(span, err)
+	}()
+	if err = task(ctx); err != nil && ctx.Err() == nil {
+		logger.WithContext(ctx).Errorf("%s failed: %v", name, err)
+	}
+}
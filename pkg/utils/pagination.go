@@ -39,7 +39,7 @@ func (q *Pagination) SetSize(sizeQuery string) error {
 // SetPage Set page number
 func (q *Pagination) SetPage(pageQuery string) error {
 	if pageQuery == "" {
-		q.Size = 0
+		q.Page = 1
 		return nil
 	}
 	n, err := strconv.Atoi(pageQuery)
@@ -98,5 +98,5 @@ func (q *Pagination) GetTotalPages(totalCount int) int {
 
 // GetHasMore Get has more
 func (q *Pagination) GetHasMore(totalCount int) bool {
-	return q.GetPage() < totalCount/q.GetSize()
+	return q.GetPage() < q.GetTotalPages(totalCount)
 }
@@ -114,6 +114,58 @@ func ParseBool(
 	return false, nil
 }
 
+func ParseFloat(
+	attribute string,
+	float_str string,
+) (*float64, *http_utils.AttributeError) {
+
+	if float_str != "" {
+		f, err := strconv.ParseFloat(float_str, 64)
+		if err != nil || f < 0 {
+			cause := fmt.Sprintf("invalid number: %s", float_str)
+			if err != nil {
+				cause = err.Error()
+			}
+			return nil, &http_utils.AttributeError{
+				Attribute: attribute,
+				Cause:     cause,
+				Constraint: fmt.Sprintf(
+					"%s must be a valid non-negative number.",
+					attribute,
+				),
+			}
+		}
+		return &f, nil
+	}
+	return nil, nil
+}
+
+func ParseInt64(
+	attribute string,
+	int_str string,
+) (*int64, *http_utils.AttributeError) {
+
+	if int_str != "" {
+		n, err := strconv.ParseInt(int_str, 10, 64)
+		if err != nil || n < 0 {
+			cause := fmt.Sprintf("invalid integer: %s", int_str)
+			if err != nil {
+				cause = err.Error()
+			}
+			return nil, &http_utils.AttributeError{
+				Attribute: attribute,
+				Cause:     cause,
+				Constraint: fmt.Sprintf(
+					"%s must be a valid non-negative integer.",
+					attribute,
+				),
+			}
+		}
+		return &n, nil
+	}
+	return nil, nil
+}
+
 func ParseDate(
 	attribute string,
 	date_str string,